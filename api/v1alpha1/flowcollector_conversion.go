@@ -0,0 +1,217 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/pkg/helper"
+)
+
+// ConversionDataAnnotation holds the spec of the FlowCollector as it was before being converted, so that
+// a round-trip conversion restores the fields that don't exist in the intermediate version, and the exact
+// representation of the fields that have a different type (e.g. v1alpha1 "5" and "5s" cache timeouts are
+// the same v1beta1 duration).
+const ConversionDataAnnotation = "flows.netobserv.io/conversion-data"
+
+var _ conversion.Convertible = &FlowCollector{}
+
+// ConvertTo converts this v1alpha1 FlowCollector to the v1beta1 hub version
+func (r *FlowCollector) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.FlowCollector)
+	r.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+
+	// Fields that only exist in v1beta1 are restored from a previous conversion, if any.
+	// The ones that also exist in v1alpha1 are always overridden, as they might have been modified since then
+	dst.Spec = v1beta1.FlowCollectorSpec{}
+	if _, err := popConversionData(&dst.ObjectMeta, &dst.Spec); err != nil {
+		return err
+	}
+	dst.Spec.Namespace = r.Spec.Namespace
+
+	timeout, err := parseCacheActiveTimeout(r.Spec.IPFIX.CacheActiveTimeout)
+	if err != nil {
+		return err
+	}
+	ipfix := &dst.Spec.Agent.IPFIX
+	ipfix.CacheActiveTimeout = timeout
+	ipfix.CacheMaxFlows = r.Spec.IPFIX.CacheMaxFlows
+	ipfix.Sampling = r.Spec.IPFIX.Sampling
+	ipfix.ClusterNetworkOperator.Namespace = r.Spec.CNO.Namespace
+
+	processor := &dst.Spec.Processor
+	processor.Kind = r.Spec.GoflowKube.Kind
+	processor.Replicas = r.Spec.GoflowKube.Replicas
+	processor.HPA = nil
+	if r.Spec.GoflowKube.HPA != nil {
+		processor.HPA = &v1beta1.FlowCollectorHPA{
+			MinReplicas:                    r.Spec.GoflowKube.HPA.MinReplicas,
+			MaxReplicas:                    r.Spec.GoflowKube.HPA.MaxReplicas,
+			TargetCPUUtilizationPercentage: r.Spec.GoflowKube.HPA.TargetCPUUtilizationPercentage,
+		}
+	}
+	processor.Port = r.Spec.GoflowKube.Port
+	processor.PreferredIPFamily = r.Spec.GoflowKube.PreferredIPFamily
+	processor.Image = r.Spec.GoflowKube.Image
+	processor.ImagePullPolicy = r.Spec.GoflowKube.ImagePullPolicy
+	processor.LogLevel = r.Spec.GoflowKube.LogLevel
+	processor.Resources = r.Spec.GoflowKube.Resources
+	processor.PrintOutput = r.Spec.GoflowKube.PrintOutput
+
+	loki := &dst.Spec.Storage.Loki
+	loki.URL = r.Spec.Loki.URL
+	loki.QuerierURL = r.Spec.Loki.QuerierURL
+	loki.BatchWait = r.Spec.Loki.BatchWait
+	loki.BatchSize = r.Spec.Loki.BatchSize
+	loki.Timeout = r.Spec.Loki.Timeout
+	loki.MinBackoff = r.Spec.Loki.MinBackoff
+	loki.MaxBackoff = r.Spec.Loki.MaxBackoff
+	loki.MaxRetries = r.Spec.Loki.MaxRetries
+	loki.StaticLabels = r.Spec.Loki.StaticLabels
+
+	console := &dst.Spec.Console
+	console.Replicas = r.Spec.ConsolePlugin.Replicas
+	console.Port = r.Spec.ConsolePlugin.Port
+	console.Image = r.Spec.ConsolePlugin.Image
+	console.ImagePullPolicy = r.Spec.ConsolePlugin.ImagePullPolicy
+	console.Resources = r.Spec.ConsolePlugin.Resources
+
+	dst.Status.Namespace = r.Status.Namespace
+	dst.Status.Conditions = r.Status.Conditions
+	dst.Status.OVSNodes = nil
+	for _, node := range r.Status.OVSNodes {
+		dst.Status.OVSNodes = append(dst.Status.OVSNodes, v1beta1.OVSNodeStatus(node))
+	}
+	dst.Status.Certificates = nil
+	for _, cert := range r.Status.Certificates {
+		dst.Status.Certificates = append(dst.Status.Certificates, v1beta1.CertificateStatus(cert))
+	}
+
+	return pushConversionData(&dst.ObjectMeta, &r.Spec)
+}
+
+// ConvertFrom converts the v1beta1 hub version to this v1alpha1 FlowCollector
+func (r *FlowCollector) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.FlowCollector)
+	src.ObjectMeta.DeepCopyInto(&r.ObjectMeta)
+
+	previous := FlowCollectorSpec{}
+	hasPrevious, err := popConversionData(&r.ObjectMeta, &previous)
+	if err != nil {
+		return err
+	}
+	r.Spec = FlowCollectorSpec{Namespace: src.Spec.Namespace}
+
+	ipfix := &src.Spec.Agent.IPFIX
+	r.Spec.IPFIX.CacheActiveTimeout = helper.UnitDuration(ipfix.CacheActiveTimeout.Duration)
+	if hasPrevious {
+		// Keep the previous representation if it is still accurate
+		if prevTimeout, err := parseCacheActiveTimeout(previous.IPFIX.CacheActiveTimeout); err == nil &&
+			prevTimeout == ipfix.CacheActiveTimeout {
+			r.Spec.IPFIX.CacheActiveTimeout = previous.IPFIX.CacheActiveTimeout
+		}
+	}
+	r.Spec.IPFIX.CacheMaxFlows = ipfix.CacheMaxFlows
+	r.Spec.IPFIX.Sampling = ipfix.Sampling
+	r.Spec.CNO.Namespace = ipfix.ClusterNetworkOperator.Namespace
+
+	processor := &src.Spec.Processor
+	r.Spec.GoflowKube.Kind = processor.Kind
+	r.Spec.GoflowKube.Replicas = processor.Replicas
+	r.Spec.GoflowKube.HPA = nil
+	if processor.HPA != nil {
+		r.Spec.GoflowKube.HPA = &FlowCollectorHPA{
+			MinReplicas:                    processor.HPA.MinReplicas,
+			MaxReplicas:                    processor.HPA.MaxReplicas,
+			TargetCPUUtilizationPercentage: processor.HPA.TargetCPUUtilizationPercentage,
+		}
+	}
+	r.Spec.GoflowKube.Port = processor.Port
+	r.Spec.GoflowKube.PreferredIPFamily = processor.PreferredIPFamily
+	r.Spec.GoflowKube.Image = processor.Image
+	r.Spec.GoflowKube.ImagePullPolicy = processor.ImagePullPolicy
+	r.Spec.GoflowKube.LogLevel = processor.LogLevel
+	r.Spec.GoflowKube.Resources = processor.Resources
+	r.Spec.GoflowKube.PrintOutput = processor.PrintOutput
+
+	loki := &src.Spec.Storage.Loki
+	r.Spec.Loki.URL = loki.URL
+	r.Spec.Loki.QuerierURL = loki.QuerierURL
+	r.Spec.Loki.BatchWait = loki.BatchWait
+	r.Spec.Loki.BatchSize = loki.BatchSize
+	r.Spec.Loki.Timeout = loki.Timeout
+	r.Spec.Loki.MinBackoff = loki.MinBackoff
+	r.Spec.Loki.MaxBackoff = loki.MaxBackoff
+	r.Spec.Loki.MaxRetries = loki.MaxRetries
+	r.Spec.Loki.StaticLabels = loki.StaticLabels
+
+	console := &src.Spec.Console
+	r.Spec.ConsolePlugin.Replicas = console.Replicas
+	r.Spec.ConsolePlugin.Port = console.Port
+	r.Spec.ConsolePlugin.Image = console.Image
+	r.Spec.ConsolePlugin.ImagePullPolicy = console.ImagePullPolicy
+	r.Spec.ConsolePlugin.Resources = console.Resources
+
+	r.Status.Namespace = src.Status.Namespace
+	r.Status.Conditions = src.Status.Conditions
+	r.Status.OVSNodes = nil
+	for _, node := range src.Status.OVSNodes {
+		r.Status.OVSNodes = append(r.Status.OVSNodes, OVSNodeStatus(node))
+	}
+	r.Status.Certificates = nil
+	for _, cert := range src.Status.Certificates {
+		r.Status.Certificates = append(r.Status.Certificates, CertificateStatus(cert))
+	}
+
+	return pushConversionData(&r.ObjectMeta, &src.Spec)
+}
+
+// parseCacheActiveTimeout parses a CacheActiveTimeout string, where a missing unit stands for seconds
+func parseCacheActiveTimeout(str string) (metav1.Duration, error) {
+	if str == "" {
+		return metav1.Duration{}, nil
+	}
+	if _, err := strconv.ParseUint(str, 10, 64); err == nil {
+		str += "s"
+	}
+	d, err := time.ParseDuration(str)
+	if err != nil {
+		return metav1.Duration{}, fmt.Errorf("invalid cacheActiveTimeout %q: %w", str, err)
+	}
+	return metav1.Duration{Duration: d}, nil
+}
+
+// pushConversionData stores the provided spec in the conversion data annotation of the converted object
+func pushConversionData(meta *metav1.ObjectMeta, spec interface{}) error {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
+	}
+	meta.Annotations[ConversionDataAnnotation] = string(data)
+	return nil
+}
+
+// popConversionData removes the conversion data annotation from the provided metadata and, if it was
+// present, decodes its content into spec
+func popConversionData(meta *metav1.ObjectMeta, spec interface{}) (bool, error) {
+	data, ok := meta.Annotations[ConversionDataAnnotation]
+	if !ok {
+		return false, nil
+	}
+	delete(meta.Annotations, ConversionDataAnnotation)
+	if len(meta.Annotations) == 0 {
+		meta.Annotations = nil
+	}
+	if err := json.Unmarshal([]byte(data), spec); err != nil {
+		return false, fmt.Errorf("decoding %s annotation: %w", ConversionDataAnnotation, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,136 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	fuzz "github.com/google/gofuzz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/netobserv/network-observability-operator/api/v1beta1"
+)
+
+const fuzzIterations = 500
+
+func fuzzer() *fuzz.Fuzzer {
+	return fuzz.New().NilChance(0.2).Funcs(
+		// durations must be representable both as v1alpha1 strings and as v1beta1 durations
+		func(d *metav1.Duration, c fuzz.Continue) {
+			d.Duration = time.Duration(c.Int63n(int64(time.Hour)))
+		},
+		func(ipfix *FlowCollectorIPFIX, c fuzz.Continue) {
+			c.FuzzNoCustom(ipfix)
+			units := []string{"", "ns", "ms", "s", "m"}
+			ipfix.CacheActiveTimeout = fmt.Sprintf("%d%s", c.Intn(1000)+1, units[c.Intn(len(units))])
+		},
+		// resources aren't converted, only copied: they don't need to be fuzzed
+		func(*corev1.ResourceRequirements, fuzz.Continue) {},
+		// quantities must be in their canonical form to be compared after a JSON round trip: below 1024Mi, they
+		// are not rewritten with a larger unit
+		func(q *resource.Quantity, c fuzz.Continue) {
+			*q = resource.MustParse(fmt.Sprintf("%dMi", c.Intn(1000)+1))
+		},
+		func(t *metav1.Time, c fuzz.Continue) {
+			*t = metav1.Unix(c.Int63n(1<<32), 0)
+		},
+	)
+}
+
+func TestRoundTripFromV1alpha1(t *testing.T) {
+	f := fuzzer()
+	for i := 0; i < fuzzIterations; i++ {
+		original := FlowCollector{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
+		f.Fuzz(&original.Spec)
+		f.Fuzz(&original.Status)
+
+		hub := v1beta1.FlowCollector{}
+		require.NoError(t, original.DeepCopy().ConvertTo(&hub))
+		converted := FlowCollector{}
+		require.NoError(t, converted.ConvertFrom(&hub))
+
+		assert.Equal(t, original.Spec, converted.Spec)
+		assert.Equal(t, original.Status, converted.Status)
+	}
+}
+
+func TestRoundTripFromV1beta1(t *testing.T) {
+	f := fuzzer()
+	for i := 0; i < fuzzIterations; i++ {
+		original := v1beta1.FlowCollector{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
+		f.Fuzz(&original.Spec)
+		f.Fuzz(&original.Status)
+
+		spoke := FlowCollector{}
+		require.NoError(t, spoke.ConvertFrom(original.DeepCopy()))
+		converted := v1beta1.FlowCollector{}
+		require.NoError(t, spoke.ConvertTo(&converted))
+
+		assert.Equal(t, original.Spec, converted.Spec)
+		assert.Equal(t, original.Status, converted.Status)
+	}
+}
+
+func TestConvertFromV1beta1WithoutConversionData(t *testing.T) {
+	hub := v1beta1.FlowCollector{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster", Annotations: map[string]string{"foo": "bar"}},
+		Spec: v1beta1.FlowCollectorSpec{
+			Agent: v1beta1.FlowCollectorAgent{IPFIX: v1beta1.FlowCollectorIPFIX{
+				CacheActiveTimeout:     metav1.Duration{Duration: 90 * time.Second},
+				ClusterNetworkOperator: v1beta1.ClusterNetworkOperator{Namespace: "openshift-network-operator"},
+			}},
+			Processor: v1beta1.FlowCollectorProcessor{Kind: "Deployment", Port: 2055},
+			Storage:   v1beta1.FlowCollectorStorage{Loki: v1beta1.FlowCollectorLoki{URL: "http://loki:3100/"}},
+		},
+	}
+	spoke := FlowCollector{}
+	require.NoError(t, spoke.ConvertFrom(&hub))
+
+	assert.Equal(t, "90s", spoke.Spec.IPFIX.CacheActiveTimeout)
+	assert.Equal(t, "openshift-network-operator", spoke.Spec.CNO.Namespace)
+	assert.Equal(t, "Deployment", spoke.Spec.GoflowKube.Kind)
+	assert.Equal(t, int32(2055), spoke.Spec.GoflowKube.Port)
+	assert.Equal(t, "http://loki:3100/", spoke.Spec.Loki.URL)
+	assert.Equal(t, "bar", spoke.Annotations["foo"])
+	assert.Contains(t, spoke.Annotations, ConversionDataAnnotation)
+	// The source object must not be altered
+	assert.Equal(t, map[string]string{"foo": "bar"}, hub.Annotations)
+}
+
+func TestCacheActiveTimeoutRepresentation(t *testing.T) {
+	spoke := FlowCollector{Spec: FlowCollectorSpec{IPFIX: FlowCollectorIPFIX{CacheActiveTimeout: "5"}}}
+	hub := v1beta1.FlowCollector{}
+	require.NoError(t, spoke.ConvertTo(&hub))
+	assert.Equal(t, 5*time.Second, hub.Spec.Agent.IPFIX.CacheActiveTimeout.Duration)
+
+	// Unchanged value keeps its original representation
+	converted := FlowCollector{}
+	require.NoError(t, converted.ConvertFrom(hub.DeepCopy()))
+	assert.Equal(t, "5", converted.Spec.IPFIX.CacheActiveTimeout)
+
+	// Updated value is formatted from the v1beta1 duration
+	hub.Spec.Agent.IPFIX.CacheActiveTimeout.Duration = 2 * time.Minute
+	require.NoError(t, converted.ConvertFrom(hub.DeepCopy()))
+	assert.Equal(t, "2m", converted.Spec.IPFIX.CacheActiveTimeout)
+}
+
+func TestParseCacheActiveTimeout(t *testing.T) {
+	for str, expected := range map[string]time.Duration{
+		"":       0,
+		"10":     10 * time.Second,
+		"10s":    10 * time.Second,
+		"1500ms": 1500 * time.Millisecond,
+		"2m":     2 * time.Minute,
+		"100ns":  100 * time.Nanosecond,
+	} {
+		d, err := parseCacheActiveTimeout(str)
+		require.NoError(t, err, str)
+		assert.Equal(t, expected, d.Duration, str)
+	}
+	_, err := parseCacheActiveTimeout("10h30")
+	assert.Error(t, err)
+}
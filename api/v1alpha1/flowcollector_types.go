@@ -91,6 +91,13 @@ type FlowCollectorGoflowKube struct {
 	// Port is the collector port: either a service port for Deployment kind, or host port for DaemonSet kind
 	Port int32 `json:"port,omitempty"`
 
+	//+kubebuilder:validation:Enum=IPv4;IPv6
+	// PreferredIPFamily is the IP family of the collector Service address that is used as OVS flows target
+	// in dual-stack clusters, for Deployment kind. If empty, or if the Service has no address of this family,
+	// its primary address is used. Ignored for DaemonSet.
+	// +optional
+	PreferredIPFamily string `json:"preferredIPFamily,omitempty"`
+
 	//+kubebuilder:default:="quay.io/netobserv/goflow2-kube:main"
 	// Image is the collector image (including domain and tag)
 	Image string `json:"image,omitempty"`
@@ -222,11 +229,73 @@ type FlowCollectorStatus struct {
 
 	// Namespace where console plugin and goflowkube have been deployed.
 	Namespace string `json:"namespace,omitempty"`
+
+	// Conditions represent the latest available observations of the FlowCollector components:
+	// Ready (aggregated readiness), CollectorReady, ConsolePluginReady, OVSConfigured and LokiReachable
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// OVSNodes reports the IPFIX configuration state of each node, when OVS is configured by the node agent
+	// (only available in v1beta1)
+	// +optional
+	// +listType=map
+	// +listMapKey=node
+	OVSNodes []OVSNodeStatus `json:"ovsNodes,omitempty"`
+
+	// Certificates reports the state of the serving certificates of the components
+	// (only available in v1beta1)
+	// +optional
+	// +listType=map
+	// +listMapKey=secretName
+	Certificates []CertificateStatus `json:"certificates,omitempty"`
+}
+
+// OVSNodeStatus is the observed IPFIX configuration state of OVS on a node
+type OVSNodeStatus struct {
+	// Node is the node name
+	Node string `json:"node"`
+
+	// Configured tells whether IPFIX export is configured in OVS on this node
+	Configured bool `json:"configured"`
+
+	// Message gives details about the configuration state, in particular when it failed
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// CertificateStatus is the observed state of a serving certificate
+type CertificateStatus struct {
+	// SecretName is the name of the Secret holding the certificate, in the namespace of the components
+	SecretName string `json:"secretName"`
+
+	// Issuer of the certificate: ServiceCA (the OpenShift service CA), CertManager, or Operator when it is generated
+	// by the operator, from its own CA, for lack of another issuer
+	Issuer string `json:"issuer"`
+
+	// NotAfter is the expiry time of the certificate. It is unset until the certificate is issued.
+	// +optional
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+
+	// RenewAfter is the time from which the certificate is rotated, when it is issued by the operator
+	// +optional
+	RenewAfter *metav1.Time `json:"renewAfter,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:resource:scope=Cluster
+//+kubebuilder:deprecatedversion:warning="flows.netobserv.io/v1alpha1 FlowCollector is deprecated, use flows.netobserv.io/v1beta1"
+//+kubebuilder:printcolumn:name="Namespace",type="string",JSONPath=".status.namespace"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+//+kubebuilder:printcolumn:name="Collector",type="string",JSONPath=".status.conditions[?(@.type==\"CollectorReady\")].status"
+//+kubebuilder:printcolumn:name="Console Plugin",type="string",JSONPath=".status.conditions[?(@.type==\"ConsolePluginReady\")].status"
+//+kubebuilder:printcolumn:name="OVS",type="string",JSONPath=".status.conditions[?(@.type==\"OVSConfigured\")].status"
+//+kubebuilder:printcolumn:name="Loki",type="string",JSONPath=".status.conditions[?(@.type==\"LokiReachable\")].status"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // FlowCollector is the Schema for the flowcollectors API
 type FlowCollector struct {
@@ -0,0 +1,4 @@
+package v1beta1
+
+// Hub marks this type as a conversion hub: every other version of FlowCollector is converted from and to v1beta1
+func (*FlowCollector) Hub() {}
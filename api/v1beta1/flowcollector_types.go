@@ -0,0 +1,895 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// FlowCollectorSpec defines the desired state of FlowCollector
+type FlowCollectorSpec struct {
+	// Important: Run "make generate" to regenerate code after modifying this file
+
+	//+kubebuilder:default:=""
+	// Namespace where console plugin and collector pods are going to be deployed.
+	// If empty, the namespace of the operator is going to be used
+	Namespace string `json:"namespace,omitempty"`
+
+	//+kubebuilder:validation:Enum=Managed;Unmanaged;Removed
+	//+kubebuilder:default:=Managed
+	// ManagementState defines how the operator handles all the components. Managed reconciles them according
+	// to their own managementState. Unmanaged stops reconciling them, e.g. to investigate an issue with a manual
+	// change, while their state is still reported. Removed deletes them, the FlowCollector being kept.
+	ManagementState string `json:"managementState,omitempty"`
+
+	// Agent contains settings related to the flows reporter
+	Agent FlowCollectorAgent `json:"agent,omitempty"`
+
+	// Processor contains settings related to the component that receives the flows from the agent,
+	// enriches them and sends them to the storage
+	Processor FlowCollectorProcessor `json:"processor,omitempty"`
+
+	// Kafka contains settings related to the optional Kafka stage between the processor and the storage
+	// +optional
+	Kafka FlowCollectorKafka `json:"kafka,omitempty"`
+
+	// Storage contains settings related to the flows storage
+	Storage FlowCollectorStorage `json:"storage,omitempty"`
+
+	// Exporters are additional destinations of the enriched flows, next to the storage. Several exporters can
+	// be used simultaneously.
+	// +optional
+	Exporters []FlowCollectorExporter `json:"exporters,omitempty"`
+
+	// Console contains settings related to the console dynamic plugin
+	Console FlowCollectorConsole `json:"console,omitempty"`
+
+	// Dashboard contains settings related to the Grafana dashboard of the flows stored in Loki
+	// +optional
+	Dashboard FlowCollectorDashboard `json:"dashboard,omitempty"`
+}
+
+// FlowCollectorDashboard defines the Grafana dashboard of the flows stored in Loki. It is generated from the Loki
+// labels, so that its queries match the stored streams.
+type FlowCollectorDashboard struct {
+	//+kubebuilder:default:=false
+	// Enable creates the dashboard, as a GrafanaDashboard when the Grafana operator API is available, and as a
+	// ConfigMap otherwise. It is ignored when the flows aren't stored in Loki.
+	Enable bool `json:"enable,omitempty"`
+
+	//+kubebuilder:default:="Loki"
+	// Datasource is the name of the Grafana Loki datasource that queries the flows
+	Datasource string `json:"datasource,omitempty"`
+
+	//+kubebuilder:default:={"grafana_dashboard":"1"}
+	// Labels are set on the dashboard ConfigMap or GrafanaDashboard, for its discovery: by default, the Grafana
+	// sidecar loads the ConfigMaps with the grafana_dashboard label, and the Grafana operator the
+	// GrafanaDashboards matching the dashboardLabelSelector of the Grafana instance.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// FlowCollectorExporter defines a destination of the enriched flows
+type FlowCollectorExporter struct {
+	//+kubebuilder:validation:Enum=OpenTelemetry
+	// Type selects the exporter. OpenTelemetry sends the flows as OTLP logs to an OpenTelemetry receiver.
+	Type string `json:"type"`
+
+	// OpenTelemetry contains the settings of the OTLP export, used when type is OpenTelemetry
+	// +optional
+	OpenTelemetry FlowCollectorOpenTelemetry `json:"openTelemetry,omitempty"`
+}
+
+// FlowCollectorOpenTelemetry defines the export of the flows to an OpenTelemetry receiver, such as an
+// OpenTelemetry Collector
+type FlowCollectorOpenTelemetry struct {
+	// Endpoint is the address of the OTLP receiver: host:port for the grpc protocol, or an URL for the http
+	// protocol, e.g. "http://otel-collector:4318/"
+	Endpoint string `json:"endpoint"`
+
+	//+kubebuilder:validation:Enum=grpc;http
+	//+kubebuilder:default:=grpc
+	// Protocol is the OTLP transport: grpc or http
+	Protocol string `json:"protocol,omitempty"`
+
+	// Headers are sent with each export request, e.g. to authenticate against the receiver
+	// +optional
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// TLS client configuration
+	// +optional
+	TLS ClientTLS `json:"tls,omitempty"`
+
+	//+kubebuilder:default:=false
+	// Metrics also exports the bytes and packets of the flows as OTLP metrics, labeled with the source and
+	// destination namespaces and workloads
+	Metrics bool `json:"metrics,omitempty"`
+}
+
+// FlowCollectorAgent defines the desired state of the flows reporter
+type FlowCollectorAgent struct {
+	//+kubebuilder:validation:Enum=Managed;Unmanaged;Removed
+	//+kubebuilder:default:=Managed
+	// ManagementState defines how the operator handles the flows reporter: the OVS IPFIX configuration or the
+	// eBPF agent. It is overridden by spec.managementState unless the latter is Managed.
+	ManagementState string `json:"managementState,omitempty"`
+
+	//+kubebuilder:validation:Enum=IPFIX;EBPF
+	//+kubebuilder:default:=IPFIX
+	// Type selects the flows reporter. IPFIX configures OVS to export flows, which requires the OVN-Kubernetes
+	// or OVS CNI. EBPF deploys an eBPF-based agent on each node, which works with any CNI.
+	Type string `json:"type,omitempty"`
+
+	// IPFIX contains the settings of the OVS IPFIX flows reporter, used when type is IPFIX
+	IPFIX FlowCollectorIPFIX `json:"ipfix,omitempty"`
+
+	// EBPF contains the settings of the eBPF flows reporter, used when type is EBPF
+	EBPF FlowCollectorEBPF `json:"ebpf,omitempty"`
+}
+
+// FlowCollectorIPFIX defines the desired IPFIX state of FlowCollector
+type FlowCollectorIPFIX struct {
+	// Important: Run "make generate" to regenerate code after modifying this file
+
+	//+kubebuilder:default:="10s"
+	// CacheActiveTimeout is the max period during which the reporter will aggregate flows before sending
+	CacheActiveTimeout metav1.Duration `json:"cacheActiveTimeout,omitempty"`
+
+	//+kubebuilder:validation:Minimum=0
+	//+kubebuilder:default:=100
+	// CacheMaxFlows is the max number of flows in an aggregate; when reached, the reporter sends the flows
+	CacheMaxFlows int32 `json:"cacheMaxFlows,omitempty"`
+
+	//+kubebuilder:validation:Minimum=0
+	//+kubebuilder:default:=400
+	// Sampling is the sampling rate on the reporter. 100 means one flow on 100 is sent. 0 means disabled.
+	Sampling int32 `json:"sampling,omitempty"`
+
+	//+kubebuilder:validation:Enum=ClusterNetworkOperator;NodeAgent
+	//+kubebuilder:default:=ClusterNetworkOperator
+	// OVSConfigMode defines how OVS is configured to export IPFIX flows. ClusterNetworkOperator, for OpenShift,
+	// writes a ConfigMap that the cluster network operator applies to OVS. NodeAgent, for other Kubernetes
+	// distributions running OVN-Kubernetes or plain OVS, deploys a privileged DaemonSet that configures OVS
+	// directly on each node through ovs-vsctl.
+	OVSConfigMode string `json:"ovsConfigMode,omitempty"`
+
+	// ClusterNetworkOperator contains settings related to the cluster network operator, which
+	// configures IPFIX in OVS in ClusterNetworkOperator mode
+	ClusterNetworkOperator ClusterNetworkOperator `json:"clusterNetworkOperator,omitempty"`
+
+	// NodeAgent contains settings related to the node agent, which configures IPFIX in OVS in NodeAgent mode
+	NodeAgent OVSNodeAgent `json:"nodeAgent,omitempty"`
+}
+
+// ClusterNetworkOperator defines the desired configuration related to the Cluster Network Configuration
+type ClusterNetworkOperator struct {
+	// Important: Run "make generate" to regenerate code after modifying this file
+
+	//+kubebuilder:default:=openshift-network-operator
+	// Namespace  where the configmap is going to be deployed.
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// OVSNodeAgent defines the desired configuration of the agent that configures OVS on each node
+type OVSNodeAgent struct {
+	// Important: Run "make generate" to regenerate code after modifying this file
+
+	// Image is the node agent image (including domain and tag). It must provide a shell and ovs-vsctl,
+	// e.g. the ovnkube-node image. Required in NodeAgent mode.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	//+kubebuilder:validation:Enum=IfNotPresent;Always;Never
+	//+kubebuilder:default:=IfNotPresent
+	// ImagePullPolicy is the Kubernetes pull policy for the image defined above
+	ImagePullPolicy string `json:"imagePullPolicy,omitempty"`
+
+	//+kubebuilder:default:=br-int
+	// Bridge is the OVS bridge on which IPFIX export is configured
+	Bridge string `json:"bridge,omitempty"`
+
+	//+kubebuilder:default:=/var/run/openvswitch
+	// OVSRunDir is the host directory containing the OVS database socket
+	OVSRunDir string `json:"ovsRunDir,omitempty"`
+}
+
+// FlowCollectorEBPF defines the desired state of the eBPF flows reporter
+type FlowCollectorEBPF struct {
+	// Important: Run "make generate" to regenerate code after modifying this file
+
+	//+kubebuilder:default:="quay.io/netobserv/netobserv-ebpf-agent:main"
+	// Image is the eBPF agent image (including domain and tag)
+	Image string `json:"image,omitempty"`
+
+	//+kubebuilder:validation:Enum=IfNotPresent;Always;Never
+	//+kubebuilder:default:=IfNotPresent
+	// ImagePullPolicy is the Kubernetes pull policy for the image defined above
+	ImagePullPolicy string `json:"imagePullPolicy,omitempty"`
+
+	//+kubebuilder:validation:Minimum=0
+	//+kubebuilder:default:=50
+	// Sampling is the sampling rate on the reporter. 100 means one packet on 100 is taken into account.
+	// 0 or 1 means all the packets are sampled.
+	Sampling int32 `json:"sampling,omitempty"`
+
+	//+kubebuilder:default:="5s"
+	// CacheActiveTimeout is the max period during which the reporter will aggregate flows before sending
+	CacheActiveTimeout metav1.Duration `json:"cacheActiveTimeout,omitempty"`
+
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:default:=1000
+	// CacheMaxFlows is the max number of flows in an aggregate; when reached, the reporter sends the flows
+	CacheMaxFlows int32 `json:"cacheMaxFlows,omitempty"`
+
+	// Interfaces contains the names of the interfaces from where flows will be collected. If empty, the agent
+	// collects flows from all the interfaces of the node, except the ones listed in ExcludeInterfaces.
+	// An entry enclosed by slashes, such as "/br-/", is matched as a regular expression.
+	// +optional
+	Interfaces []string `json:"interfaces,omitempty"`
+
+	//+kubebuilder:default:={"lo"}
+	// ExcludeInterfaces contains the names of the interfaces that are excluded from flow tracing.
+	// An entry enclosed by slashes, such as "/^veth/", is matched as a regular expression.
+	ExcludeInterfaces []string `json:"excludeInterfaces,omitempty"`
+
+	//+kubebuilder:validation:Enum=trace;debug;info;warn;error;fatal;panic
+	//+kubebuilder:default:=info
+	// LogLevel defines the log level for the eBPF agent
+	LogLevel string `json:"logLevel,omitempty"`
+
+	// Compute Resources required by this container.
+	// More info: https://kubernetes.io/docs/concepts/configuration/manage-resources-containers/
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty" protobuf:"bytes,8,opt,name=resources"`
+}
+
+// FlowCollectorProcessor defines the desired state of the flows processor (goflow-kube)
+type FlowCollectorProcessor struct {
+	// Important: Run "make generate" to regenerate code after modifying this file
+
+	//+kubebuilder:validation:Enum=Managed;Unmanaged;Removed
+	//+kubebuilder:default:=Managed
+	// ManagementState defines how the operator handles goflow-kube, including its Kafka consumer. It is
+	// overridden by spec.managementState unless the latter is Managed.
+	ManagementState string `json:"managementState,omitempty"`
+
+	//+kubebuilder:validation:Enum=DaemonSet;Deployment
+	//+kubebuilder:default:=DaemonSet
+	// Kind is the workload kind, either DaemonSet or Deployment
+	Kind string `json:"kind,omitempty"`
+
+	//+kubebuilder:validation:Minimum=0
+	//+kubebuilder:default:=1
+	// Replicas defines the number of replicas (pods) to start for Deployment kind. Ignored for DaemonSet.
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// HPA spec of an horizontal pod autoscaler to set up for the collector Deployment. Ignored for DaemonSet.
+	// +optional
+	HPA *FlowCollectorHPA `json:"hpa,omitempty"`
+
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=65535
+	//+kubebuilder:default:=2055
+	// Port is the collector port: either a service port for Deployment kind, or host port for DaemonSet kind
+	Port int32 `json:"port,omitempty"`
+
+	// Listeners are additional flow listeners, e.g. for physical switches or nodes that export sFlow or
+	// NetFlow v5. Like the main port, each listener is either a service port for Deployment kind, or a host
+	// port for DaemonSet kind.
+	// +optional
+	Listeners []FlowCollectorListener `json:"listeners,omitempty"`
+
+	//+kubebuilder:validation:Enum=IPv4;IPv6
+	// PreferredIPFamily is the IP family of the collector Service address that is used as OVS flows target
+	// in dual-stack clusters, for Deployment kind. If empty, or if the Service has no address of this family,
+	// its primary address is used. Ignored for DaemonSet.
+	// +optional
+	PreferredIPFamily string `json:"preferredIPFamily,omitempty"`
+
+	//+kubebuilder:default:="quay.io/netobserv/goflow2-kube:main"
+	// Image is the collector image (including domain and tag)
+	Image string `json:"image,omitempty"`
+
+	//+kubebuilder:validation:Enum=IfNotPresent;Always;Never
+	//+kubebuilder:default:=IfNotPresent
+	// ImagePullPolicy is the Kubernetes pull policy for the image defined above
+	ImagePullPolicy string `json:"imagePullPolicy,omitempty"`
+
+	//+kubebuilder:validation:Enum=trace;debug;info;warn;error;fatal;panic
+	//+kubebuilder:default:=info
+	// LogLevel defines the log level for the collector runtime
+	LogLevel string `json:"logLevel,omitempty"`
+
+	// Compute Resources required by this container.
+	// Cannot be updated.
+	// More info: https://kubernetes.io/docs/concepts/configuration/manage-resources-containers/
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty" protobuf:"bytes,8,opt,name=resources"`
+
+	//+kubebuilder:default:=false
+	// PrintOutput is a debug flag to print flows exported in kube-enricher logs
+	PrintOutput bool `json:"printOutput,omitempty"`
+
+	// Metrics defines the Prometheus metrics computed from the flows by the processor
+	// +optional
+	Metrics FlowCollectorMetrics `json:"metrics,omitempty"`
+
+	// Monitoring defines the monitoring of the processor itself, through its own metrics and alerts
+	// +optional
+	Monitoring FlowCollectorMonitoring `json:"monitoring,omitempty"`
+
+	// Scheduling defines where the processor pods run, including the Kafka consumer. Unless tolerations are
+	// set, the DaemonSet kind tolerates all taints, to run on every node.
+	// +optional
+	Scheduling FlowCollectorScheduling `json:"scheduling,omitempty"`
+}
+
+// FlowCollectorListener defines a flow listener of the collector
+type FlowCollectorListener struct {
+	//+kubebuilder:validation:Enum=netflow;sflow
+	// Protocol is the flow protocol: netflow receives NetFlow v5, NetFlow v9 and IPFIX; sflow receives sFlow v5
+	Protocol string `json:"protocol"`
+
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=65535
+	// Port is the UDP port of the listener
+	Port int32 `json:"port"`
+}
+
+// FlowCollectorMetrics defines the Prometheus counters computed from the flows. They are exposed by the
+// collector, or by the Kafka consumer when Kafka is enabled.
+type FlowCollectorMetrics struct {
+	//+kubebuilder:default:=false
+	// Enable exposes Prometheus counters aggregated from the flows. A ServiceMonitor is created for them when
+	// the Prometheus operator API is available.
+	Enable bool `json:"enable,omitempty"`
+
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=65535
+	//+kubebuilder:default:=9102
+	// Port is the port exposing the metrics
+	Port int32 `json:"port,omitempty"`
+
+	//+kubebuilder:default:="netobserv_"
+	// Prefix is prepended to the name of the metrics
+	Prefix string `json:"prefix,omitempty"`
+
+	//+kubebuilder:default:={"bytes_total","packets_total"}
+	// IncludeList is the allow-list of the exposed metrics, among: bytes_total (sum of the flows bytes),
+	// packets_total (sum of the flows packets) and flows_total (number of flows)
+	IncludeList []string `json:"includeList,omitempty"`
+
+	//+kubebuilder:default:={"SrcNamespace","SrcWorkload","DstNamespace","DstWorkload"}
+	// Labels is the list of flow fields used as metrics labels. Each distinct combination of values creates a
+	// time series: fields with a high cardinality, such as IPs or ports, are rejected unless
+	// forceHighCardinalityLabels is set.
+	Labels []string `json:"labels,omitempty"`
+
+	// ForceHighCardinalityLabels allows fields with a high cardinality, such as IPs or ports, in labels. This
+	// can severely degrade the Prometheus performance.
+	// +optional
+	ForceHighCardinalityLabels bool `json:"forceHighCardinalityLabels,omitempty"`
+}
+
+// FlowCollectorMonitoring defines the monitoring of the processor, including its Kafka consumer, by the Prometheus
+// operator: its own metrics, such as the received flows and the Loki push errors, are scraped and alerted on.
+type FlowCollectorMonitoring struct {
+	//+kubebuilder:default:=false
+	// Enable exposes the processor metrics, and creates a ServiceMonitor and a PrometheusRule for them when the
+	// Prometheus operator API is available
+	Enable bool `json:"enable,omitempty"`
+
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=65535
+	//+kubebuilder:default:=9090
+	// Port is the port exposing the processor metrics. It must be different from the flow metrics port.
+	Port int32 `json:"port,omitempty"`
+
+	// Alerts defines the thresholds of the alerts of the PrometheusRule
+	// +optional
+	Alerts FlowCollectorAlerts `json:"alerts,omitempty"`
+}
+
+// FlowCollectorAlerts defines the thresholds of the processor alerts
+type FlowCollectorAlerts struct {
+	//+kubebuilder:default:="10m"
+	// NoFlowsFor is the duration without any flow received by the collector after which the NetObservNoFlows
+	// alert fires
+	NoFlowsFor metav1.Duration `json:"noFlowsFor,omitempty"`
+
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=100
+	//+kubebuilder:default:=5
+	// LokiErrorsPercent is the percentage of the flows that fail to be pushed to Loki, over the last 5 minutes,
+	// above which the NetObservLokiErrors alert fires
+	LokiErrorsPercent int32 `json:"lokiErrorsPercent,omitempty"`
+
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:default:=100
+	// UDPDropsPerSecond is the rate of the UDP datagrams dropped by the kernel of a node, because the receive
+	// buffers are full, above which the NetObservUDPDrops alert fires. It is read from the node-exporter metrics.
+	UDPDropsPerSecond int32 `json:"udpDropsPerSecond,omitempty"`
+
+	//+kubebuilder:default:="5m"
+	// NotReadyFor is the duration during which a processor pod isn't ready after which the
+	// NetObservCollectorNotReady alert fires. It is read from the kube-state-metrics metrics.
+	NotReadyFor metav1.Duration `json:"notReadyFor,omitempty"`
+}
+
+type FlowCollectorHPA struct {
+	// minReplicas is the lower limit for the number of replicas to which the autoscaler
+	// can scale down.  It defaults to 1 pod.  minReplicas is allowed to be 0 if the
+	// alpha feature gate HPAScaleToZero is enabled and at least one Object or External
+	// metric is configured.  Scaling is active as long as at least one metric value is
+	// available.
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty" protobuf:"varint,2,opt,name=minReplicas"`
+	// upper limit for the number of pods that can be set by the autoscaler; cannot be smaller than MinReplicas.
+	MaxReplicas int32 `json:"maxReplicas" protobuf:"varint,3,opt,name=maxReplicas"`
+	// target average CPU utilization (represented as a percentage of requested CPU) over all the pods;
+	// if not specified the default autoscaling policy will be used.
+	// +optional
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty" protobuf:"varint,4,opt,name=targetCPUUtilizationPercentage"`
+}
+
+// FlowCollectorKafka defines the desired state of the Kafka stage. When enabled, the processor produces the
+// flows to a Kafka topic, and a separate consumer Deployment reads them, enriches them and sends them to the storage.
+type FlowCollectorKafka struct {
+	//+kubebuilder:default:=false
+	// Enable inserts Kafka between the processor and the storage, so that flows are buffered when the storage
+	// is slow or unavailable
+	Enable bool `json:"enable,omitempty"`
+
+	// Brokers is the list of Kafka bootstrap brokers addresses, as host:port
+	// +optional
+	Brokers []string `json:"brokers,omitempty"`
+
+	//+kubebuilder:default:="network-flows"
+	// Topic is the Kafka topic to use. It must exist, or the brokers must allow its automatic creation.
+	Topic string `json:"topic,omitempty"`
+
+	// TLS client configuration
+	// +optional
+	TLS ClientTLS `json:"tls,omitempty"`
+
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:default:=3
+	// ConsumerReplicas defines the number of replicas (pods) of the consumer Deployment. Setting it to the number
+	// of partitions of the topic makes the most of them.
+	ConsumerReplicas int32 `json:"consumerReplicas,omitempty"`
+}
+
+// ClientTLS defines the TLS client configuration
+type ClientTLS struct {
+	//+kubebuilder:default:=false
+	// Enable TLS
+	Enable bool `json:"enable,omitempty"`
+
+	//+kubebuilder:default:=false
+	// InsecureSkipVerify allows skipping the verification of the server certificate.
+	// If set to true, CACert is ignored.
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+
+	// CACert is the reference of the certificate of the Certificate Authority
+	// +optional
+	CACert CertificateReference `json:"caCert,omitempty"`
+
+	// UserCert is the reference of the client certificate, used for mutual TLS. Leave it empty for one-way TLS.
+	// +optional
+	UserCert CertificateReference `json:"userCert,omitempty"`
+}
+
+// CertificateReference is the reference of a certificate stored in a ConfigMap or a Secret
+type CertificateReference struct {
+	//+kubebuilder:validation:Enum=configmap;secret
+	//+kubebuilder:default:=secret
+	// Type is the kind of object holding the certificate: configmap or secret
+	Type string `json:"type,omitempty"`
+
+	// Name of the ConfigMap or Secret holding the certificate. It must be in the namespace where the
+	// collector is deployed.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// CertFile is the name of the certificate file within the ConfigMap or Secret
+	// +optional
+	CertFile string `json:"certFile,omitempty"`
+
+	// CertKey is the name of the private key file within the ConfigMap or Secret. Leave it empty when there is
+	// no key, such as for a CA certificate.
+	// +optional
+	CertKey string `json:"certKey,omitempty"`
+}
+
+// FlowCollectorStorage defines the desired state of the flows storage
+type FlowCollectorStorage struct {
+	// Loki contains settings related to the loki client
+	Loki FlowCollectorLoki `json:"loki,omitempty"`
+}
+
+// FlowCollectorLoki defines the desired state for FlowCollector's Loki client
+type FlowCollectorLoki struct {
+	//+kubebuilder:default:=true
+	// Enable stores the flows in Loki. It can be disabled when the flows are only exported as metrics, in
+	// which case the other Loki settings are ignored.
+	Enable *bool `json:"enable,omitempty"`
+
+	//+kubebuilder:validation:Enum=Manual;LokiStack;Deploy
+	//+kubebuilder:default:=Manual
+	// Mode is the way the connection to Loki is configured. In Manual mode, the URLs, tenant, TLS and
+	// authentication settings are used as defined. In LokiStack mode, they are ignored: flows are pushed and
+	// queried through the gateway of the LokiStack referenced in lokiStack, in its "network" tenant, with the
+	// service account token of each component. In Deploy mode, they are ignored too: the operator deploys a
+	// single-binary Loki, configured in deploy, and connects the components to it. It is meant for development
+	// and small clusters.
+	Mode string `json:"mode,omitempty"`
+
+	// LokiStack references the LokiStack to use in LokiStack mode
+	// +optional
+	LokiStack LokiStackRef `json:"lokiStack,omitempty"`
+
+	// Deploy defines the Loki deployed by the operator in Deploy mode
+	// +optional
+	Deploy FlowCollectorLokiDeploy `json:"deploy,omitempty"`
+
+	//+kubebuilder:default:="http://loki:3100/"
+	// URL is the address of an existing Loki service to push the flows to.
+	URL string `json:"url,omitempty"`
+
+	//+kubebuilder:validation:optional
+	// QuerierURL specifies the address of the Loki querier service, in case it is different from the
+	// Loki ingester URL. If empty, the URL value will be used (assuming that the Loki ingester
+	// and querier are in the same host).
+	QuerierURL string `json:"querierUrl,omitempty"`
+
+	//+kubebuilder:default:="1s"
+	// BatchWait is max time to wait before sending a batch
+	BatchWait metav1.Duration `json:"batchWait,omitempty"`
+
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:default:=102400
+	// BatchSize is max batch size (in bytes) of logs to accumulate before sending
+	BatchSize int64 `json:"batchSize,omitempty"`
+
+	//+kubebuilder:default:="10s"
+	// Timeout is the maximum time connection / request limit
+	// A Timeout of zero means no timeout.
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+
+	//+kubebuilder:default:="1s"
+	// MinBackoff is the initial backoff time for client connection between retries
+	MinBackoff metav1.Duration `json:"minBackoff,omitempty"`
+
+	//+kubebuilder:default:="300s"
+	// MaxBackoff is the maximum backoff time for client connection between retries
+	MaxBackoff metav1.Duration `json:"maxBackoff,omitempty"`
+
+	//+kubebuilder:validation:Minimum=0
+	//+kubebuilder:default:=10
+	// MaxRetries is the maximum number of retries for client connections
+	MaxRetries int32 `json:"maxRetries,omitempty"`
+
+	//+kubebuilder:default:={"app":"netobserv-flowcollector"}
+	// StaticLabels is a map of common labels to set on each flow
+	StaticLabels map[string]string `json:"staticLabels,omitempty"`
+
+	//+kubebuilder:default:={"SrcNamespace","SrcWorkload","DstNamespace","DstWorkload"}
+	// Labels is the list of flow fields used as Loki stream labels, in addition to the static labels. Each
+	// distinct combination of values creates a stream: removing labels reduces the number of streams in large
+	// clusters, while adding labels makes queries filtering on them more efficient. Fields with a high
+	// cardinality, such as IPs or ports, are rejected unless forceHighCardinalityLabels is set.
+	Labels []string `json:"labels,omitempty"`
+
+	// ForceHighCardinalityLabels allows fields with a high cardinality, such as IPs or ports, in labels. This
+	// can severely degrade the Loki performance.
+	// +optional
+	ForceHighCardinalityLabels bool `json:"forceHighCardinalityLabels,omitempty"`
+
+	// TLS client configuration, used both to push and to query the flows
+	// +optional
+	TLS ClientTLS `json:"tls,omitempty"`
+
+	// Auth defines the authentication to Loki, used both to push and to query the flows
+	// +optional
+	Auth LokiAuth `json:"auth,omitempty"`
+
+	// TenantID is the Loki tenant, sent in the X-Scope-OrgID header when pushing and querying the flows.
+	// It is not sent when empty.
+	// +optional
+	TenantID string `json:"tenantID,omitempty"`
+}
+
+// FlowCollectorLokiDeploy defines the single-binary Loki deployed by the operator
+type FlowCollectorLokiDeploy struct {
+	//+kubebuilder:default:="grafana/loki:2.4.1"
+	// Image is the Loki image
+	Image string `json:"image,omitempty"`
+
+	//+kubebuilder:validation:Enum=IfNotPresent;Always;Never
+	//+kubebuilder:default:=IfNotPresent
+	// ImagePullPolicy is the Kubernetes pull policy for the image defined above
+	ImagePullPolicy string `json:"imagePullPolicy,omitempty"`
+
+	//+kubebuilder:default:="10Gi"
+	// StorageSize is the size of the PersistentVolumeClaim where the flows are stored. It can only be
+	// increased, if the storage class allows volume expansion.
+	StorageSize resource.Quantity `json:"storageSize,omitempty"`
+
+	// StorageClassName is the storage class of the PersistentVolumeClaim. If empty, the default storage
+	// class is used.
+	// +optional
+	StorageClassName string `json:"storageClassName,omitempty"`
+
+	//+kubebuilder:default:="24h"
+	// RetentionPeriod is the time after which the flows are deleted. It must be a multiple of 24h.
+	RetentionPeriod metav1.Duration `json:"retentionPeriod,omitempty"`
+
+	// Compute Resources required by this container.
+	// More info: https://kubernetes.io/docs/concepts/configuration/manage-resources-containers/
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// LokiStackRef references a LokiStack, as deployed by the Loki operator
+type LokiStackRef struct {
+	// Name of the LokiStack
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Namespace of the LokiStack. If empty, the namespace where the collector is deployed is used.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// LokiAuth defines the authentication to Loki
+type LokiAuth struct {
+	//+kubebuilder:validation:Enum=None;BearerToken;ServiceAccountToken;BasicAuth
+	//+kubebuilder:default:=None
+	// Type is the authentication method. BearerToken sends the token read from a Secret, ServiceAccountToken
+	// sends the token of the service account of each component, and BasicAuth sends the username and password
+	// read from a Secret.
+	Type string `json:"type,omitempty"`
+
+	// SecretName is the name of the Secret holding the credentials, when type is BearerToken or BasicAuth.
+	// It must be in the namespace where the collector is deployed.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	//+kubebuilder:default:="token"
+	// TokenKey is the key of the token in the Secret, when type is BearerToken
+	TokenKey string `json:"tokenKey,omitempty"`
+
+	//+kubebuilder:default:="username"
+	// UsernameKey is the key of the username in the Secret, when type is BasicAuth
+	UsernameKey string `json:"usernameKey,omitempty"`
+
+	//+kubebuilder:default:="password"
+	// PasswordKey is the key of the password in the Secret, when type is BasicAuth
+	PasswordKey string `json:"passwordKey,omitempty"`
+}
+
+// FlowCollectorConsole defines the desired state of the console dynamic plugin
+type FlowCollectorConsole struct {
+	// Important: Run "make generate" to regenerate code after modifying this file
+
+	//+kubebuilder:validation:Enum=Managed;Unmanaged;Removed
+	//+kubebuilder:default:=Managed
+	// ManagementState defines how the operator handles the console plugin. It is overridden by
+	// spec.managementState unless the latter is Managed.
+	ManagementState string `json:"managementState,omitempty"`
+
+	//+kubebuilder:validation:Minimum=0
+	//+kubebuilder:default:=1
+	// Replicas defines the number of replicas (pods) to start.
+	Replicas int32 `json:"replicas,omitempty"`
+
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=65535
+	//+kubebuilder:default:=9001
+	// Port is the plugin service port
+	Port int32 `json:"port,omitempty"`
+
+	//+kubebuilder:default:="quay.io/netobserv/network-observability-console-plugin:main"
+	// Image is the plugin image (including domain and tag)
+	Image string `json:"image,omitempty"`
+
+	//+kubebuilder:validation:Enum=IfNotPresent;Always;Never
+	//+kubebuilder:default:=IfNotPresent
+	// ImagePullPolicy is the Kubernetes pull policy for the image defined above
+	ImagePullPolicy string `json:"imagePullPolicy,omitempty"`
+
+	// Compute Resources required by this container.
+	// Cannot be updated.
+	// More info: https://kubernetes.io/docs/concepts/configuration/manage-resources-containers/
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty" protobuf:"bytes,8,opt,name=resources"`
+
+	// Scheduling defines where the plugin pods run
+	// +optional
+	Scheduling FlowCollectorScheduling `json:"scheduling,omitempty"`
+
+	// Standalone exposes the plugin through an Ingress, as a web application, instead of registering it in the
+	// OpenShift console. It allows to browse the flows on clusters without the OpenShift console.
+	// +optional
+	Standalone FlowCollectorStandalone `json:"standalone,omitempty"`
+}
+
+// FlowCollectorStandalone defines the standalone mode of the console plugin
+type FlowCollectorStandalone struct {
+	//+kubebuilder:default:=false
+	// Enable deploys the plugin as a web application, exposed through an Ingress, even when the OpenShift console
+	// is not available. The plugin is then not registered in the console.
+	Enable bool `json:"enable,omitempty"`
+
+	// Ingress defines how the plugin is exposed
+	// +optional
+	Ingress FlowCollectorIngress `json:"ingress,omitempty"`
+
+	// CertManagerIssuer, when set, references the cert-manager issuer of the plugin serving certificate. Otherwise,
+	// the certificate is issued by the OpenShift service CA.
+	// +optional
+	CertManagerIssuer *CertManagerIssuerReference `json:"certManagerIssuer,omitempty"`
+}
+
+// FlowCollectorIngress defines the Ingress of the standalone plugin
+type FlowCollectorIngress struct {
+	// Host is the host name the plugin is served on. All hosts are matched when empty.
+	// +optional
+	Host string `json:"host,omitempty"`
+
+	// IngressClassName is the name of the IngressClass of the Ingress. The default class is used when empty.
+	// +optional
+	IngressClassName string `json:"ingressClassName,omitempty"`
+
+	// Annotations are added to the Ingress, e.g. to tell the ingress controller that the plugin is served over
+	// HTTPS, or to require an authentication
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// CertManagerIssuerReference references a cert-manager Issuer or ClusterIssuer
+type CertManagerIssuerReference struct {
+	// Name of the issuer
+	Name string `json:"name"`
+
+	//+kubebuilder:validation:Enum=Issuer;ClusterIssuer
+	//+kubebuilder:default:=Issuer
+	// Kind of the issuer: Issuer, in the namespace of the components, or ClusterIssuer
+	Kind string `json:"kind,omitempty"`
+}
+
+// FlowCollectorScheduling defines the scheduling constraints of pods
+type FlowCollectorScheduling struct {
+	// NodeSelector restricts the pods to the nodes that have all these labels
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Affinity defines the node, pod and pod anti-affinity scheduling constraints of the pods
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// Tolerations allow the pods to run on nodes with matching taints
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// TopologySpreadConstraints define how the pods are spread across topology domains, such as zones.
+	// Ignored by the DaemonSet kind.
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// PriorityClassName is the priority class of the pods, e.g. system-node-critical to avoid their eviction
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+}
+
+// FlowCollectorStatus defines the observed state of FlowCollector
+type FlowCollectorStatus struct {
+	// Important: Run "make" to regenerate code after modifying this file
+
+	// Namespace where console plugin and goflowkube have been deployed.
+	Namespace string `json:"namespace,omitempty"`
+
+	// Conditions represent the latest available observations of the FlowCollector components:
+	// Ready (aggregated readiness), CollectorReady, ConsolePluginReady, OVSConfigured or EBPFAgentReady
+	// according to the agent type, and LokiReachable
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// OVSNodes reports the IPFIX configuration state of each node, when OVS is configured by the node agent
+	// +optional
+	// +listType=map
+	// +listMapKey=node
+	OVSNodes []OVSNodeStatus `json:"ovsNodes,omitempty"`
+
+	// Certificates reports the state of the serving certificates of the components
+	// +optional
+	// +listType=map
+	// +listMapKey=secretName
+	Certificates []CertificateStatus `json:"certificates,omitempty"`
+}
+
+// OVSNodeStatus is the observed IPFIX configuration state of OVS on a node
+type OVSNodeStatus struct {
+	// Node is the node name
+	Node string `json:"node"`
+
+	// Configured tells whether IPFIX export is configured in OVS on this node
+	Configured bool `json:"configured"`
+
+	// Message gives details about the configuration state, in particular when it failed
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// CertificateStatus is the observed state of a serving certificate
+type CertificateStatus struct {
+	// SecretName is the name of the Secret holding the certificate, in the namespace of the components
+	SecretName string `json:"secretName"`
+
+	// Issuer of the certificate: ServiceCA (the OpenShift service CA), CertManager, or Operator when it is generated
+	// by the operator, from its own CA, for lack of another issuer
+	Issuer string `json:"issuer"`
+
+	// NotAfter is the expiry time of the certificate. It is unset until the certificate is issued.
+	// +optional
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+
+	// RenewAfter is the time from which the certificate is rotated, when it is issued by the operator
+	// +optional
+	RenewAfter *metav1.Time `json:"renewAfter,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:storageversion
+//+kubebuilder:printcolumn:name="Namespace",type="string",JSONPath=".status.namespace"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+//+kubebuilder:printcolumn:name="Collector",type="string",JSONPath=".status.conditions[?(@.type==\"CollectorReady\")].status"
+//+kubebuilder:printcolumn:name="Console Plugin",type="string",JSONPath=".status.conditions[?(@.type==\"ConsolePluginReady\")].status"
+//+kubebuilder:printcolumn:name="OVS",type="string",JSONPath=".status.conditions[?(@.type==\"OVSConfigured\")].status"
+//+kubebuilder:printcolumn:name="eBPF Agent",type="string",JSONPath=".status.conditions[?(@.type==\"EBPFAgentReady\")].status"
+//+kubebuilder:printcolumn:name="Loki",type="string",JSONPath=".status.conditions[?(@.type==\"LokiReachable\")].status"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// FlowCollector is the Schema for the flowcollectors API
+type FlowCollector struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FlowCollectorSpec   `json:"spec,omitempty"`
+	Status FlowCollectorStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// FlowCollectorList contains a list of FlowCollector
+type FlowCollectorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FlowCollector `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FlowCollector{}, &FlowCollectorList{})
+}
@@ -0,0 +1,553 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var flowcollectorlog = logf.Log.WithName("flowcollector-resource")
+
+// SetupWebhookWithManager registers the defaulting, validating and conversion webhooks. Requests made
+// with other API versions are converted to v1beta1 before being defaulted and validated.
+func (r *FlowCollector) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-flows-netobserv-io-v1beta1-flowcollector,mutating=true,failurePolicy=fail,sideEffects=None,groups=flows.netobserv.io,resources=flowcollectors,verbs=create;update,versions=v1beta1,name=mflowcollector.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Defaulter = &FlowCollector{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type.
+// Most defaults are set by the CRD schema; they are applied here again for fields that can't be
+// legitimately empty, so that the reconciled spec is consistent whatever the way it was created.
+func (r *FlowCollector) Default() {
+	flowcollectorlog.Info("default", "name", r.Name)
+
+	spec := &r.Spec
+	defaultString(&spec.ManagementState, "Managed")
+	defaultString(&spec.Agent.ManagementState, "Managed")
+	defaultString(&spec.Agent.Type, "IPFIX")
+	defaultDuration(&spec.Agent.IPFIX.CacheActiveTimeout, 10*time.Second)
+	defaultString(&spec.Agent.IPFIX.OVSConfigMode, "ClusterNetworkOperator")
+	defaultString(&spec.Agent.IPFIX.ClusterNetworkOperator.Namespace, "openshift-network-operator")
+	defaultString(&spec.Agent.IPFIX.NodeAgent.ImagePullPolicy, "IfNotPresent")
+	defaultString(&spec.Agent.IPFIX.NodeAgent.Bridge, "br-int")
+	defaultString(&spec.Agent.IPFIX.NodeAgent.OVSRunDir, "/var/run/openvswitch")
+
+	ebpf := &spec.Agent.EBPF
+	defaultString(&ebpf.Image, "quay.io/netobserv/netobserv-ebpf-agent:main")
+	defaultString(&ebpf.ImagePullPolicy, "IfNotPresent")
+	defaultDuration(&ebpf.CacheActiveTimeout, 5*time.Second)
+	defaultInt32(&ebpf.CacheMaxFlows, 1000)
+	if ebpf.ExcludeInterfaces == nil {
+		ebpf.ExcludeInterfaces = []string{"lo"}
+	}
+	defaultString(&ebpf.LogLevel, "info")
+
+	defaultString(&spec.Processor.ManagementState, "Managed")
+	defaultString(&spec.Processor.Kind, "DaemonSet")
+	defaultInt32(&spec.Processor.Port, 2055)
+	defaultString(&spec.Processor.Image, "quay.io/netobserv/goflow2-kube:main")
+	defaultString(&spec.Processor.ImagePullPolicy, "IfNotPresent")
+	defaultString(&spec.Processor.LogLevel, "info")
+	if spec.Processor.HPA != nil && spec.Processor.HPA.MinReplicas == nil {
+		minReplicas := int32(1)
+		spec.Processor.HPA.MinReplicas = &minReplicas
+	}
+	metrics := &spec.Processor.Metrics
+	defaultInt32(&metrics.Port, 9102)
+	defaultString(&metrics.Prefix, "netobserv_")
+	if metrics.IncludeList == nil {
+		metrics.IncludeList = []string{"bytes_total", "packets_total"}
+	}
+	if metrics.Labels == nil {
+		metrics.Labels = []string{"SrcNamespace", "SrcWorkload", "DstNamespace", "DstWorkload"}
+	}
+
+	monitoring := &spec.Processor.Monitoring
+	defaultInt32(&monitoring.Port, 9090)
+	defaultDuration(&monitoring.Alerts.NoFlowsFor, 10*time.Minute)
+	defaultInt32(&monitoring.Alerts.LokiErrorsPercent, 5)
+	defaultInt32(&monitoring.Alerts.UDPDropsPerSecond, 100)
+	defaultDuration(&monitoring.Alerts.NotReadyFor, 5*time.Minute)
+
+	kafka := &spec.Kafka
+	defaultString(&kafka.Topic, "network-flows")
+	defaultCertificateReference(&kafka.TLS.CACert)
+	defaultCertificateReference(&kafka.TLS.UserCert)
+	defaultInt32(&kafka.ConsumerReplicas, 3)
+
+	loki := &spec.Storage.Loki
+	if loki.Enable == nil {
+		enable := true
+		loki.Enable = &enable
+	}
+	defaultString(&loki.Mode, "Manual")
+	defaultString(&loki.URL, "http://loki:3100/")
+	defaultDuration(&loki.BatchWait, time.Second)
+	if loki.BatchSize == 0 {
+		loki.BatchSize = 102400
+	}
+	defaultDuration(&loki.Timeout, 10*time.Second)
+	defaultDuration(&loki.MinBackoff, time.Second)
+	defaultDuration(&loki.MaxBackoff, 300*time.Second)
+	if loki.StaticLabels == nil {
+		loki.StaticLabels = map[string]string{"app": "netobserv-flowcollector"}
+	}
+	if loki.Labels == nil {
+		loki.Labels = []string{"SrcNamespace", "SrcWorkload", "DstNamespace", "DstWorkload"}
+	}
+	defaultCertificateReference(&loki.TLS.CACert)
+	defaultCertificateReference(&loki.TLS.UserCert)
+	defaultString(&loki.Auth.Type, "None")
+	defaultString(&loki.Auth.TokenKey, "token")
+	defaultString(&loki.Auth.UsernameKey, "username")
+	defaultString(&loki.Auth.PasswordKey, "password")
+	defaultString(&loki.Deploy.Image, "grafana/loki:2.4.1")
+	defaultString(&loki.Deploy.ImagePullPolicy, "IfNotPresent")
+	if loki.Deploy.StorageSize.IsZero() {
+		loki.Deploy.StorageSize = resource.MustParse("10Gi")
+	}
+	defaultDuration(&loki.Deploy.RetentionPeriod, 24*time.Hour)
+
+	for i := range spec.Exporters {
+		otlp := &spec.Exporters[i].OpenTelemetry
+		defaultString(&otlp.Protocol, "grpc")
+		defaultCertificateReference(&otlp.TLS.CACert)
+		defaultCertificateReference(&otlp.TLS.UserCert)
+	}
+
+	defaultString(&spec.Console.ManagementState, "Managed")
+	defaultInt32(&spec.Console.Port, 9001)
+	defaultString(&spec.Console.Image, "quay.io/netobserv/network-observability-console-plugin:main")
+	defaultString(&spec.Console.ImagePullPolicy, "IfNotPresent")
+	if issuer := spec.Console.Standalone.CertManagerIssuer; issuer != nil {
+		defaultString(&issuer.Kind, "Issuer")
+	}
+
+	defaultString(&spec.Dashboard.Datasource, "Loki")
+	if spec.Dashboard.Labels == nil {
+		spec.Dashboard.Labels = map[string]string{"grafana_dashboard": "1"}
+	}
+}
+
+func defaultString(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
+func defaultInt32(field *int32, value int32) {
+	if *field == 0 {
+		*field = value
+	}
+}
+
+func defaultCertificateReference(ref *CertificateReference) {
+	defaultString(&ref.Type, "secret")
+}
+
+func defaultDuration(field *metav1.Duration, value time.Duration) {
+	if field.Duration == 0 {
+		field.Duration = value
+	}
+}
+
+//+kubebuilder:webhook:path=/validate-flows-netobserv-io-v1beta1-flowcollector,mutating=false,failurePolicy=fail,sideEffects=None,groups=flows.netobserv.io,resources=flowcollectors,verbs=create;update,versions=v1beta1,name=vflowcollector.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &FlowCollector{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *FlowCollector) ValidateCreate() error {
+	flowcollectorlog.Info("validate create", "name", r.Name)
+	return r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *FlowCollector) ValidateUpdate(old runtime.Object) error {
+	flowcollectorlog.Info("validate update", "name", r.Name)
+	if oldFC, ok := old.(*FlowCollector); ok && equality.Semantic.DeepEqual(oldFC.Spec, r.Spec) {
+		// Resources created before the validation was introduced must remain updatable, e.g. for the
+		// storage version migration, as long as their spec isn't modified
+		return nil
+	}
+	return r.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *FlowCollector) ValidateDelete() error {
+	// Nothing to validate on deletion
+	return nil
+}
+
+func (r *FlowCollector) validate() error {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+	if r.Spec.Agent.Type == "EBPF" {
+		allErrs = append(allErrs, validateEBPF(&r.Spec.Agent.EBPF, specPath.Child("agent", "ebpf"))...)
+	} else {
+		allErrs = append(allErrs, validateIPFIX(&r.Spec.Agent.IPFIX, specPath.Child("agent", "ipfix"))...)
+	}
+	allErrs = append(allErrs, validateProcessor(&r.Spec.Processor, specPath.Child("processor"))...)
+	if r.Spec.Kafka.Enable {
+		allErrs = append(allErrs, validateKafka(&r.Spec.Kafka, specPath.Child("kafka"))...)
+	}
+	if loki := &r.Spec.Storage.Loki; loki.Enable == nil || *loki.Enable {
+		allErrs = append(allErrs, validateLoki(loki, specPath.Child("storage", "loki"))...)
+	} else if !r.Spec.Processor.Metrics.Enable && len(r.Spec.Exporters) == 0 {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("storage", "loki", "enable"), false,
+			"flows must be stored in Loki, exported as metrics or sent to an exporter: "+
+				"enable spec.processor.metrics or set spec.exporters"))
+	}
+	for i := range r.Spec.Exporters {
+		allErrs = append(allErrs, validateExporter(&r.Spec.Exporters[i], specPath.Child("exporters").Index(i))...)
+	}
+	if r.Spec.Agent.Type != "EBPF" && r.Spec.Agent.IPFIX.OVSConfigMode != "NodeAgent" && r.Spec.Namespace != "" &&
+		r.Spec.Namespace == r.Spec.Agent.IPFIX.ClusterNetworkOperator.Namespace {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("namespace"), r.Spec.Namespace,
+			"must be different from spec.agent.ipfix.clusterNetworkOperator.namespace"))
+	}
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("FlowCollector").GroupKind(), r.Name, allErrs)
+}
+
+func validateIPFIX(ipfix *FlowCollectorIPFIX, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if ipfix.CacheActiveTimeout.Duration <= 0 {
+		errs = append(errs, field.Invalid(path.Child("cacheActiveTimeout"), ipfix.CacheActiveTimeout.Duration.String(),
+			"must be greater than zero"))
+	}
+	if ipfix.OVSConfigMode == "NodeAgent" && ipfix.NodeAgent.Image == "" {
+		errs = append(errs, field.Required(path.Child("nodeAgent", "image"), "must be set when ovsConfigMode is NodeAgent"))
+	}
+	return errs
+}
+
+func validateEBPF(ebpf *FlowCollectorEBPF, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if ebpf.CacheActiveTimeout.Duration <= 0 {
+		errs = append(errs, field.Invalid(path.Child("cacheActiveTimeout"), ebpf.CacheActiveTimeout.Duration.String(),
+			"must be greater than zero"))
+	}
+	for i, itf := range ebpf.Interfaces {
+		if err := validateInterface(itf); err != "" {
+			errs = append(errs, field.Invalid(path.Child("interfaces").Index(i), itf, err))
+		}
+	}
+	for i, itf := range ebpf.ExcludeInterfaces {
+		if err := validateInterface(itf); err != "" {
+			errs = append(errs, field.Invalid(path.Child("excludeInterfaces").Index(i), itf, err))
+		}
+	}
+	return errs
+}
+
+// validateInterface returns an error message if the provided interface name, or regular expression when enclosed
+// by slashes, is invalid
+func validateInterface(itf string) string {
+	if len(itf) > 2 && strings.HasPrefix(itf, "/") && strings.HasSuffix(itf, "/") {
+		if _, err := regexp.Compile(itf[1 : len(itf)-1]); err != nil {
+			return "invalid regular expression: " + err.Error()
+		}
+		return ""
+	}
+	if itf == "" || strings.ContainsAny(itf, ", ") {
+		return "must be a non-empty interface name without commas or spaces"
+	}
+	return ""
+}
+
+func validateProcessor(processor *FlowCollectorProcessor, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	// All listeners receive UDP: they can't share a port
+	ports := map[int32]bool{processor.Port: true}
+	for i, listener := range processor.Listeners {
+		if ports[listener.Port] {
+			errs = append(errs, field.Duplicate(path.Child("listeners").Index(i).Child("port"), listener.Port))
+		}
+		ports[listener.Port] = true
+	}
+	if processor.Metrics.Enable {
+		errs = append(errs, validateMetrics(&processor.Metrics, path.Child("metrics"))...)
+	}
+	if processor.Monitoring.Enable {
+		errs = append(errs, validateMonitoring(processor, path.Child("monitoring"))...)
+	}
+	if processor.HPA == nil {
+		return errs
+	}
+	hpaPath := path.Child("hpa")
+	if processor.Kind == "DaemonSet" {
+		errs = append(errs, field.Forbidden(hpaPath, "HPA can only be set when kind is Deployment"))
+	}
+	if processor.HPA.MinReplicas != nil && processor.HPA.MaxReplicas < *processor.HPA.MinReplicas {
+		errs = append(errs, field.Invalid(hpaPath.Child("maxReplicas"), processor.HPA.MaxReplicas,
+			"must be greater than or equal to minReplicas"))
+	}
+	return errs
+}
+
+func validateKafka(kafka *FlowCollectorKafka, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if len(kafka.Brokers) == 0 {
+		errs = append(errs, field.Required(path.Child("brokers"), "must be set when Kafka is enabled"))
+	}
+	for i, broker := range kafka.Brokers {
+		if _, _, err := net.SplitHostPort(broker); err != nil {
+			errs = append(errs, field.Invalid(path.Child("brokers").Index(i), broker, "must be a host:port address"))
+		}
+	}
+	if kafka.Topic == "" {
+		errs = append(errs, field.Required(path.Child("topic"), "must be set when Kafka is enabled"))
+	}
+	return append(errs, validateTLS(&kafka.TLS, path.Child("tls"))...)
+}
+
+func validateTLS(tls *ClientTLS, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if !tls.Enable {
+		return errs
+	}
+	if !tls.InsecureSkipVerify {
+		caPath := path.Child("caCert")
+		if tls.CACert.Name == "" {
+			errs = append(errs, field.Required(caPath.Child("name"), "must be set unless insecureSkipVerify is true"))
+		}
+		if tls.CACert.CertFile == "" {
+			errs = append(errs, field.Required(caPath.Child("certFile"), "must be set unless insecureSkipVerify is true"))
+		}
+	}
+	if tls.UserCert.Name != "" {
+		userPath := path.Child("userCert")
+		if tls.UserCert.CertFile == "" {
+			errs = append(errs, field.Required(userPath.Child("certFile"), "must be set for mutual TLS"))
+		}
+		if tls.UserCert.CertKey == "" {
+			errs = append(errs, field.Required(userPath.Child("certKey"), "must be set for mutual TLS"))
+		}
+	}
+	return errs
+}
+
+func validateExporter(exporter *FlowCollectorExporter, path *field.Path) field.ErrorList {
+	if exporter.Type != "OpenTelemetry" {
+		return nil
+	}
+	var errs field.ErrorList
+	otlp := &exporter.OpenTelemetry
+	otlpPath := path.Child("openTelemetry")
+	if otlp.Protocol == "http" {
+		if err := validateURL(otlp.Endpoint); err != "" {
+			errs = append(errs, field.Invalid(otlpPath.Child("endpoint"), otlp.Endpoint, err))
+		}
+	} else if _, _, err := net.SplitHostPort(otlp.Endpoint); err != nil {
+		errs = append(errs, field.Invalid(otlpPath.Child("endpoint"), otlp.Endpoint, "must be host:port for the grpc protocol"))
+	}
+	return append(errs, validateTLS(&otlp.TLS, otlpPath.Child("tls"))...)
+}
+
+// metricNames are the metrics that can be computed from the flows
+var metricNames = []string{"bytes_total", "flows_total", "packets_total"}
+
+func validateMetrics(metrics *FlowCollectorMetrics, path *field.Path) field.ErrorList {
+	errs := validateLabels(metrics.Labels, nil, metrics.ForceHighCardinalityLabels, path.Child("labels"))
+	seen := map[string]bool{}
+	for i, name := range metrics.IncludeList {
+		namePath := path.Child("includeList").Index(i)
+		switch {
+		case !containsString(metricNames, name):
+			errs = append(errs, field.NotSupported(namePath, name, metricNames))
+		case seen[name]:
+			errs = append(errs, field.Duplicate(namePath, name))
+		}
+		seen[name] = true
+	}
+	if len(metrics.IncludeList) == 0 {
+		errs = append(errs, field.Required(path.Child("includeList"), "at least one metric must be included"))
+	}
+	return errs
+}
+
+func validateMonitoring(processor *FlowCollectorProcessor, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	monitoring := &processor.Monitoring
+	if processor.Metrics.Enable && monitoring.Port == processor.Metrics.Port {
+		errs = append(errs, field.Duplicate(path.Child("port"), monitoring.Port))
+	}
+	alertsPath := path.Child("alerts")
+	if monitoring.Alerts.NoFlowsFor.Duration <= 0 {
+		errs = append(errs, field.Invalid(alertsPath.Child("noFlowsFor"), monitoring.Alerts.NoFlowsFor.Duration.String(),
+			"must be greater than zero"))
+	}
+	if monitoring.Alerts.NotReadyFor.Duration <= 0 {
+		errs = append(errs, field.Invalid(alertsPath.Child("notReadyFor"), monitoring.Alerts.NotReadyFor.Duration.String(),
+			"must be greater than zero"))
+	}
+	return errs
+}
+
+func validateLoki(loki *FlowCollectorLoki, path *field.Path) field.ErrorList {
+	errs := validateLabels(loki.Labels, loki.StaticLabels, loki.ForceHighCardinalityLabels, path.Child("labels"))
+	if loki.Mode == "LokiStack" {
+		// Connection settings are derived from the LokiStack
+		if loki.LokiStack.Name == "" {
+			errs = append(errs, field.Required(path.Child("lokiStack", "name"), "must be set when mode is LokiStack"))
+		}
+		return errs
+	}
+	if loki.Mode == "Deploy" {
+		// Connection settings are derived from the deployed Loki
+		return append(errs, validateLokiDeploy(&loki.Deploy, path.Child("deploy"))...)
+	}
+	if err := validateURL(loki.URL); err != "" {
+		errs = append(errs, field.Invalid(path.Child("url"), loki.URL, err))
+	}
+	if loki.QuerierURL != "" {
+		if err := validateURL(loki.QuerierURL); err != "" {
+			errs = append(errs, field.Invalid(path.Child("querierUrl"), loki.QuerierURL, err))
+		}
+	}
+	errs = append(errs, validateTLS(&loki.TLS, path.Child("tls"))...)
+	if (loki.Auth.Type == "BearerToken" || loki.Auth.Type == "BasicAuth") && loki.Auth.SecretName == "" {
+		errs = append(errs, field.Required(path.Child("auth", "secretName"), "must be set when type is "+loki.Auth.Type))
+	}
+	return errs
+}
+
+func validateLokiDeploy(deploy *FlowCollectorLokiDeploy, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if deploy.StorageSize.Sign() <= 0 {
+		errs = append(errs, field.Invalid(path.Child("storageSize"), deploy.StorageSize.String(), "must be greater than zero"))
+	}
+	// Loki requires the retention to be a multiple of the index period, which is 24h
+	if period := deploy.RetentionPeriod.Duration; period < 24*time.Hour || period%(24*time.Hour) != 0 {
+		errs = append(errs, field.Invalid(path.Child("retentionPeriod"), period.String(), "must be a multiple of 24h"))
+	}
+	return errs
+}
+
+// labelFields are the fields of the flows produced by goflow-kube, telling whether they have a high
+// cardinality, i.e. whether they would create too many Loki streams or Prometheus series when used as labels
+var labelFields = map[string]bool{
+	"SrcNamespace":    false,
+	"SrcWorkload":     false,
+	"SrcWorkloadKind": false,
+	"SrcK8S_Type":     false,
+	"DstNamespace":    false,
+	"DstWorkload":     false,
+	"DstWorkloadKind": false,
+	"DstK8S_Type":     false,
+	"FlowDirection":   false,
+	"Proto":           false,
+	"Etype":           false,
+	"SrcPod":          true,
+	"SrcAddr":         true,
+	"SrcPort":         true,
+	"SrcMac":          true,
+	"SrcHostIP":       true,
+	"DstPod":          true,
+	"DstAddr":         true,
+	"DstPort":         true,
+	"DstMac":          true,
+	"DstHostIP":       true,
+	"SamplerAddress":  true,
+	"Bytes":           true,
+	"Packets":         true,
+	"TimeReceived":    true,
+	"TimeFlowStart":   true,
+	"TimeFlowEnd":     true,
+}
+
+// validateLabels checks that the provided flow fields can be used as labels: they must be known, not
+// duplicated, distinct from the static labels, and have a low cardinality unless forced
+func validateLabels(labels []string, staticLabels map[string]string, forceHighCardinality bool,
+	path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	seen := map[string]bool{}
+	for i, label := range labels {
+		labelPath := path.Index(i)
+		highCardinality, known := labelFields[label]
+		_, static := staticLabels[label]
+		switch {
+		case !known:
+			errs = append(errs, field.NotSupported(labelPath, label, labelFieldNames()))
+		case static:
+			errs = append(errs, field.Invalid(labelPath, label, "must not be a static label"))
+		case highCardinality && !forceHighCardinality:
+			errs = append(errs, field.Forbidden(labelPath,
+				label+" has a high cardinality: set forceHighCardinalityLabels to use it anyway"))
+		case seen[label]:
+			errs = append(errs, field.Duplicate(labelPath, label))
+		}
+		seen[label] = true
+	}
+	return errs
+}
+
+func labelFieldNames() []string {
+	names := make([]string, 0, len(labelFields))
+	for name := range labelFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// validateURL returns an error message if the provided URL isn't a valid HTTP(S) absolute URL
+func validateURL(str string) string {
+	u, err := url.Parse(str)
+	if err != nil {
+		return err.Error()
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "must be an absolute URL with http or https scheme"
+	}
+	if u.Host == "" {
+		return "must contain a host"
+	}
+	return ""
+}
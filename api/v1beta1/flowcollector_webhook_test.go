@@ -0,0 +1,457 @@
+package v1beta1
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func getValidFlowCollector() *FlowCollector {
+	fc := &FlowCollector{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
+	fc.Default()
+	return fc
+}
+
+func causeFields(t *testing.T, err error) []string {
+	statusErr, ok := err.(*apierrors.StatusError)
+	require.True(t, ok, "expected a StatusError, got %v", err)
+	var fields []string
+	for _, cause := range statusErr.ErrStatus.Details.Causes {
+		fields = append(fields, cause.Field)
+	}
+	return fields
+}
+
+func TestValidateDefaulted(t *testing.T) {
+	fc := getValidFlowCollector()
+	assert.NoError(t, fc.ValidateCreate())
+	assert.NoError(t, fc.ValidateUpdate(fc.DeepCopy()))
+}
+
+func TestValidateUnchangedSpec(t *testing.T) {
+	fc := getValidFlowCollector()
+	fc.Spec.Storage.Loki.URL = "loki"
+	updated := fc.DeepCopy()
+	updated.Annotations = map[string]string{"foo": "bar"}
+	assert.NoError(t, updated.ValidateUpdate(fc))
+
+	updated.Spec.Processor.LogLevel = "debug"
+	assert.Error(t, updated.ValidateUpdate(fc))
+}
+
+func TestValidateHPA(t *testing.T) {
+	min := int32(3)
+	fc := getValidFlowCollector()
+	fc.Spec.Processor.Kind = "DaemonSet"
+	fc.Spec.Processor.HPA = &FlowCollectorHPA{MinReplicas: &min, MaxReplicas: 2}
+	err := fc.ValidateCreate()
+	require.Error(t, err)
+	assert.True(t, apierrors.IsInvalid(err))
+	assert.Equal(t, []string{"spec.processor.hpa", "spec.processor.hpa.maxReplicas"}, causeFields(t, err))
+
+	fc.Spec.Processor.Kind = "Deployment"
+	fc.Spec.Processor.HPA.MaxReplicas = 3
+	assert.NoError(t, fc.ValidateCreate())
+}
+
+func TestValidateLokiURLs(t *testing.T) {
+	fc := getValidFlowCollector()
+	fc.Spec.Storage.Loki.QuerierURL = "loki-querier:3100"
+	err := fc.ValidateCreate()
+	require.Error(t, err)
+	assert.Equal(t, []string{"spec.storage.loki.querierUrl"}, causeFields(t, err))
+
+	fc.Spec.Storage.Loki.QuerierURL = "https://loki-querier:3100/"
+	fc.Spec.Storage.Loki.URL = "http://"
+	err = fc.ValidateCreate()
+	require.Error(t, err)
+	assert.Equal(t, []string{"spec.storage.loki.url"}, causeFields(t, err))
+}
+
+func TestValidateCacheActiveTimeout(t *testing.T) {
+	fc := getValidFlowCollector()
+	fc.Spec.Agent.IPFIX.CacheActiveTimeout = metav1.Duration{Duration: 1500 * time.Millisecond}
+	assert.NoError(t, fc.ValidateCreate())
+
+	fc.Spec.Agent.IPFIX.CacheActiveTimeout = metav1.Duration{Duration: -time.Second}
+	err := fc.ValidateCreate()
+	require.Error(t, err)
+	assert.Equal(t, []string{"spec.agent.ipfix.cacheActiveTimeout"}, causeFields(t, err))
+}
+
+func TestValidateListeners(t *testing.T) {
+	fc := getValidFlowCollector()
+	fc.Spec.Processor.Listeners = []FlowCollectorListener{
+		{Protocol: "sflow", Port: 6343},
+		{Protocol: "netflow", Port: 2056},
+	}
+	assert.NoError(t, fc.ValidateCreate())
+
+	// Listeners can't share a port, including the main one
+	fc.Spec.Processor.Listeners = []FlowCollectorListener{
+		{Protocol: "sflow", Port: 6343},
+		{Protocol: "netflow", Port: fc.Spec.Processor.Port},
+		{Protocol: "netflow", Port: 6343},
+	}
+	err := fc.ValidateCreate()
+	require.Error(t, err)
+	assert.Equal(t, []string{"spec.processor.listeners[1].port", "spec.processor.listeners[2].port"}, causeFields(t, err))
+}
+
+func TestValidateNamespace(t *testing.T) {
+	fc := getValidFlowCollector()
+	fc.Spec.Namespace = fc.Spec.Agent.IPFIX.ClusterNetworkOperator.Namespace
+	err := fc.ValidateCreate()
+	require.Error(t, err)
+	assert.Equal(t, []string{"spec.namespace"}, causeFields(t, err))
+
+	// The CNO namespace is unused in NodeAgent mode
+	fc.Spec.Agent.IPFIX.OVSConfigMode = "NodeAgent"
+	fc.Spec.Agent.IPFIX.NodeAgent.Image = "ghcr.io/ovn-org/ovn-kubernetes/ovn-kube-f:master"
+	assert.NoError(t, fc.ValidateCreate())
+}
+
+func TestValidateNodeAgentImage(t *testing.T) {
+	fc := getValidFlowCollector()
+	fc.Spec.Agent.IPFIX.OVSConfigMode = "NodeAgent"
+	err := fc.ValidateCreate()
+	require.Error(t, err)
+	assert.Equal(t, []string{"spec.agent.ipfix.nodeAgent.image"}, causeFields(t, err))
+
+	fc.Spec.Agent.IPFIX.NodeAgent.Image = "ghcr.io/ovn-org/ovn-kubernetes/ovn-kube-f:master"
+	assert.NoError(t, fc.ValidateCreate())
+}
+
+func TestValidateEBPF(t *testing.T) {
+	fc := getValidFlowCollector()
+	fc.Spec.Agent.Type = "EBPF"
+	fc.Spec.Agent.EBPF.Interfaces = []string{"eth0", "/^br-/", "/[/"}
+	fc.Spec.Agent.EBPF.ExcludeInterfaces = []string{"lo", "eth1,eth2"}
+	fc.Spec.Agent.EBPF.CacheActiveTimeout = metav1.Duration{}
+	// IPFIX settings are ignored
+	fc.Spec.Agent.IPFIX.CacheActiveTimeout = metav1.Duration{}
+	err := fc.ValidateCreate()
+	require.Error(t, err)
+	assert.Equal(t, []string{
+		"spec.agent.ebpf.cacheActiveTimeout",
+		"spec.agent.ebpf.interfaces[2]",
+		"spec.agent.ebpf.excludeInterfaces[1]",
+	}, causeFields(t, err))
+
+	fc = getValidFlowCollector()
+	fc.Spec.Agent.Type = "EBPF"
+	// The CNO namespace is unused with the eBPF agent
+	fc.Spec.Namespace = fc.Spec.Agent.IPFIX.ClusterNetworkOperator.Namespace
+	assert.NoError(t, fc.ValidateCreate())
+}
+
+func TestValidateKafka(t *testing.T) {
+	fc := getValidFlowCollector()
+	fc.Spec.Kafka.Enable = true
+	fc.Spec.Kafka.Brokers = []string{"kafka-bootstrap:9092", "kafka-bootstrap"}
+	fc.Spec.Kafka.TLS = ClientTLS{
+		Enable:   true,
+		UserCert: CertificateReference{Type: "secret", Name: "kafka-user", CertFile: "user.crt"},
+	}
+	err := fc.ValidateCreate()
+	require.Error(t, err)
+	assert.Equal(t, []string{
+		"spec.kafka.brokers[1]",
+		"spec.kafka.tls.caCert.name",
+		"spec.kafka.tls.caCert.certFile",
+		"spec.kafka.tls.userCert.certKey",
+	}, causeFields(t, err))
+
+	fc.Spec.Kafka.Brokers = []string{"kafka-bootstrap:9093"}
+	fc.Spec.Kafka.TLS.CACert = CertificateReference{Type: "configmap", Name: "kafka-ca", CertFile: "ca.crt"}
+	fc.Spec.Kafka.TLS.UserCert.CertKey = "user.key"
+	assert.NoError(t, fc.ValidateCreate())
+
+	// Kafka settings are ignored when it is disabled
+	fc = getValidFlowCollector()
+	fc.Spec.Kafka.TLS.Enable = true
+	assert.NoError(t, fc.ValidateCreate())
+}
+
+func TestValidateLokiTLSAndAuth(t *testing.T) {
+	fc := getValidFlowCollector()
+	fc.Spec.Storage.Loki.TLS.Enable = true
+	fc.Spec.Storage.Loki.Auth.Type = "BasicAuth"
+	err := fc.ValidateCreate()
+	require.Error(t, err)
+	assert.Equal(t, []string{
+		"spec.storage.loki.tls.caCert.name",
+		"spec.storage.loki.tls.caCert.certFile",
+		"spec.storage.loki.auth.secretName",
+	}, causeFields(t, err))
+
+	fc.Spec.Storage.Loki.TLS.InsecureSkipVerify = true
+	fc.Spec.Storage.Loki.Auth.SecretName = "loki-credentials"
+	assert.NoError(t, fc.ValidateCreate())
+
+	// The service account token doesn't need any secret
+	fc.Spec.Storage.Loki.Auth = LokiAuth{Type: "ServiceAccountToken"}
+	assert.NoError(t, fc.ValidateCreate())
+}
+
+func TestValidateLokiStackMode(t *testing.T) {
+	fc := getValidFlowCollector()
+	fc.Spec.Storage.Loki.Mode = "LokiStack"
+	// Manual settings are ignored in LokiStack mode
+	fc.Spec.Storage.Loki.URL = "loki"
+	fc.Spec.Storage.Loki.Auth.Type = "BasicAuth"
+	err := fc.ValidateCreate()
+	require.Error(t, err)
+	assert.Equal(t, []string{"spec.storage.loki.lokiStack.name"}, causeFields(t, err))
+
+	fc.Spec.Storage.Loki.LokiStack.Name = "loki"
+	assert.NoError(t, fc.ValidateCreate())
+}
+
+func TestValidateLokiDeploy(t *testing.T) {
+	fc := getValidFlowCollector()
+	fc.Spec.Storage.Loki.Mode = "Deploy"
+	// Manual settings are ignored in Deploy mode
+	fc.Spec.Storage.Loki.URL = "loki"
+	assert.NoError(t, fc.ValidateCreate())
+
+	fc.Spec.Storage.Loki.Deploy.RetentionPeriod.Duration = 36 * time.Hour
+	fc.Spec.Storage.Loki.Deploy.StorageSize = resource.MustParse("0")
+	err := fc.ValidateCreate()
+	require.Error(t, err)
+	assert.Equal(t, []string{
+		"spec.storage.loki.deploy.storageSize",
+		"spec.storage.loki.deploy.retentionPeriod",
+	}, causeFields(t, err))
+
+	fc.Spec.Storage.Loki.Deploy.RetentionPeriod.Duration = 7 * 24 * time.Hour
+	fc.Spec.Storage.Loki.Deploy.StorageSize = resource.MustParse("1Gi")
+	assert.NoError(t, fc.ValidateCreate())
+}
+
+func TestValidateMetrics(t *testing.T) {
+	fc := getValidFlowCollector()
+	fc.Spec.Processor.Metrics.Enable = true
+	assert.NoError(t, fc.ValidateCreate())
+
+	fc.Spec.Processor.Metrics.IncludeList = []string{"flows_total", "unknown_total", "flows_total"}
+	fc.Spec.Processor.Metrics.Labels = []string{"SrcNamespace", "SrcPod"}
+	err := fc.ValidateCreate()
+	require.Error(t, err)
+	assert.Equal(t, []string{
+		"spec.processor.metrics.labels[1]",
+		"spec.processor.metrics.includeList[1]",
+		"spec.processor.metrics.includeList[2]",
+	}, causeFields(t, err))
+
+	fc.Spec.Processor.Metrics.IncludeList = []string{}
+	fc.Spec.Processor.Metrics.ForceHighCardinalityLabels = true
+	err = fc.ValidateCreate()
+	require.Error(t, err)
+	assert.Equal(t, []string{"spec.processor.metrics.includeList"}, causeFields(t, err))
+
+	// Settings are ignored when the metrics are disabled
+	fc.Spec.Processor.Metrics.Enable = false
+	assert.NoError(t, fc.ValidateCreate())
+}
+
+func TestValidateMonitoring(t *testing.T) {
+	fc := getValidFlowCollector()
+	fc.Spec.Processor.Monitoring.Enable = true
+	fc.Spec.Processor.Metrics.Enable = true
+	assert.NoError(t, fc.ValidateCreate())
+
+	fc.Spec.Processor.Monitoring.Port = fc.Spec.Processor.Metrics.Port
+	fc.Spec.Processor.Monitoring.Alerts.NoFlowsFor = metav1.Duration{Duration: -time.Minute}
+	err := fc.ValidateCreate()
+	require.Error(t, err)
+	assert.Equal(t, []string{
+		"spec.processor.monitoring.port",
+		"spec.processor.monitoring.alerts.noFlowsFor",
+	}, causeFields(t, err))
+
+	// Settings are ignored when the monitoring is disabled
+	fc.Spec.Processor.Monitoring.Enable = false
+	assert.NoError(t, fc.ValidateCreate())
+}
+
+func TestValidateLokiDisabled(t *testing.T) {
+	fc := getValidFlowCollector()
+	disabled := false
+	fc.Spec.Storage.Loki.Enable = &disabled
+	// Loki settings are ignored when disabled
+	fc.Spec.Storage.Loki.URL = "loki"
+	err := fc.ValidateCreate()
+	require.Error(t, err)
+	assert.Equal(t, []string{"spec.storage.loki.enable"}, causeFields(t, err))
+
+	// Flows are then only exported as metrics
+	fc.Spec.Processor.Metrics.Enable = true
+	assert.NoError(t, fc.ValidateCreate())
+}
+
+func TestValidateExporters(t *testing.T) {
+	fc := getValidFlowCollector()
+	fc.Spec.Exporters = []FlowCollectorExporter{{
+		Type:          "OpenTelemetry",
+		OpenTelemetry: FlowCollectorOpenTelemetry{Endpoint: "otel-collector:4317"},
+	}, {
+		Type:          "OpenTelemetry",
+		OpenTelemetry: FlowCollectorOpenTelemetry{Endpoint: "http://otel-collector:4318/", Protocol: "http"},
+	}}
+	fc.Default()
+	assert.Equal(t, "grpc", fc.Spec.Exporters[0].OpenTelemetry.Protocol)
+	assert.NoError(t, fc.ValidateCreate())
+
+	fc.Spec.Exporters[0].OpenTelemetry.Endpoint = "http://otel-collector:4317/"
+	fc.Spec.Exporters[1].OpenTelemetry.Endpoint = "otel-collector:4318"
+	fc.Spec.Exporters[1].OpenTelemetry.TLS.Enable = true
+	err := fc.ValidateCreate()
+	require.Error(t, err)
+	assert.Equal(t, []string{
+		"spec.exporters[0].openTelemetry.endpoint",
+		"spec.exporters[1].openTelemetry.endpoint",
+		"spec.exporters[1].openTelemetry.tls.caCert.name",
+		"spec.exporters[1].openTelemetry.tls.caCert.certFile",
+	}, causeFields(t, err))
+
+	// Exporters are enough to disable Loki
+	fc.Spec.Exporters = fc.Spec.Exporters[:1]
+	fc.Spec.Exporters[0].OpenTelemetry.Endpoint = "otel-collector:4317"
+	disabled := false
+	fc.Spec.Storage.Loki.Enable = &disabled
+	assert.NoError(t, fc.ValidateCreate())
+}
+
+func TestValidateLokiLabels(t *testing.T) {
+	fc := getValidFlowCollector()
+	fc.Spec.Storage.Loki.Labels = []string{"SrcNamespace", "FlowDirection", "SrcK8S_Type"}
+	assert.NoError(t, fc.ValidateCreate())
+
+	fc.Spec.Storage.Loki.Labels = []string{"SrcNamespace", "Unknown", "SrcAddr", "DstPort", "SrcNamespace", "app"}
+	err := fc.ValidateCreate()
+	require.Error(t, err)
+	assert.Equal(t, []string{
+		"spec.storage.loki.labels[1]",
+		"spec.storage.loki.labels[2]",
+		"spec.storage.loki.labels[3]",
+		"spec.storage.loki.labels[4]",
+		"spec.storage.loki.labels[5]",
+	}, causeFields(t, err))
+
+	// High cardinality fields can be forced
+	fc.Spec.Storage.Loki.Labels = []string{"SrcNamespace", "SrcAddr", "DstPort"}
+	fc.Spec.Storage.Loki.ForceHighCardinalityLabels = true
+	assert.NoError(t, fc.ValidateCreate())
+
+	// Static labels can't be used as dynamic labels
+	fc.Spec.Storage.Loki.StaticLabels = map[string]string{"SrcNamespace": "x"}
+	err = fc.ValidateCreate()
+	require.Error(t, err)
+	assert.Equal(t, []string{"spec.storage.loki.labels[0]"}, causeFields(t, err))
+}
+
+func TestDefaultHPAMinReplicas(t *testing.T) {
+	fc := FlowCollector{Spec: FlowCollectorSpec{Processor: FlowCollectorProcessor{
+		HPA: &FlowCollectorHPA{MaxReplicas: 3},
+	}}}
+	fc.Default()
+	require.NotNil(t, fc.Spec.Processor.HPA.MinReplicas)
+	assert.Equal(t, int32(1), *fc.Spec.Processor.HPA.MinReplicas)
+}
+
+// TestDefaultMatchesCRD ensures that the webhook defaults are consistent with the ones defined in the CRD schema
+func TestDefaultMatchesCRD(t *testing.T) {
+	raw, err := os.ReadFile("../../config/crd/bases/flows.netobserv.io_flowcollectors.yaml")
+	require.NoError(t, err)
+	var crd struct {
+		Spec struct {
+			Versions []struct {
+				Name   string
+				Schema struct {
+					OpenAPIV3Schema crdSchema `yaml:"openAPIV3Schema"`
+				}
+			}
+		}
+	}
+	require.NoError(t, yaml.Unmarshal(raw, &crd))
+	var specSchema *crdSchema
+	for i := range crd.Spec.Versions {
+		if crd.Spec.Versions[i].Name == GroupVersion.Version {
+			s := crd.Spec.Versions[i].Schema.OpenAPIV3Schema.Properties["spec"]
+			specSchema = &s
+		}
+	}
+	require.NotNil(t, specSchema)
+
+	fc := FlowCollector{}
+	fc.Default()
+	bytes, err := json.Marshal(fc.Spec)
+	require.NoError(t, err)
+	var defaulted map[string]interface{}
+	require.NoError(t, json.Unmarshal(bytes, &defaulted))
+
+	assertDefaults(t, "spec", specSchema, defaulted)
+}
+
+// assertDefaults recursively checks that the values set by the webhook match the schema defaults
+func assertDefaults(t *testing.T, path string, schema *crdSchema, values map[string]interface{}) {
+	for field, fieldSchema := range schema.Properties {
+		fieldSchema := fieldSchema
+		value, defaultedByWebhook := values[field]
+		if !defaultedByWebhook {
+			continue
+		}
+		if nested, ok := value.(map[string]interface{}); ok && len(fieldSchema.Properties) > 0 {
+			assertDefaults(t, path+"."+field, &fieldSchema, nested)
+			continue
+		}
+		if fieldSchema.Default == nil {
+			continue
+		}
+		expected := normalize(fieldSchema.Default)
+		if d, ok := asDuration(expected); ok {
+			// durations may have different representations, e.g. 300s and 5m0s
+			expected = d
+			value, _ = asDuration(value)
+		}
+		assert.EqualValues(t, expected, value, "%s.%s", path, field)
+	}
+}
+
+type crdSchema struct {
+	Default    interface{}          `yaml:"default"`
+	Properties map[string]crdSchema `yaml:"properties"`
+}
+
+func asDuration(v interface{}) (time.Duration, bool) {
+	str, ok := v.(string)
+	if !ok {
+		return 0, false
+	}
+	d, err := time.ParseDuration(str)
+	return d, err == nil
+}
+
+// normalize converts YAML-decoded values into their JSON-decoded equivalent
+func normalize(v interface{}) interface{} {
+	switch val := v.(type) {
+	case int:
+		return float64(val)
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for k, v := range val {
+			m[k.(string)] = normalize(v)
+		}
+		return m
+	}
+	return v
+}
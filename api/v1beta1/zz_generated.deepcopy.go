@@ -0,0 +1,740 @@
+// +build !ignore_autogenerated
+
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by controller-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertManagerIssuerReference) DeepCopyInto(out *CertManagerIssuerReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertManagerIssuerReference.
+func (in *CertManagerIssuerReference) DeepCopy() *CertManagerIssuerReference {
+	if in == nil {
+		return nil
+	}
+	out := new(CertManagerIssuerReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateReference) DeepCopyInto(out *CertificateReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateReference.
+func (in *CertificateReference) DeepCopy() *CertificateReference {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateStatus) DeepCopyInto(out *CertificateStatus) {
+	*out = *in
+	if in.NotAfter != nil {
+		in, out := &in.NotAfter, &out.NotAfter
+		*out = (*in).DeepCopy()
+	}
+	if in.RenewAfter != nil {
+		in, out := &in.RenewAfter, &out.RenewAfter
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateStatus.
+func (in *CertificateStatus) DeepCopy() *CertificateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientTLS) DeepCopyInto(out *ClientTLS) {
+	*out = *in
+	out.CACert = in.CACert
+	out.UserCert = in.UserCert
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientTLS.
+func (in *ClientTLS) DeepCopy() *ClientTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterNetworkOperator) DeepCopyInto(out *ClusterNetworkOperator) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterNetworkOperator.
+func (in *ClusterNetworkOperator) DeepCopy() *ClusterNetworkOperator {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterNetworkOperator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowCollector) DeepCopyInto(out *FlowCollector) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowCollector.
+func (in *FlowCollector) DeepCopy() *FlowCollector {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowCollector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FlowCollector) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowCollectorAgent) DeepCopyInto(out *FlowCollectorAgent) {
+	*out = *in
+	out.IPFIX = in.IPFIX
+	in.EBPF.DeepCopyInto(&out.EBPF)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowCollectorAgent.
+func (in *FlowCollectorAgent) DeepCopy() *FlowCollectorAgent {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowCollectorAgent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowCollectorAlerts) DeepCopyInto(out *FlowCollectorAlerts) {
+	*out = *in
+	out.NoFlowsFor = in.NoFlowsFor
+	out.NotReadyFor = in.NotReadyFor
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowCollectorAlerts.
+func (in *FlowCollectorAlerts) DeepCopy() *FlowCollectorAlerts {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowCollectorAlerts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowCollectorConsole) DeepCopyInto(out *FlowCollectorConsole) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+	in.Scheduling.DeepCopyInto(&out.Scheduling)
+	in.Standalone.DeepCopyInto(&out.Standalone)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowCollectorConsole.
+func (in *FlowCollectorConsole) DeepCopy() *FlowCollectorConsole {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowCollectorConsole)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowCollectorDashboard) DeepCopyInto(out *FlowCollectorDashboard) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowCollectorDashboard.
+func (in *FlowCollectorDashboard) DeepCopy() *FlowCollectorDashboard {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowCollectorDashboard)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowCollectorEBPF) DeepCopyInto(out *FlowCollectorEBPF) {
+	*out = *in
+	out.CacheActiveTimeout = in.CacheActiveTimeout
+	if in.Interfaces != nil {
+		in, out := &in.Interfaces, &out.Interfaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeInterfaces != nil {
+		in, out := &in.ExcludeInterfaces, &out.ExcludeInterfaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowCollectorEBPF.
+func (in *FlowCollectorEBPF) DeepCopy() *FlowCollectorEBPF {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowCollectorEBPF)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowCollectorExporter) DeepCopyInto(out *FlowCollectorExporter) {
+	*out = *in
+	in.OpenTelemetry.DeepCopyInto(&out.OpenTelemetry)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowCollectorExporter.
+func (in *FlowCollectorExporter) DeepCopy() *FlowCollectorExporter {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowCollectorExporter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowCollectorHPA) DeepCopyInto(out *FlowCollectorHPA) {
+	*out = *in
+	if in.MinReplicas != nil {
+		in, out := &in.MinReplicas, &out.MinReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TargetCPUUtilizationPercentage != nil {
+		in, out := &in.TargetCPUUtilizationPercentage, &out.TargetCPUUtilizationPercentage
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowCollectorHPA.
+func (in *FlowCollectorHPA) DeepCopy() *FlowCollectorHPA {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowCollectorHPA)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowCollectorIPFIX) DeepCopyInto(out *FlowCollectorIPFIX) {
+	*out = *in
+	out.CacheActiveTimeout = in.CacheActiveTimeout
+	out.ClusterNetworkOperator = in.ClusterNetworkOperator
+	out.NodeAgent = in.NodeAgent
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowCollectorIPFIX.
+func (in *FlowCollectorIPFIX) DeepCopy() *FlowCollectorIPFIX {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowCollectorIPFIX)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowCollectorIngress) DeepCopyInto(out *FlowCollectorIngress) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowCollectorIngress.
+func (in *FlowCollectorIngress) DeepCopy() *FlowCollectorIngress {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowCollectorIngress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowCollectorKafka) DeepCopyInto(out *FlowCollectorKafka) {
+	*out = *in
+	if in.Brokers != nil {
+		in, out := &in.Brokers, &out.Brokers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.TLS = in.TLS
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowCollectorKafka.
+func (in *FlowCollectorKafka) DeepCopy() *FlowCollectorKafka {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowCollectorKafka)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowCollectorList) DeepCopyInto(out *FlowCollectorList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]FlowCollector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowCollectorList.
+func (in *FlowCollectorList) DeepCopy() *FlowCollectorList {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowCollectorList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FlowCollectorList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowCollectorListener) DeepCopyInto(out *FlowCollectorListener) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowCollectorListener.
+func (in *FlowCollectorListener) DeepCopy() *FlowCollectorListener {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowCollectorListener)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowCollectorLoki) DeepCopyInto(out *FlowCollectorLoki) {
+	*out = *in
+	if in.Enable != nil {
+		in, out := &in.Enable, &out.Enable
+		*out = new(bool)
+		**out = **in
+	}
+	out.LokiStack = in.LokiStack
+	in.Deploy.DeepCopyInto(&out.Deploy)
+	out.BatchWait = in.BatchWait
+	out.Timeout = in.Timeout
+	out.MinBackoff = in.MinBackoff
+	out.MaxBackoff = in.MaxBackoff
+	if in.StaticLabels != nil {
+		in, out := &in.StaticLabels, &out.StaticLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.TLS = in.TLS
+	out.Auth = in.Auth
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowCollectorLoki.
+func (in *FlowCollectorLoki) DeepCopy() *FlowCollectorLoki {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowCollectorLoki)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowCollectorLokiDeploy) DeepCopyInto(out *FlowCollectorLokiDeploy) {
+	*out = *in
+	out.StorageSize = in.StorageSize.DeepCopy()
+	out.RetentionPeriod = in.RetentionPeriod
+	in.Resources.DeepCopyInto(&out.Resources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowCollectorLokiDeploy.
+func (in *FlowCollectorLokiDeploy) DeepCopy() *FlowCollectorLokiDeploy {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowCollectorLokiDeploy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowCollectorMetrics) DeepCopyInto(out *FlowCollectorMetrics) {
+	*out = *in
+	if in.IncludeList != nil {
+		in, out := &in.IncludeList, &out.IncludeList
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowCollectorMetrics.
+func (in *FlowCollectorMetrics) DeepCopy() *FlowCollectorMetrics {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowCollectorMetrics)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowCollectorMonitoring) DeepCopyInto(out *FlowCollectorMonitoring) {
+	*out = *in
+	out.Alerts = in.Alerts
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowCollectorMonitoring.
+func (in *FlowCollectorMonitoring) DeepCopy() *FlowCollectorMonitoring {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowCollectorMonitoring)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowCollectorOpenTelemetry) DeepCopyInto(out *FlowCollectorOpenTelemetry) {
+	*out = *in
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	out.TLS = in.TLS
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowCollectorOpenTelemetry.
+func (in *FlowCollectorOpenTelemetry) DeepCopy() *FlowCollectorOpenTelemetry {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowCollectorOpenTelemetry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowCollectorProcessor) DeepCopyInto(out *FlowCollectorProcessor) {
+	*out = *in
+	if in.HPA != nil {
+		in, out := &in.HPA, &out.HPA
+		*out = new(FlowCollectorHPA)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Listeners != nil {
+		in, out := &in.Listeners, &out.Listeners
+		*out = make([]FlowCollectorListener, len(*in))
+		copy(*out, *in)
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+	in.Metrics.DeepCopyInto(&out.Metrics)
+	out.Monitoring = in.Monitoring
+	in.Scheduling.DeepCopyInto(&out.Scheduling)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowCollectorProcessor.
+func (in *FlowCollectorProcessor) DeepCopy() *FlowCollectorProcessor {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowCollectorProcessor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowCollectorScheduling) DeepCopyInto(out *FlowCollectorScheduling) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowCollectorScheduling.
+func (in *FlowCollectorScheduling) DeepCopy() *FlowCollectorScheduling {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowCollectorScheduling)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowCollectorSpec) DeepCopyInto(out *FlowCollectorSpec) {
+	*out = *in
+	in.Agent.DeepCopyInto(&out.Agent)
+	in.Processor.DeepCopyInto(&out.Processor)
+	in.Kafka.DeepCopyInto(&out.Kafka)
+	in.Storage.DeepCopyInto(&out.Storage)
+	if in.Exporters != nil {
+		in, out := &in.Exporters, &out.Exporters
+		*out = make([]FlowCollectorExporter, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Console.DeepCopyInto(&out.Console)
+	in.Dashboard.DeepCopyInto(&out.Dashboard)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowCollectorSpec.
+func (in *FlowCollectorSpec) DeepCopy() *FlowCollectorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowCollectorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowCollectorStandalone) DeepCopyInto(out *FlowCollectorStandalone) {
+	*out = *in
+	in.Ingress.DeepCopyInto(&out.Ingress)
+	if in.CertManagerIssuer != nil {
+		in, out := &in.CertManagerIssuer, &out.CertManagerIssuer
+		*out = new(CertManagerIssuerReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowCollectorStandalone.
+func (in *FlowCollectorStandalone) DeepCopy() *FlowCollectorStandalone {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowCollectorStandalone)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowCollectorStatus) DeepCopyInto(out *FlowCollectorStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.OVSNodes != nil {
+		in, out := &in.OVSNodes, &out.OVSNodes
+		*out = make([]OVSNodeStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Certificates != nil {
+		in, out := &in.Certificates, &out.Certificates
+		*out = make([]CertificateStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowCollectorStatus.
+func (in *FlowCollectorStatus) DeepCopy() *FlowCollectorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowCollectorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowCollectorStorage) DeepCopyInto(out *FlowCollectorStorage) {
+	*out = *in
+	in.Loki.DeepCopyInto(&out.Loki)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowCollectorStorage.
+func (in *FlowCollectorStorage) DeepCopy() *FlowCollectorStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowCollectorStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LokiAuth) DeepCopyInto(out *LokiAuth) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LokiAuth.
+func (in *LokiAuth) DeepCopy() *LokiAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(LokiAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LokiStackRef) DeepCopyInto(out *LokiStackRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LokiStackRef.
+func (in *LokiStackRef) DeepCopy() *LokiStackRef {
+	if in == nil {
+		return nil
+	}
+	out := new(LokiStackRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OVSNodeAgent) DeepCopyInto(out *OVSNodeAgent) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OVSNodeAgent.
+func (in *OVSNodeAgent) DeepCopy() *OVSNodeAgent {
+	if in == nil {
+		return nil
+	}
+	out := new(OVSNodeAgent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OVSNodeStatus) DeepCopyInto(out *OVSNodeStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OVSNodeStatus.
+func (in *OVSNodeStatus) DeepCopy() *OVSNodeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OVSNodeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
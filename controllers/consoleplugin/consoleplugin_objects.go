@@ -1,12 +1,23 @@
 package consoleplugin
 
 import (
+	"strings"
+	"time"
+
 	osv1alpha1 "github.com/openshift/api/console/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
-	flowsv1alpha1 "github.com/netobserv/network-observability-operator/api/v1alpha1"
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/constants"
+	"github.com/netobserv/network-observability-operator/controllers/reconcilers"
+	"github.com/netobserv/network-observability-operator/pkg/certificates"
+	"github.com/netobserv/network-observability-operator/pkg/helper"
+	"github.com/netobserv/network-observability-operator/pkg/volumes"
 )
 
 func buildLabels() map[string]string {
@@ -15,13 +26,29 @@ func buildLabels() map[string]string {
 	}
 }
 
-const secretName = "console-serving-cert"
+const secretName = constants.PluginServingCertSecret
 const displayName = "Network Observability plugin"
 
+// caSecretName is the Secret of the CA generated by the operator, when no other issuer of the serving certificate
+// is available
+const caSecretName = "netobserv-ca"
+
+// Validity periods of the certificates generated by the operator, which are renewed after two thirds of it
+const (
+	caValidity          = 3 * 365 * 24 * time.Hour
+	certificateValidity = 365 * 24 * time.Hour
+)
+
+var certificateGVK = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+
 // lokiURLAnnotation contains the used Loki querier URL, facilitating the change management
 const lokiURLAnnotation = "flows.netobserv.io/loki-url"
 
-func buildConsolePlugin(desired *flowsv1alpha1.FlowCollectorConsolePlugin, ns string) *osv1alpha1.ConsolePlugin {
+// PodConfigurationDigest is an annotation name to facilitate pod restart after any configuration change,
+// including the rotation of the mounted certificates and credentials
+const PodConfigurationDigest = "flows.netobserv.io/console-plugin-config"
+
+func buildConsolePlugin(desired *flowsv1beta1.FlowCollectorConsole, ns string) *osv1alpha1.ConsolePlugin {
 	return &osv1alpha1.ConsolePlugin{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: pluginName,
@@ -45,95 +72,243 @@ func buildConsolePlugin(desired *flowsv1alpha1.FlowCollectorConsolePlugin, ns st
 	}
 }
 
-func buildDeployment(desired *flowsv1alpha1.FlowCollectorSpec, ns string) *appsv1.Deployment {
+// buildDeployment returns the plugin Deployment. contentDigest is the digest of the contents of the mounted
+// certificates and credentials.
+func buildDeployment(desired *flowsv1beta1.FlowCollectorSpec, ns, contentDigest string) *appsv1.Deployment {
 	return &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      pluginName,
 			Namespace: ns,
 			Annotations: map[string]string{
-				lokiURLAnnotation: querierURL(&desired.Loki),
+				lokiURLAnnotation: querierURL(&desired.Storage.Loki),
 			},
 		},
 		Spec: appsv1.DeploymentSpec{
-			Replicas: &desired.ConsolePlugin.Replicas,
+			Replicas: &desired.Console.Replicas,
 			Selector: &metav1.LabelSelector{
 				MatchLabels: buildLabels(),
 			},
-			Template: *buildPodTemplate(desired),
+			Template: *buildPodTemplate(desired, contentDigest),
 		},
 	}
 }
 
-func buildPodTemplate(desired *flowsv1alpha1.FlowCollectorSpec) *corev1.PodTemplateSpec {
+func buildPodTemplate(desired *flowsv1beta1.FlowCollectorSpec, contentDigest string) *corev1.PodTemplateSpec {
+	vols := volumes.Builder{}
+	args := []string{
+		"-cert", "/var/serving-cert/tls.crt",
+		"-key", "/var/serving-cert/tls.key",
+		"-loki", querierURL(&desired.Storage.Loki),
+	}
+	if len(desired.Storage.Loki.Labels) > 0 {
+		// Lets the plugin filter on labels in stream selectors
+		args = append(args, "-loki-labels", strings.Join(desired.Storage.Loki.Labels, ","))
+	}
+	if desired.Storage.Loki.TenantID != "" {
+		args = append(args, "-loki-tenant-id", desired.Storage.Loki.TenantID)
+	}
+	args = append(args, buildLokiClientArgs(vols.AddLokiClientFiles(&desired.Storage.Loki))...)
+	spec := corev1.PodSpec{
+		Containers: []corev1.Container{{
+			Name:            pluginName,
+			Image:           desired.Console.Image,
+			ImagePullPolicy: corev1.PullPolicy(desired.Console.ImagePullPolicy),
+			Resources:       *desired.Console.Resources.DeepCopy(),
+			VolumeMounts: append([]corev1.VolumeMount{{
+				Name:      secretName,
+				MountPath: "/var/serving-cert",
+				ReadOnly:  true,
+			}}, vols.GetMounts()...),
+			Args: args,
+		}},
+		Volumes: append([]corev1.Volume{{
+			Name: secretName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: secretName,
+				},
+			},
+		}}, vols.GetVolumes()...),
+		ServiceAccountName: pluginName,
+	}
+	helper.ApplyScheduling(&spec, &desired.Console.Scheduling, false)
+	digest := reconcilers.PodSpecDigest(&spec)
+	if contentDigest != "" {
+		digest += "-" + contentDigest
+	}
 	return &corev1.PodTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
 			Labels: buildLabels(),
+			Annotations: map[string]string{
+				PodConfigurationDigest: digest,
+			},
 		},
-		Spec: corev1.PodSpec{
-			Containers: []corev1.Container{{
-				Name:            pluginName,
-				Image:           desired.ConsolePlugin.Image,
-				ImagePullPolicy: corev1.PullPolicy(desired.ConsolePlugin.ImagePullPolicy),
-				Resources:       *desired.ConsolePlugin.Resources.DeepCopy(),
-				VolumeMounts: []corev1.VolumeMount{{
-					Name:      secretName,
-					MountPath: "/var/serving-cert",
-					ReadOnly:  true,
-				}},
-				Args: []string{
-					"-cert", "/var/serving-cert/tls.crt",
-					"-key", "/var/serving-cert/tls.key",
-					"-loki", querierURL(&desired.Loki),
-				},
+		Spec: spec,
+	}
+}
+
+func querierURL(loki *flowsv1beta1.FlowCollectorLoki) string {
+	if loki.QuerierURL != "" {
+		return loki.QuerierURL
+	}
+	return loki.URL
+}
+
+// buildLokiClientArgs returns the arguments that configure the TLS and the authentication of the plugin Loki client
+func buildLokiClientArgs(files volumes.LokiClientFiles) []string {
+	var args []string
+	if files.InsecureSkipVerify {
+		args = append(args, "-loki-skip-tls")
+	}
+	for _, arg := range []struct{ name, path string }{
+		{"-loki-ca-path", files.CACertPath},
+		{"-loki-user-cert-path", files.UserCertPath},
+		{"-loki-user-key-path", files.UserKeyPath},
+		{"-loki-token-path", files.TokenPath},
+		{"-loki-username-path", files.UsernamePath},
+		{"-loki-password-path", files.PasswordPath},
+	} {
+		if arg.path != "" {
+			args = append(args, arg.name, arg.path)
+		}
+	}
+	return args
+}
+
+// buildService returns the plugin Service. issuer is the issuer of the serving certificate.
+func buildService(desired *flowsv1beta1.FlowCollectorConsole, ns, issuer string) *corev1.Service {
+	annotations := map[string]string{}
+	if issuer == constants.CertIssuerServiceCA {
+		// The serving certificate is issued by the OpenShift service CA
+		annotations["service.alpha.openshift.io/serving-cert-secret-name"] = secretName
+	}
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        pluginName,
+			Namespace:   ns,
+			Labels:      buildLabels(),
+			Annotations: annotations,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: buildLabels(),
+			Ports: []corev1.ServicePort{{
+				Port:     desired.Port,
+				Protocol: corev1.ProtocolTCP,
 			}},
-			Volumes: []corev1.Volume{{
-				Name: secretName,
-				VolumeSource: corev1.VolumeSource{
-					Secret: &corev1.SecretVolumeSource{
-						SecretName: secretName,
+		},
+	}
+}
+
+func buildServiceAccount(ns string) *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pluginName,
+			Namespace: ns,
+			Labels:    buildLabels(),
+		},
+	}
+}
+
+// buildIngress returns the Ingress of the standalone plugin. The serving certificate of the plugin is also used
+// for the Ingress TLS when it is issued by cert-manager, as it then includes the Ingress host.
+func buildIngress(desired *flowsv1beta1.FlowCollectorConsole, ns string) *networkingv1.Ingress {
+	ingress := desired.Standalone.Ingress
+	pathType := networkingv1.PathTypePrefix
+	ing := networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        pluginName,
+			Namespace:   ns,
+			Labels:      buildLabels(),
+			Annotations: ingress.Annotations,
+		},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{
+				Host: ingress.Host,
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     "/",
+							PathType: &pathType,
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{
+									Name: pluginName,
+									Port: networkingv1.ServiceBackendPort{Number: desired.Port},
+								},
+							},
+						}},
 					},
 				},
 			}},
-			ServiceAccountName: pluginName,
 		},
 	}
+	if ingress.IngressClassName != "" {
+		className := ingress.IngressClassName
+		ing.Spec.IngressClassName = &className
+	}
+	if ingress.Host != "" && desired.Standalone.CertManagerIssuer != nil {
+		ing.Spec.TLS = []networkingv1.IngressTLS{{
+			Hosts:      []string{ingress.Host},
+			SecretName: secretName,
+		}}
+	}
+	return &ing
 }
 
-func buildService(old *corev1.Service, desired *flowsv1alpha1.FlowCollectorConsolePlugin, ns string) *corev1.Service {
-	if old == nil {
-		return &corev1.Service{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      pluginName,
-				Namespace: ns,
-				Labels:    buildLabels(),
-				Annotations: map[string]string{
-					"service.alpha.openshift.io/serving-cert-secret-name": "console-serving-cert",
-				},
-			},
-			Spec: corev1.ServiceSpec{
-				Selector: buildLabels(),
-				Ports: []corev1.ServicePort{{
-					Port:     desired.Port,
-					Protocol: "TCP",
-				}},
-			},
-		}
+// servingCertDNSNames returns the DNS names of the serving certificate: the Service, and the Ingress host when set
+func servingCertDNSNames(desired *flowsv1beta1.FlowCollectorConsole, ns string) []string {
+	dnsNames := []string{
+		pluginName + "." + ns + ".svc",
+		pluginName + "." + ns + ".svc.cluster.local",
+	}
+	if host := desired.Standalone.Ingress.Host; host != "" {
+		dnsNames = append(dnsNames, host)
+	}
+	return dnsNames
+}
+
+// buildCertificate returns the cert-manager Certificate of the plugin serving certificate. It is built as
+// unstructured, so that the cert-manager API isn't a dependency.
+func buildCertificate(desired *flowsv1beta1.FlowCollectorConsole, ns string) *unstructured.Unstructured {
+	var dnsNames []interface{}
+	for _, name := range servingCertDNSNames(desired, ns) {
+		dnsNames = append(dnsNames, name)
 	}
-	// In case we're updating an existing service, we need to build from the old one to keep immutable fields such as clusterIP
-	newService := old.DeepCopy()
-	newService.Spec.Ports = []corev1.ServicePort{{
-		Port:     desired.Port,
-		Protocol: corev1.ProtocolUDP,
+	issuer := desired.Standalone.CertManagerIssuer
+	cert := unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"secretName": secretName,
+			"dnsNames":   dnsNames,
+			"issuerRef": map[string]interface{}{
+				"name":  issuer.Name,
+				"kind":  issuer.Kind,
+				"group": certificateGVK.Group,
+			},
+		},
 	}}
-	return newService
+	cert.SetGroupVersionKind(certificateGVK)
+	cert.SetName(pluginName)
+	cert.SetNamespace(ns)
+	cert.SetLabels(buildLabels())
+	return &cert
 }
 
-func buildServiceAccount(ns string) *corev1.ServiceAccount {
-	return &corev1.ServiceAccount{
+// buildTLSSecret returns a Secret holding a certificate generated by the operator. The CA certificate is added
+// for the clients, when provided.
+func buildTLSSecret(name, ns string, pair *certificates.KeyPair, caCertPEM []byte) *corev1.Secret {
+	secret := corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      pluginName,
+			Name:      name,
 			Namespace: ns,
 			Labels:    buildLabels(),
 		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       pair.CertPEM,
+			corev1.TLSPrivateKeyKey: pair.KeyPEM,
+		},
+	}
+	if caCertPEM != nil {
+		secret.Data["ca.crt"] = caCertPEM
 	}
+	return &secret
 }
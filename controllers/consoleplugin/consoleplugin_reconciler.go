@@ -2,48 +2,85 @@ package consoleplugin
 
 import (
 	"context"
-	"reflect"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"time"
 
 	osv1alpha1 "github.com/openshift/api/console/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 
-	flowsv1alpha1 "github.com/netobserv/network-observability-operator/api/v1alpha1"
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/constants"
 	"github.com/netobserv/network-observability-operator/controllers/reconcilers"
+	"github.com/netobserv/network-observability-operator/pkg/certificates"
+	"github.com/netobserv/network-observability-operator/pkg/volumes"
 )
 
-const pluginName = "network-observability-plugin"
+const pluginName = constants.PluginName
+
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=create;update;patch;delete
 
 // Type alias
-type pluginSpec = flowsv1alpha1.FlowCollectorConsolePlugin
+type pluginSpec = flowsv1beta1.FlowCollectorConsole
 
 // CPReconciler reconciles the current console plugin state with the desired configuration
 type CPReconciler struct {
 	reconcilers.ClientHelper
 	nobjMngr *reconcilers.NamespacedObjectManager
 	owned    ownedObjects
+	// serviceCAAvailable tells whether the OpenShift service CA can issue the serving certificate
+	serviceCAAvailable bool
+	now                func() time.Time
+	// servingCert is the Secret of the serving certificate after the last reconciliation or fetch, if issued
+	servingCert *corev1.Secret
+	certStatus  *flowsv1beta1.CertificateStatus
 }
 
 type ownedObjects struct {
 	deployment     *appsv1.Deployment
 	service        *corev1.Service
 	serviceAccount *corev1.ServiceAccount
+	ingress        *networkingv1.Ingress
+	caSecret       *corev1.Secret
+	servingSecret  *corev1.Secret
 }
 
-func NewReconciler(cl reconcilers.ClientHelper, ns, prevNS string) CPReconciler {
+func NewReconciler(cl reconcilers.ClientHelper, ns, prevNS string, serviceCAAvailable bool) CPReconciler {
 	owned := ownedObjects{
 		deployment:     &appsv1.Deployment{},
 		service:        &corev1.Service{},
 		serviceAccount: &corev1.ServiceAccount{},
+		ingress:        &networkingv1.Ingress{},
+		caSecret:       &corev1.Secret{},
+		servingSecret:  &corev1.Secret{},
 	}
 	nobjMngr := reconcilers.NewNamespacedObjectManager(cl, ns, prevNS)
 	nobjMngr.AddManagedObject(pluginName, owned.deployment)
 	nobjMngr.AddManagedObject(pluginName, owned.service)
 	nobjMngr.AddManagedObject(pluginName, owned.serviceAccount)
+	nobjMngr.AddManagedObject(pluginName, owned.ingress)
+	nobjMngr.AddManagedObject(caSecretName, owned.caSecret)
+	nobjMngr.AddManagedObject(secretName, owned.servingSecret)
 
-	return CPReconciler{ClientHelper: cl, nobjMngr: nobjMngr, owned: owned}
+	return CPReconciler{
+		ClientHelper:       cl,
+		nobjMngr:           nobjMngr,
+		owned:              owned,
+		serviceCAAvailable: serviceCAAvailable,
+		now:                time.Now,
+	}
 }
 
 // InitStaticResources inits some "static" / one-shot resources, usually not subject to reconciliation
@@ -55,114 +92,349 @@ func (r *CPReconciler) InitStaticResources(ctx context.Context) error {
 func (r *CPReconciler) PrepareNamespaceChange(ctx context.Context) error {
 	// Switching namespace => delete everything in the previous namespace
 	r.nobjMngr.CleanupNamespace(ctx)
+	if err := r.deleteCertificate(ctx, r.nobjMngr.PreviousNamespace); err != nil {
+		return err
+	}
 	return r.CreateOwned(ctx, buildServiceAccount(r.nobjMngr.Namespace))
 }
 
+// CleanupClusterResources unregisters the plugin from the console, as the cluster-scoped ConsolePlugin is not
+// reliably garbage collected through its owner reference. Nothing is done when the console isn't available.
+func (r *CPReconciler) CleanupClusterResources(ctx context.Context) error {
+	plg := osv1alpha1.ConsolePlugin{}
+	if err := r.Get(ctx, types.NamespacedName{Name: pluginName}, &plg); err != nil {
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return nil
+		}
+		return err
+	}
+	return r.DeleteIfExists(ctx, &plg)
+}
+
+// Cleanup deletes the plugin and unregisters it from the console
+func (r *CPReconciler) Cleanup(ctx context.Context) error {
+	if err := r.nobjMngr.FetchAll(ctx); err != nil {
+		return err
+	}
+	r.nobjMngr.DeleteAll(ctx)
+	if err := r.deleteCertificate(ctx, r.nobjMngr.Namespace); err != nil {
+		return err
+	}
+	return r.CleanupClusterResources(ctx)
+}
+
+// FetchAll retrieves the current owned objects, so that CheckReadiness reports their state when they are not
+// reconciled
+func (r *CPReconciler) FetchAll(ctx context.Context) error {
+	if err := r.nobjMngr.FetchAll(ctx); err != nil {
+		return err
+	}
+	if r.nobjMngr.Exists(r.owned.servingSecret) {
+		r.servingCert = r.owned.servingSecret
+	}
+	return nil
+}
+
 // Reconcile is the reconciler entry point to reconcile the current plugin state with the desired configuration
-func (r *CPReconciler) Reconcile(ctx context.Context, desired *flowsv1alpha1.FlowCollectorSpec) error {
+func (r *CPReconciler) Reconcile(ctx context.Context, desired *flowsv1beta1.FlowCollectorSpec) error {
 	ns := r.nobjMngr.Namespace
 	// Retrieve current owned objects
 	err := r.nobjMngr.FetchAll(ctx)
 	if err != nil {
 		return err
 	}
-
-	// Console plugin is cluster-scope (it's not deployed in our namespace) however it must still be updated if our namespace changes
-	oldPlg := osv1alpha1.ConsolePlugin{}
-	pluginExists := true
-	err = r.Get(ctx, types.NamespacedName{Name: pluginName}, &oldPlg)
-	if err != nil {
-		if errors.IsNotFound(err) {
-			pluginExists = false
-		} else {
+	// The service account is restored after a removal of the plugin
+	if !r.nobjMngr.Exists(r.owned.serviceAccount) {
+		if err := r.CreateOwned(ctx, buildServiceAccount(ns)); err != nil {
 			return err
 		}
 	}
 
-	// Check if objects need update
-	consolePlugin := buildConsolePlugin(&desired.ConsolePlugin, ns)
-	if !pluginExists {
-		if err := r.CreateOwned(ctx, consolePlugin); err != nil {
-			return err
-		}
-	} else if pluginNeedsUpdate(&oldPlg, &desired.ConsolePlugin, ns) {
-		if err := r.UpdateOwned(ctx, &oldPlg, consolePlugin); err != nil {
-			return err
-		}
+	// The plugin is either registered in the console, or exposed through an Ingress
+	if desired.Console.Standalone.Enable {
+		err = r.reconcileStandalone(ctx, &desired.Console)
+	} else {
+		err = r.reconcileConsolePlugin(ctx, &desired.Console)
+	}
+	if err != nil {
+		return err
+	}
+	issuer := r.certificateIssuer(&desired.Console)
+	if err := r.reconcileCertificate(ctx, &desired.Console, issuer); err != nil {
+		return err
 	}
 
-	newDepl := buildDeployment(desired, ns)
+	contentDigest, err := r.contentDigest(ctx, desired)
+	if err != nil {
+		return err
+	}
+	newDepl := buildDeployment(desired, ns, contentDigest)
 	if !r.nobjMngr.Exists(r.owned.deployment) {
 		if err := r.CreateOwned(ctx, newDepl); err != nil {
 			return err
 		}
-	} else if deploymentNeedsUpdate(r.owned.deployment, desired, ns) {
-		if err := r.UpdateOwned(ctx, r.owned.deployment, newDepl); err != nil {
+	} else if reconcilers.NeedsUpdate(r.owned.deployment, newDepl) {
+		if err := r.UpdateOwned(ctx, newDepl); err != nil {
 			return err
 		}
 	}
 
+	newSVC := buildService(&desired.Console, ns, issuer)
 	if !r.nobjMngr.Exists(r.owned.service) {
-		newSVC := buildService(nil, &desired.ConsolePlugin, ns)
 		if err := r.CreateOwned(ctx, newSVC); err != nil {
 			return err
 		}
-	} else if serviceNeedsUpdate(r.owned.service, &desired.ConsolePlugin, ns) {
-		newSVC := buildService(r.owned.service, &desired.ConsolePlugin, ns)
-		if err := r.UpdateOwned(ctx, r.owned.service, newSVC); err != nil {
+	} else if reconcilers.NeedsUpdate(r.owned.service, newSVC) {
+		if err := r.UpdateOwned(ctx, newSVC); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func pluginNeedsUpdate(plg *osv1alpha1.ConsolePlugin, desired *pluginSpec, ns string) bool {
-	return plg.Spec.Service.Namespace != ns ||
-		plg.Spec.Service.Port != desired.Port
+func (r *CPReconciler) reconcileConsolePlugin(ctx context.Context, desired *pluginSpec) error {
+	r.nobjMngr.TryDelete(ctx, r.owned.ingress)
+	// Console plugin is cluster-scope (it's not deployed in our namespace) however it must still be updated if our namespace changes
+	oldPlg := osv1alpha1.ConsolePlugin{}
+	pluginExists := true
+	err := r.Get(ctx, types.NamespacedName{Name: pluginName}, &oldPlg)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			pluginExists = false
+		} else {
+			return err
+		}
+	}
+
+	// Check if objects need update
+	consolePlugin := buildConsolePlugin(desired, r.nobjMngr.Namespace)
+	if !pluginExists {
+		return r.CreateOwned(ctx, consolePlugin)
+	} else if reconcilers.NeedsUpdate(&oldPlg, consolePlugin) {
+		return r.UpdateOwned(ctx, consolePlugin)
+	}
+	return nil
 }
 
-func deploymentNeedsUpdate(depl *appsv1.Deployment, desired *flowsv1alpha1.FlowCollectorSpec, ns string) bool {
-	if depl.Namespace != ns {
-		return true
+// reconcileStandalone exposes the plugin through an Ingress, and unregisters it from the console if needed
+func (r *CPReconciler) reconcileStandalone(ctx context.Context, desired *pluginSpec) error {
+	if err := r.CleanupClusterResources(ctx); err != nil {
+		return err
 	}
-	return containerNeedsUpdate(&depl.Spec.Template.Spec, &desired.ConsolePlugin) ||
-		hasLokiURLChanged(depl, &desired.Loki) ||
-		*depl.Spec.Replicas != desired.ConsolePlugin.Replicas
+	newIngress := buildIngress(desired, r.nobjMngr.Namespace)
+	if !r.nobjMngr.Exists(r.owned.ingress) {
+		return r.CreateOwned(ctx, newIngress)
+	} else if reconcilers.NeedsUpdate(r.owned.ingress, newIngress) {
+		return r.UpdateOwned(ctx, newIngress)
+	}
+	return nil
 }
 
-func hasLokiURLChanged(depl *appsv1.Deployment, loki *flowsv1alpha1.FlowCollectorLoki) bool {
-	return depl.Annotations[lokiURLAnnotation] != querierURL(loki)
+// contentDigest returns the digest of the contents of the certificates and credentials mounted in the pods, so
+// that their rotation triggers a rollout
+func (r *CPReconciler) contentDigest(ctx context.Context, desired *flowsv1beta1.FlowCollectorSpec) (string, error) {
+	vols := volumes.Builder{}
+	vols.AddLokiClientFiles(&desired.Storage.Loki)
+	digest, err := r.VolumesContentDigest(ctx, r.nobjMngr.Namespace, vols.GetVolumes())
+	if err != nil || r.servingCert == nil {
+		return digest, err
+	}
+	// The serving certificate isn't part of the digested volumes, as it may not be issued yet
+	hasher := fnv.New64a()
+	b, err := json.Marshal(r.servingCert.Data)
+	if err != nil {
+		return "", err
+	}
+	_, _ = hasher.Write(b)
+	if digest != "" {
+		digest += "-"
+	}
+	return digest + strconv.FormatUint(hasher.Sum64(), 36), nil
 }
 
-func querierURL(loki *flowsv1alpha1.FlowCollectorLoki) string {
-	if loki.QuerierURL != "" {
-		return loki.QuerierURL
+// certificateIssuer returns the issuer of the serving certificate: cert-manager when configured, otherwise the
+// OpenShift service CA when available, or the operator itself
+func (r *CPReconciler) certificateIssuer(desired *pluginSpec) string {
+	switch {
+	case desired.Standalone.CertManagerIssuer != nil:
+		return constants.CertIssuerCertManager
+	case r.serviceCAAvailable:
+		return constants.CertIssuerServiceCA
+	default:
+		return constants.CertIssuerOperator
 	}
-	return loki.URL
 }
 
-func serviceNeedsUpdate(svc *corev1.Service, desired *flowsv1alpha1.FlowCollectorConsolePlugin, ns string) bool {
-	if svc.Namespace != ns {
-		return true
+// reconcileCertificate makes the serving certificate issued by the provided issuer, and reports its state
+func (r *CPReconciler) reconcileCertificate(ctx context.Context, desired *pluginSpec, issuer string) error {
+	var err error
+	if issuer == constants.CertIssuerOperator {
+		err = r.reconcileGeneratedCertificate(ctx, desired)
+	} else {
+		err = r.reconcileIssuedCertificate(ctx, desired, issuer)
+	}
+	if err != nil {
+		return err
 	}
-	for _, port := range svc.Spec.Ports {
-		if port.Port == desired.Port && port.Protocol == "TCP" {
-			return false
+	return r.updateCertificateStatus(issuer)
+}
+
+// reconcileIssuedCertificate requests the serving certificate to cert-manager, or lets the service CA issue it
+// through the Service annotation. The certificates previously generated by the operator are removed.
+func (r *CPReconciler) reconcileIssuedCertificate(ctx context.Context, desired *pluginSpec, issuer string) error {
+	r.nobjMngr.TryDelete(ctx, r.owned.caSecret)
+	r.servingCert = nil
+	if r.nobjMngr.Exists(r.owned.servingSecret) {
+		if isGenerated(r.owned.servingSecret) {
+			r.nobjMngr.TryDelete(ctx, r.owned.servingSecret)
+		} else {
+			r.servingCert = r.owned.servingSecret
 		}
 	}
-	return true
+	ns := r.nobjMngr.Namespace
+	if issuer != constants.CertIssuerCertManager {
+		return r.deleteCertificate(ctx, ns)
+	}
+	newCert := buildCertificate(desired, ns)
+	cert, err := r.getCertificate(ctx, ns)
+	switch {
+	case meta.IsNoMatchError(err):
+		return fmt.Errorf("a cert-manager issuer is configured, but the cert-manager API is not available: %w", err)
+	case errors.IsNotFound(err):
+		return r.CreateOwned(ctx, newCert)
+	case err != nil:
+		return err
+	}
+	if !equality.Semantic.DeepEqual(cert.Object["spec"], newCert.Object["spec"]) {
+		return r.UpdateOwned(ctx, newCert)
+	}
+	return nil
 }
 
-func containerNeedsUpdate(podSpec *corev1.PodSpec, desired *pluginSpec) bool {
-	container := reconcilers.FindContainer(podSpec, pluginName)
-	if container == nil {
-		return true
+// getCertificate returns the cert-manager Certificate of the plugin in the provided namespace. A NoMatch error
+// is returned when cert-manager isn't installed.
+func (r *CPReconciler) getCertificate(ctx context.Context, ns string) (*unstructured.Unstructured, error) {
+	cert := unstructured.Unstructured{}
+	cert.SetGroupVersionKind(certificateGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: pluginName, Namespace: ns}, &cert); err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// reconcileGeneratedCertificate generates the CA and the serving certificate, and renews them ahead of their
+// expiry. The serving certificate is also renewed when its DNS names change.
+func (r *CPReconciler) reconcileGeneratedCertificate(ctx context.Context, desired *pluginSpec) error {
+	ns := r.nobjMngr.Namespace
+	if err := r.deleteCertificate(ctx, ns); err != nil {
+		return err
+	}
+	now := r.now()
+	ca := r.currentKeyPair(r.owned.caSecret)
+	renewCA := ca == nil || !certificates.IsValid(ca, nil, now)
+	if renewCA {
+		var err error
+		if ca, err = certificates.NewCA(caSecretName, now, caValidity); err != nil {
+			return err
+		}
+		if err := r.writeSecret(ctx, r.owned.caSecret, buildTLSSecret(caSecretName, ns, ca, nil)); err != nil {
+			return err
+		}
+	}
+	caCert, err := certificates.ParseCertificate(ca.CertPEM)
+	if err != nil {
+		return err
+	}
+	dnsNames := servingCertDNSNames(desired, ns)
+	serving := r.currentKeyPair(r.owned.servingSecret)
+	if !renewCA && serving != nil && certificates.IsValid(serving, caCert, now) &&
+		certificates.MatchesDNSNames(serving.CertPEM, dnsNames) {
+		r.servingCert = r.owned.servingSecret
+		return nil
+	}
+	if serving, err = certificates.NewServingCertificate(ca, dnsNames, now, certificateValidity); err != nil {
+		return err
 	}
-	if desired.Image != container.Image || desired.ImagePullPolicy != string(container.ImagePullPolicy) {
-		return true
+	r.servingCert = buildTLSSecret(secretName, ns, serving, ca.CertPEM)
+	return r.writeSecret(ctx, r.owned.servingSecret, r.servingCert)
+}
+
+// currentKeyPair returns the key pair of a current Secret, if it exists
+func (r *CPReconciler) currentKeyPair(secret *corev1.Secret) *certificates.KeyPair {
+	if !r.nobjMngr.Exists(secret) {
+		return nil
+	}
+	return &certificates.KeyPair{CertPEM: secret.Data[corev1.TLSCertKey], KeyPEM: secret.Data[corev1.TLSPrivateKeyKey]}
+}
+
+func (r *CPReconciler) writeSecret(ctx context.Context, current, desired *corev1.Secret) error {
+	if r.nobjMngr.Exists(current) {
+		return r.UpdateOwned(ctx, desired)
+	}
+	return r.CreateOwned(ctx, desired)
+}
+
+// isGenerated tells whether a Secret holds a certificate generated by the operator, rather than issued by the
+// service CA or cert-manager
+func isGenerated(secret *corev1.Secret) bool {
+	return secret.Labels["app"] == pluginName
+}
+
+// updateCertificateStatus reports the state of the serving certificate. The renewal time is only known for the
+// certificates generated by the operator: it is the earliest renewal time of the certificate and its CA.
+func (r *CPReconciler) updateCertificateStatus(issuer string) error {
+	r.certStatus = &flowsv1beta1.CertificateStatus{SecretName: secretName, Issuer: issuer}
+	if r.servingCert == nil {
+		return nil
+	}
+	cert, err := certificates.ParseCertificate(r.servingCert.Data[corev1.TLSCertKey])
+	if err != nil {
+		// The certificate is not issued by the operator, which doesn't have to understand it
+		return nil
+	}
+	notAfter := metav1.NewTime(cert.NotAfter)
+	r.certStatus.NotAfter = &notAfter
+	if issuer != constants.CertIssuerOperator {
+		return nil
+	}
+	renewAfter := certificates.RenewalTime(cert)
+	caCert, err := certificates.ParseCertificate(r.servingCert.Data["ca.crt"])
+	if err != nil {
+		return err
+	}
+	if caRenewal := certificates.RenewalTime(caCert); caRenewal.Before(renewAfter) {
+		renewAfter = caRenewal
+	}
+	r.certStatus.RenewAfter = &metav1.Time{Time: renewAfter}
+	return nil
+}
+
+// CertificateStatus returns the state of the serving certificate after the last reconciliation, if any
+func (r *CPReconciler) CertificateStatus() *flowsv1beta1.CertificateStatus {
+	return r.certStatus
+}
+
+func (r *CPReconciler) deleteCertificate(ctx context.Context, ns string) error {
+	cert, err := r.getCertificate(ctx, ns)
+	if err != nil {
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return nil
+		}
+		return err
+	}
+	return r.DeleteIfExists(ctx, cert)
+}
+
+// CheckReadiness tells whether the plugin deployment, as fetched during the last reconciliation,
+// has completed its rollout. A message describing the rollout state is also returned.
+func (r *CPReconciler) CheckReadiness() (bool, string) {
+	if !r.nobjMngr.Exists(r.owned.deployment) {
+		return false, "Deployment " + pluginName + " is being created"
 	}
-	if !reflect.DeepEqual(desired.Resources, container.Resources) {
-		return true
+	ready, msg := reconcilers.DeploymentProgress(r.owned.deployment)
+	if !ready && r.servingCert == nil {
+		// Otherwise, the pods wait for it without any explanation
+		msg += ": the serving certificate Secret " + secretName + " is not issued yet"
 	}
-	return false
+	return ready, msg
 }
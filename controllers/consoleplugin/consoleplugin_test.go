@@ -1,14 +1,32 @@
 package consoleplugin
 
 import (
+	"context"
+	"crypto/x509"
 	"testing"
+	"time"
 
+	osv1alpha1 "github.com/openshift/api/console/v1alpha1"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
-	flowsv1alpha1 "github.com/netobserv/network-observability-operator/api/v1alpha1"
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/constants"
+	"github.com/netobserv/network-observability-operator/controllers/controllerstest"
+	"github.com/netobserv/network-observability-operator/controllers/reconcilers"
+	"github.com/netobserv/network-observability-operator/pkg/certificates"
 )
 
 const testImage = "quay.io/netobserv/network-observability-console-plugin:dev"
@@ -22,8 +40,8 @@ var testResources = corev1.ResourceRequirements{
 	},
 }
 
-func getPluginConfig() flowsv1alpha1.FlowCollectorConsolePlugin {
-	return flowsv1alpha1.FlowCollectorConsolePlugin{
+func getPluginConfig() flowsv1beta1.FlowCollectorConsole {
+	return flowsv1beta1.FlowCollectorConsole{
 		Port:            9001,
 		Image:           testImage,
 		ImagePullPolicy: string(testPullPolicy),
@@ -31,107 +49,373 @@ func getPluginConfig() flowsv1alpha1.FlowCollectorConsolePlugin {
 	}
 }
 
-func getContainerSpecs() (corev1.PodSpec, flowsv1alpha1.FlowCollectorConsolePlugin) {
-	var podSpec = corev1.PodSpec{
-		Containers: []corev1.Container{
-			{
-				Name:            pluginName,
-				Image:           testImage,
-				Resources:       testResources,
-				ImagePullPolicy: testPullPolicy,
-			},
-		},
-	}
-
-	return podSpec, getPluginConfig()
-}
-
-func getServiceSpecs() (corev1.Service, flowsv1alpha1.FlowCollectorConsolePlugin) {
-	var service = corev1.Service{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace: testNamespace,
-		},
-		Spec: corev1.ServiceSpec{
-			Ports: []corev1.ServicePort{
-				{
-					Port:     9001,
-					Protocol: "TCP",
-				},
-			},
-		},
+func getFlowCollectorSpec() flowsv1beta1.FlowCollectorSpec {
+	return flowsv1beta1.FlowCollectorSpec{
+		Storage: flowsv1beta1.FlowCollectorStorage{Loki: flowsv1beta1.FlowCollectorLoki{URL: "http://foo:1234"}},
+		Console: getPluginConfig(),
 	}
-
-	return service, getPluginConfig()
 }
 
-func TestContainerUpdateCheck(t *testing.T) {
+func TestDeploymentUpdateCheck(t *testing.T) {
 	assert := assert.New(t)
 
 	//equals specs
-	podSpec, containerConfig := getContainerSpecs()
-	assert.Equal(containerNeedsUpdate(&podSpec, &containerConfig), false)
+	config := getFlowCollectorSpec()
+	current := controllerstest.AsWritten(buildDeployment(&config, testNamespace, ""))
+	assert.False(reconcilers.NeedsUpdate(current, buildDeployment(&config, testNamespace, "")))
 
 	//wrong resources
-	podSpec, containerConfig = getContainerSpecs()
-	containerConfig.Resources.Limits = map[corev1.ResourceName]resource.Quantity{
+	config.Console.Resources.Limits = map[corev1.ResourceName]resource.Quantity{
 		corev1.ResourceCPU:    resource.MustParse("500m"),
 		corev1.ResourceMemory: resource.MustParse("500Gi"),
 	}
-	assert.Equal(containerNeedsUpdate(&podSpec, &containerConfig), true)
+	assert.True(reconcilers.NeedsUpdate(current, buildDeployment(&config, testNamespace, "")))
 
 	//new image
-	podSpec, containerConfig = getContainerSpecs()
-	containerConfig.Image = "quay.io/netobserv/network-observability-console-plugin:latest"
-	assert.Equal(containerNeedsUpdate(&podSpec, &containerConfig), true)
+	config = getFlowCollectorSpec()
+	config.Console.Image = "quay.io/netobserv/network-observability-console-plugin:latest"
+	assert.True(reconcilers.NeedsUpdate(current, buildDeployment(&config, testNamespace, "")))
 
 	//new pull policy
-	podSpec, containerConfig = getContainerSpecs()
-	containerConfig.ImagePullPolicy = string(corev1.PullAlways)
-	assert.Equal(containerNeedsUpdate(&podSpec, &containerConfig), true)
+	config = getFlowCollectorSpec()
+	config.Console.ImagePullPolicy = string(corev1.PullAlways)
+	assert.True(reconcilers.NeedsUpdate(current, buildDeployment(&config, testNamespace, "")))
+
+	//new Loki URL
+	config = getFlowCollectorSpec()
+	config.Storage.Loki.QuerierURL = "http://querier:1234"
+	assert.True(reconcilers.NeedsUpdate(current, buildDeployment(&config, testNamespace, "")))
+
+	//wrong namespace
+	config = getFlowCollectorSpec()
+	assert.True(reconcilers.NeedsUpdate(current, buildDeployment(&config, "OldNamespace", "")))
 
+	//any modified field is detected
+	assert.Empty(controllerstest.UndetectedDrifts(buildDeployment(&config, testNamespace, "")))
 }
 
 func TestServiceUpdateCheck(t *testing.T) {
 	assert := assert.New(t)
 
 	//equals specs
-	serviceSpec, containerConfig := getServiceSpecs()
-	assert.Equal(serviceNeedsUpdate(&serviceSpec, &containerConfig, testNamespace), false)
+	config := getPluginConfig()
+	current := controllerstest.AsWritten(buildService(&config, testNamespace, constants.CertIssuerServiceCA))
+	assert.False(reconcilers.NeedsUpdate(current, buildService(&config, testNamespace, constants.CertIssuerServiceCA)))
+
+	//annotations added by the service CA operator are ignored
+	svc := current.DeepCopyObject().(*corev1.Service)
+	svc.Annotations["service.alpha.openshift.io/serving-cert-signed-by"] = "openshift-service-serving-signer"
+	svc.Spec.ClusterIP = "172.30.0.10"
+	assert.False(reconcilers.NeedsUpdate(svc, buildService(&config, testNamespace, constants.CertIssuerServiceCA)))
 
 	//wrong port protocol
-	serviceSpec, containerConfig = getServiceSpecs()
-	serviceSpec.Spec.Ports[0].Protocol = "UDP"
-	assert.Equal(serviceNeedsUpdate(&serviceSpec, &containerConfig, testNamespace), true)
+	svc = current.DeepCopyObject().(*corev1.Service)
+	svc.Spec.Ports[0].Protocol = corev1.ProtocolUDP
+	assert.True(reconcilers.NeedsUpdate(svc, buildService(&config, testNamespace, constants.CertIssuerServiceCA)))
 
 	//wrong port number
-	serviceSpec, containerConfig = getServiceSpecs()
-	serviceSpec.Spec.Ports[0].Port = 8080
-	assert.Equal(serviceNeedsUpdate(&serviceSpec, &containerConfig, testNamespace), true)
+	config.Port = 8080
+	assert.True(reconcilers.NeedsUpdate(current, buildService(&config, testNamespace, constants.CertIssuerServiceCA)))
+
+	//wrong namespace
+	config = getPluginConfig()
+	assert.True(reconcilers.NeedsUpdate(current, buildService(&config, "OldNamespace", constants.CertIssuerServiceCA)))
+
+	//any modified field is detected
+	assert.Empty(controllerstest.UndetectedDrifts(buildService(&config, testNamespace, constants.CertIssuerServiceCA)))
+}
+
+func TestConsolePluginUpdateCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	config := getPluginConfig()
+	current := controllerstest.AsWritten(buildConsolePlugin(&config, testNamespace))
+	assert.False(reconcilers.NeedsUpdate(current, buildConsolePlugin(&config, testNamespace)))
 
 	//wrong namespace
-	serviceSpec, containerConfig = getServiceSpecs()
-	serviceSpec.Namespace = "OldNamespace"
-	assert.Equal(serviceNeedsUpdate(&serviceSpec, &containerConfig, testNamespace), true)
+	assert.True(reconcilers.NeedsUpdate(current, buildConsolePlugin(&config, "OldNamespace")))
+
+	//wrong port number
+	config.Port = 8080
+	assert.True(reconcilers.NeedsUpdate(current, buildConsolePlugin(&config, testNamespace)))
+
+	//any modified field is detected
+	assert.Empty(controllerstest.UndetectedDrifts(buildConsolePlugin(&config, testNamespace)))
+}
+
+func TestSchedulingUpdateCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	config := getFlowCollectorSpec()
+	config.Console.Scheduling = flowsv1beta1.FlowCollectorScheduling{
+		NodeSelector:      map[string]string{"node-role.kubernetes.io/infra": ""},
+		Tolerations:       []corev1.Toleration{{Key: "node-role.kubernetes.io/infra", Operator: corev1.TolerationOpExists}},
+		PriorityClassName: "system-cluster-critical",
+		Affinity: &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
+				LabelSelector: &metav1.LabelSelector{MatchLabels: buildLabels()},
+				TopologyKey:   "kubernetes.io/hostname",
+			}},
+		}},
+		TopologySpreadConstraints: []corev1.TopologySpreadConstraint{{
+			MaxSkew:           1,
+			TopologyKey:       "topology.kubernetes.io/zone",
+			WhenUnsatisfiable: corev1.ScheduleAnyway,
+			LabelSelector:     &metav1.LabelSelector{MatchLabels: buildLabels()},
+		}},
+	}
+	current := controllerstest.AsWritten(buildDeployment(&config, testNamespace, ""))
+	podSpec := current.(*appsv1.Deployment).Spec.Template.Spec
+	assert.Equal(map[string]string{"node-role.kubernetes.io/infra": ""}, podSpec.NodeSelector)
+	assert.Equal("system-cluster-critical", podSpec.PriorityClassName)
+	assert.Equal(config.Console.Scheduling.Affinity, podSpec.Affinity)
+	assert.Equal(config.Console.Scheduling.TopologySpreadConstraints, podSpec.TopologySpreadConstraints)
+	assert.False(reconcilers.NeedsUpdate(current, buildDeployment(&config, testNamespace, "")))
+	assert.Empty(controllerstest.UndetectedDrifts(buildDeployment(&config, testNamespace, "")))
+
+	//removed constraint
+	config.Console.Scheduling.NodeSelector = nil
+	assert.True(reconcilers.NeedsUpdate(current, buildDeployment(&config, testNamespace, "")))
+}
+
+func TestBuiltLokiClientArgs(t *testing.T) {
+	assert := assert.New(t)
+
+	config := flowsv1beta1.FlowCollectorSpec{
+		Storage: flowsv1beta1.FlowCollectorStorage{Loki: flowsv1beta1.FlowCollectorLoki{
+			URL:      "https://loki-gateway:8080",
+			TenantID: "netobserv",
+			Labels:   []string{"SrcNamespace", "DstNamespace"},
+			TLS: flowsv1beta1.ClientTLS{
+				Enable: true,
+				CACert: flowsv1beta1.CertificateReference{Type: "configmap", Name: "loki-ca", CertFile: "ca.crt"},
+			},
+			Auth: flowsv1beta1.LokiAuth{Type: "BearerToken", SecretName: "loki-token", TokenKey: "token"},
+		}},
+		Console: getPluginConfig(),
+	}
+	tmpl := buildPodTemplate(&config, "")
+	container := tmpl.Spec.Containers[0]
+	assert.Equal([]string{
+		"-cert", "/var/serving-cert/tls.crt",
+		"-key", "/var/serving-cert/tls.key",
+		"-loki", "https://loki-gateway:8080",
+		"-loki-labels", "SrcNamespace,DstNamespace",
+		"-loki-tenant-id", "netobserv",
+		"-loki-ca-path", "/var/loki-ca/ca.crt",
+		"-loki-token-path", "/var/loki-auth/token",
+	}, container.Args)
+	assert.Len(tmpl.Spec.Volumes, 3)
+	assert.Len(container.VolumeMounts, 3)
+
+	// The rotation of the mounted certificates changes the pod configuration digest
+	rotated := buildPodTemplate(&config, "rotated")
+	assert.NotEqual(tmpl.Annotations[PodConfigurationDigest], rotated.Annotations[PodConfigurationDigest])
+}
+
+func getStandaloneConfig() flowsv1beta1.FlowCollectorConsole {
+	config := getPluginConfig()
+	config.Standalone = flowsv1beta1.FlowCollectorStandalone{
+		Enable: true,
+		Ingress: flowsv1beta1.FlowCollectorIngress{
+			Host:             "netobserv.example.com",
+			IngressClassName: "nginx",
+			Annotations:      map[string]string{"nginx.ingress.kubernetes.io/backend-protocol": "HTTPS"},
+		},
+		CertManagerIssuer: &flowsv1beta1.CertManagerIssuerReference{Name: "ca-issuer", Kind: "ClusterIssuer"},
+	}
+	return config
+}
+
+func TestIngressUpdateCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	config := getStandaloneConfig()
+	ing := buildIngress(&config, testNamespace)
+	assert.Equal("nginx", *ing.Spec.IngressClassName)
+	assert.Equal("netobserv.example.com", ing.Spec.Rules[0].Host)
+	assert.Equal(int32(9001), ing.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Port.Number)
+	assert.Equal([]networkingv1.IngressTLS{{Hosts: []string{"netobserv.example.com"}, SecretName: secretName}}, ing.Spec.TLS)
+
+	//equals specs
+	current := controllerstest.AsWritten(ing)
+	assert.False(reconcilers.NeedsUpdate(current, buildIngress(&config, testNamespace)))
+
+	//new host
+	config.Standalone.Ingress.Host = "flows.example.com"
+	assert.True(reconcilers.NeedsUpdate(current, buildIngress(&config, testNamespace)))
 
+	//without cert-manager, the TLS of the Ingress is left to the ingress controller
+	config.Standalone.CertManagerIssuer = nil
+	assert.Empty(buildIngress(&config, testNamespace).Spec.TLS)
+
+	//any modified field is detected
+	assert.Empty(controllerstest.UndetectedDrifts(ing))
+}
+
+func TestServiceCertificate(t *testing.T) {
+	assert := assert.New(t)
+
+	//the serving certificate is issued by the service CA by default
+	config := getPluginConfig()
+	svc := buildService(&config, testNamespace, constants.CertIssuerServiceCA)
+	assert.Equal(secretName, svc.Annotations["service.alpha.openshift.io/serving-cert-secret-name"])
+
+	//or by cert-manager, for the Service and the Ingress host
+	config = getStandaloneConfig()
+	svc = buildService(&config, testNamespace, constants.CertIssuerCertManager)
+	assert.NotContains(svc.Annotations, "service.alpha.openshift.io/serving-cert-secret-name")
+	cert := buildCertificate(&config, testNamespace)
+	dnsNames, _, _ := unstructured.NestedStringSlice(cert.Object, "spec", "dnsNames")
+	assert.Equal([]string{
+		pluginName + "." + testNamespace + ".svc",
+		pluginName + "." + testNamespace + ".svc.cluster.local",
+		"netobserv.example.com",
+	}, dnsNames)
+	issuer, _, _ := unstructured.NestedStringMap(cert.Object, "spec", "issuerRef")
+	assert.Equal(map[string]string{"name": "ca-issuer", "kind": "ClusterIssuer", "group": "cert-manager.io"}, issuer)
 }
 
-func TestBuiltContainer(t *testing.T) {
+func TestStandaloneReconcile(t *testing.T) {
 	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
 
-	//newly created containers should not need update
-	config := flowsv1alpha1.FlowCollectorSpec{
-		Loki:          flowsv1alpha1.FlowCollectorLoki{URL: "http://foo:1234"},
-		ConsolePlugin: getPluginConfig(),
+	scheme := runtime.NewScheme()
+	require.NoError(clientgoscheme.AddToScheme(scheme))
+	require.NoError(osv1alpha1.AddToScheme(scheme))
+	cl := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(&osv1alpha1.ConsolePlugin{ObjectMeta: metav1.ObjectMeta{Name: pluginName}}).
+		Build()
+	newReconciler := func() CPReconciler {
+		return NewReconciler(reconcilers.ClientHelper{
+			Client:                 controllerstest.NewFakeApplyClient(cl),
+			SetControllerReference: func(client.Object) error { return nil },
+			ForceOwnership:         true,
+		}, testNamespace, "", true)
 	}
-	newContainer := buildPodTemplate(&config)
-	assert.Equal(containerNeedsUpdate(&newContainer.Spec, &config.ConsolePlugin), false)
+	key := types.NamespacedName{Name: pluginName, Namespace: testNamespace}
+	spec := getFlowCollectorSpec()
+	spec.Console = getStandaloneConfig()
+
+	// In standalone mode, the plugin is exposed through an Ingress instead of being registered in the console
+	r := newReconciler()
+	require.NoError(r.Reconcile(ctx, &spec))
+	assert.True(errors.IsNotFound(cl.Get(ctx, types.NamespacedName{Name: pluginName}, &osv1alpha1.ConsolePlugin{})))
+	require.NoError(cl.Get(ctx, key, &networkingv1.Ingress{}))
+	require.NoError(cl.Get(ctx, key, &appsv1.Deployment{}))
+	cert := unstructured.Unstructured{}
+	cert.SetGroupVersionKind(certificateGVK)
+	require.NoError(cl.Get(ctx, key, &cert))
+
+	// Back to the console plugin
+	spec.Console = getPluginConfig()
+	r = newReconciler()
+	require.NoError(r.Reconcile(ctx, &spec))
+	require.NoError(cl.Get(ctx, types.NamespacedName{Name: pluginName}, &osv1alpha1.ConsolePlugin{}))
+	assert.True(errors.IsNotFound(cl.Get(ctx, key, &networkingv1.Ingress{})))
+	assert.True(errors.IsNotFound(cl.Get(ctx, key, &cert)))
 }
 
-func TestBuiltService(t *testing.T) {
+func TestGeneratedCertificate(t *testing.T) {
 	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(clientgoscheme.AddToScheme(scheme))
+	require.NoError(osv1alpha1.AddToScheme(scheme))
+	cl := fake.NewClientBuilder().WithScheme(scheme).Build()
+	now := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	newReconciler := func(serviceCAAvailable bool) CPReconciler {
+		r := NewReconciler(reconcilers.ClientHelper{
+			Client:                 controllerstest.NewFakeApplyClient(cl),
+			SetControllerReference: func(client.Object) error { return nil },
+			ForceOwnership:         true,
+		}, testNamespace, "", serviceCAAvailable)
+		r.now = func() time.Time { return now }
+		return r
+	}
+	key := types.NamespacedName{Name: pluginName, Namespace: testNamespace}
+	caKey := types.NamespacedName{Name: caSecretName, Namespace: testNamespace}
+	certKey := types.NamespacedName{Name: secretName, Namespace: testNamespace}
+	podDigest := func() string {
+		depl := appsv1.Deployment{}
+		require.NoError(cl.Get(ctx, key, &depl))
+		return depl.Spec.Template.Annotations[PodConfigurationDigest]
+	}
+	spec := getFlowCollectorSpec()
+	spec.Console.Replicas = 1
+	spec.Console.Standalone = flowsv1beta1.FlowCollectorStandalone{
+		Enable:  true,
+		Ingress: flowsv1beta1.FlowCollectorIngress{Host: "netobserv.example.com"},
+	}
+
+	// Without the service CA, the operator generates a CA and the serving certificate
+	r := newReconciler(false)
+	require.NoError(r.Reconcile(ctx, &spec))
+	ca := corev1.Secret{}
+	require.NoError(cl.Get(ctx, caKey, &ca))
+	secret := corev1.Secret{}
+	require.NoError(cl.Get(ctx, certKey, &secret))
+	assert.Equal(ca.Data[corev1.TLSCertKey], secret.Data["ca.crt"])
+	caCert, err := certificates.ParseCertificate(ca.Data[corev1.TLSCertKey])
+	require.NoError(err)
+	cert, err := certificates.ParseCertificate(secret.Data[corev1.TLSCertKey])
+	require.NoError(err)
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	_, err = cert.Verify(x509.VerifyOptions{DNSName: "netobserv.example.com", Roots: roots, CurrentTime: now})
+	assert.NoError(err)
+	svc := corev1.Service{}
+	require.NoError(cl.Get(ctx, key, &svc))
+	assert.NotContains(svc.Annotations, "service.alpha.openshift.io/serving-cert-secret-name")
+	status := r.CertificateStatus()
+	require.NotNil(status)
+	assert.Equal(constants.CertIssuerOperator, status.Issuer)
+	assert.Equal(cert.NotAfter, status.NotAfter.Time)
+	assert.Equal(certificates.RenewalTime(cert), status.RenewAfter.Time)
+	digest := podDigest()
+
+	// The certificates are kept until they have to be renewed
+	now = now.Add(24 * time.Hour)
+	r = newReconciler(false)
+	require.NoError(r.Reconcile(ctx, &spec))
+	renewed := corev1.Secret{}
+	require.NoError(cl.Get(ctx, certKey, &renewed))
+	assert.Equal(secret.Data, renewed.Data)
+	assert.Equal(digest, podDigest())
+
+	// A new Ingress host requires a new serving certificate, from the same CA, which is rolled out
+	spec.Console.Standalone.Ingress.Host = "flows.example.com"
+	r = newReconciler(false)
+	require.NoError(r.Reconcile(ctx, &spec))
+	require.NoError(cl.Get(ctx, certKey, &renewed))
+	assert.NotEqual(secret.Data[corev1.TLSCertKey], renewed.Data[corev1.TLSCertKey])
+	assert.Equal(ca.Data[corev1.TLSCertKey], renewed.Data["ca.crt"])
+	assert.NotEqual(digest, podDigest())
+
+	// The serving certificate is renewed ahead of its expiry
+	now = r.CertificateStatus().RenewAfter.Time
+	secret = renewed
+	r = newReconciler(false)
+	require.NoError(r.Reconcile(ctx, &spec))
+	renewed = corev1.Secret{}
+	require.NoError(cl.Get(ctx, certKey, &renewed))
+	assert.NotEqual(secret.Data[corev1.TLSCertKey], renewed.Data[corev1.TLSCertKey])
+	cert, err = certificates.ParseCertificate(renewed.Data[corev1.TLSCertKey])
+	require.NoError(err)
+	assert.True(cert.NotAfter.After(now.Add(300 * 24 * time.Hour)))
 
-	//newly created service should not need update
-	containerConfig := getPluginConfig()
-	newService := buildService(nil, &containerConfig, testNamespace)
-	assert.Equal(serviceNeedsUpdate(newService, &containerConfig, testNamespace), false)
+	// With the service CA, the generated certificates are removed for the service CA to issue its own
+	r = newReconciler(true)
+	require.NoError(r.Reconcile(ctx, &spec))
+	assert.True(errors.IsNotFound(cl.Get(ctx, caKey, &corev1.Secret{})))
+	assert.True(errors.IsNotFound(cl.Get(ctx, certKey, &corev1.Secret{})))
+	require.NoError(cl.Get(ctx, key, &svc))
+	assert.Equal(secretName, svc.Annotations["service.alpha.openshift.io/serving-cert-secret-name"])
+	assert.Equal(&flowsv1beta1.CertificateStatus{SecretName: secretName, Issuer: constants.CertIssuerServiceCA}, r.CertificateStatus())
+	ready, msg := r.CheckReadiness()
+	assert.False(ready)
+	assert.Contains(msg, "the serving certificate Secret console-serving-cert is not issued yet")
 }
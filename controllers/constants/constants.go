@@ -3,6 +3,28 @@ package constants
 
 const (
 	GoflowKubeName = "goflow-kube"
-	DeploymentKind = "Deployment"
-	DaemonSetKind  = "DaemonSet"
+	PluginName     = "network-observability-plugin"
+	// PluginServingCertSecret is the Secret of the console plugin serving certificate
+	PluginServingCertSecret = "console-serving-cert"
+	DeploymentKind          = "Deployment"
+	DaemonSetKind           = "DaemonSet"
+
+	AgentIPFIX = "IPFIX"
+	AgentEBPF  = "EBPF"
+
+	OVSConfigModeCNO       = "ClusterNetworkOperator"
+	OVSConfigModeNodeAgent = "NodeAgent"
+
+	LokiModeLokiStack = "LokiStack"
+	LokiModeDeploy    = "Deploy"
+
+	ExporterOpenTelemetry = "OpenTelemetry"
+
+	ManagementStateManaged   = "Managed"
+	ManagementStateUnmanaged = "Unmanaged"
+	ManagementStateRemoved   = "Removed"
+
+	CertIssuerServiceCA   = "ServiceCA"
+	CertIssuerCertManager = "CertManager"
+	CertIssuerOperator    = "Operator"
 )
@@ -0,0 +1,241 @@
+package controllerstest
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/netobserv/network-observability-operator/controllers/reconcilers"
+)
+
+// AsWritten returns a copy of the desired object as written by the reconcilers.ClientHelper, to test NeedsUpdate
+// without a client
+func AsWritten(desired client.Object) client.Object {
+	written := desired.DeepCopyObject().(client.Object)
+	annotations := written.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[reconcilers.DesiredDigestAnnotation] = reconcilers.DesiredDigest(written)
+	written.SetAnnotations(annotations)
+	return written
+}
+
+// Mutations returns, for every field set in the desired object, a copy of the current object where this field
+// is modified, keyed by the field path. Every list and map, except the annotations, also gets a copy where an
+// element is added, keyed by the path followed by [+]. The object name and namespace, which identify it, are left
+// unchanged.
+func Mutations(current, desired client.Object) map[string]client.Object {
+	var paths [][]step
+	collectLeaves(reflect.ValueOf(desired).Elem(), nil, &paths)
+	mutations := map[string]client.Object{}
+	for _, path := range paths {
+		mutated := current.DeepCopyObject().(client.Object)
+		if mutate(reflect.ValueOf(mutated).Elem(), path) {
+			mutations[pathString(path)] = mutated
+		}
+	}
+	return mutations
+}
+
+// ModifyAll returns a copy of the object where every field set is modified, as in Mutations
+func ModifyAll(obj client.Object) client.Object {
+	return mutateAll(obj, false)
+}
+
+// AddAll returns a copy of the object where an element is added to every list and map, as in Mutations
+func AddAll(obj client.Object) client.Object {
+	return mutateAll(obj, true)
+}
+
+func mutateAll(obj client.Object, additions bool) client.Object {
+	var paths [][]step
+	collectLeaves(reflect.ValueOf(obj).Elem(), nil, &paths)
+	mutated := obj.DeepCopyObject().(client.Object)
+	for _, path := range paths {
+		if path[len(path)-1].add == additions {
+			mutate(reflect.ValueOf(mutated).Elem(), path)
+		}
+	}
+	return mutated
+}
+
+// UndetectedDrifts returns the paths of the fields set in the desired object that reconcilers.NeedsUpdate doesn't detect as
+// changed when they are modified in the written object. It is empty when the drift detection is complete.
+func UndetectedDrifts(desired client.Object) []string {
+	var undetected []string
+	for path, mutated := range Mutations(AsWritten(desired), desired) {
+		if !reconcilers.NeedsUpdate(mutated, desired) {
+			undetected = append(undetected, path)
+		}
+	}
+	sort.Strings(undetected)
+	return undetected
+}
+
+// step is an element of a field path: a struct field name, a slice index, a map key, or an added element
+type step struct {
+	field string
+	index int
+	key   reflect.Value
+	add   bool
+}
+
+var quantityType = reflect.TypeOf(resource.Quantity{})
+var timeType = reflect.TypeOf(metav1.Time{})
+
+func isLeaf(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return v.Type() == quantityType
+}
+
+func collectLeaves(v reflect.Value, path []step, paths *[][]step) {
+	if isLeaf(v) {
+		if !v.IsZero() {
+			*paths = append(*paths, append([]step{}, path...))
+		}
+		return
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			collectLeaves(v.Elem(), path, paths)
+		}
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			switch {
+			case f.PkgPath != "":
+				// unexported
+			case f.Anonymous && f.Type == reflect.TypeOf(metav1.TypeMeta{}):
+			case v.Type() == reflect.TypeOf(metav1.ObjectMeta{}) && f.Name != "Labels" && f.Name != "Annotations":
+			default:
+				collectLeaves(v.Field(i), append(path, step{field: f.Name}), paths)
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			collectLeaves(v.Index(i), append(path, step{index: i}), paths)
+		}
+		collectAddition(v, path, paths)
+	case reflect.Map:
+		collectAddition(v, path, paths)
+		iter := v.MapRange()
+		for iter.Next() {
+			if isLeaf(iter.Value()) && !iter.Value().IsZero() {
+				*paths = append(*paths, append(append([]step{}, path...), step{key: iter.Key()}))
+			}
+		}
+	}
+}
+
+// collectAddition adds the path of an element added to a list or a map, unless it is empty or holds annotations
+func collectAddition(v reflect.Value, path []step, paths *[][]step) {
+	if v.Len() > 0 && (len(path) == 0 || path[len(path)-1].field != "Annotations") {
+		*paths = append(*paths, append(append([]step{}, path...), step{add: true}))
+	}
+}
+
+func mutate(v reflect.Value, path []step) bool {
+	for i, s := range path {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return false
+			}
+			v = v.Elem()
+		}
+		switch {
+		case s.add:
+			return addElement(v)
+		case s.key.IsValid():
+			// Map values aren't addressable: the mutated value is set back in the map
+			if v.Kind() != reflect.Map || i != len(path)-1 {
+				return false
+			}
+			value := reflect.New(v.Type().Elem()).Elem()
+			value.Set(v.MapIndex(s.key))
+			mutateLeaf(value)
+			v.SetMapIndex(s.key, value)
+			return true
+		case s.field != "":
+			v = v.FieldByName(s.field)
+		default:
+			if v.Len() <= s.index {
+				return false
+			}
+			v = v.Index(s.index)
+		}
+	}
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	mutateLeaf(v)
+	return true
+}
+
+// addElement adds a copy of an existing element to a list or a map
+func addElement(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.Len() == 0 {
+			return false
+		}
+		v.Set(reflect.Append(v, v.Index(v.Len()-1)))
+		return true
+	case reflect.Map:
+		if v.Len() == 0 || v.Type().Key().Kind() != reflect.String {
+			return false
+		}
+		key := reflect.New(v.Type().Key()).Elem()
+		key.SetString("mutated")
+		iter := v.MapRange()
+		iter.Next()
+		v.SetMapIndex(key, iter.Value())
+		return true
+	}
+	return false
+}
+
+func mutateLeaf(v reflect.Value) {
+	if v.Type() == quantityType {
+		q := v.Interface().(resource.Quantity)
+		q.Add(resource.MustParse("1"))
+		v.Set(reflect.ValueOf(q))
+		return
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(v.String() + "-mutated")
+	case reflect.Bool:
+		v.SetBool(!v.Bool())
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		v.SetInt(v.Int() + 1)
+	}
+}
+
+func pathString(path []step) string {
+	str := ""
+	for _, s := range path {
+		switch {
+		case s.add:
+			str += "[+]"
+		case s.key.IsValid():
+			str += fmt.Sprintf("[%v]", s.key.Interface())
+		case s.field != "":
+			str += "." + s.field
+		default:
+			str += fmt.Sprintf("[%d]", s.index)
+		}
+	}
+	return str
+}
@@ -0,0 +1,123 @@
+package controllerstest
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/netobserv/network-observability-operator/controllers/reconcilers"
+)
+
+// NewFakeApplyClient wraps a fake client, which doesn't support server-side apply, to emulate it: the applied
+// object is created when missing, and otherwise merged into the current one. The fields of an object modified by
+// another writer since the operator last wrote it are considered as owned by another field manager: changing them
+// is a conflict, unless the ownership is forced.
+func NewFakeApplyClient(cl client.Client) client.Client {
+	return &fakeApplyClient{Client: cl, written: map[string]string{}}
+}
+
+type fakeApplyClient struct {
+	client.Client
+	// written holds the resource version of the objects last written by the operator
+	written map[string]string
+}
+
+func (c *fakeApplyClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if patch.Type() != types.ApplyPatchType {
+		return c.Client.Patch(ctx, obj, patch, opts...)
+	}
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+	current := obj.DeepCopyObject().(client.Object)
+	if err := c.Get(ctx, client.ObjectKeyFromObject(obj), current); err != nil {
+		if errors.IsNotFound(err) {
+			return c.track(obj, c.Create(ctx, obj))
+		}
+		return err
+	}
+	options := client.PatchOptions{}
+	options.ApplyOptions(opts)
+	if (options.Force == nil || !*options.Force) && c.written[c.key(current)] != current.GetResourceVersion() {
+		changed, err := changes(current, data)
+		if err != nil {
+			return err
+		}
+		if changed {
+			return errors.NewConflict(schema.GroupResource{Resource: reflect.TypeOf(obj).Elem().Name()},
+				obj.GetName(), errors.NewBadRequest("fields managed by another field manager"))
+		}
+	}
+	return c.track(obj, c.Client.Patch(ctx, obj, client.RawPatch(types.MergePatchType, data)))
+}
+
+func (c *fakeApplyClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	options := client.UpdateOptions{}
+	options.ApplyOptions(opts)
+	err := c.Client.Update(ctx, obj, opts...)
+	if options.FieldManager != reconcilers.FieldManager {
+		return err
+	}
+	return c.track(obj, err)
+}
+
+// track records the resource version of an object written by the operator
+func (c *fakeApplyClient) track(obj client.Object, err error) error {
+	if err == nil {
+		c.written[c.key(obj)] = obj.GetResourceVersion()
+	}
+	return err
+}
+
+func (c *fakeApplyClient) key(obj client.Object) string {
+	return reflect.TypeOf(obj).String() + "/" + client.ObjectKeyFromObject(obj).String()
+}
+
+// changes tells whether merging the applied data into the current object changes it
+func changes(current client.Object, data []byte) (bool, error) {
+	currentJSON, err := json.Marshal(current)
+	if err != nil {
+		return false, err
+	}
+	var before, merged, applied map[string]interface{}
+	if err := json.Unmarshal(currentJSON, &before); err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(currentJSON, &merged); err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, &applied); err != nil {
+		return false, err
+	}
+	mergeInto(merged, applied)
+	// The metadata managed by the server isn't applied
+	for _, m := range []map[string]interface{}{before, merged} {
+		if metadata, ok := m["metadata"].(map[string]interface{}); ok {
+			delete(metadata, "resourceVersion")
+			delete(metadata, "managedFields")
+		}
+	}
+	return !reflect.DeepEqual(before, merged), nil
+}
+
+// mergeInto merges a JSON merge patch into a JSON object
+func mergeInto(target, patch map[string]interface{}) {
+	for k, v := range patch {
+		patchMap, isMap := v.(map[string]interface{})
+		targetMap, targetIsMap := target[k].(map[string]interface{})
+		switch {
+		case v == nil:
+			delete(target, k)
+		case isMap && targetIsMap:
+			mergeInto(targetMap, patchMap)
+		default:
+			target[k] = v
+		}
+	}
+}
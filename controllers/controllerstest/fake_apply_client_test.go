@@ -0,0 +1,62 @@
+package controllerstest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/netobserv/network-observability-operator/controllers/reconcilers"
+)
+
+func getConfigMap() *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "netobserv",
+		},
+		Data: map[string]string{"config.yaml": "port: 2055"},
+	}
+}
+
+func TestFakeApplyClient(t *testing.T) {
+	ctx := context.Background()
+	cl := NewFakeApplyClient(fake.NewClientBuilder().WithScheme(scheme.Scheme).Build())
+	helper := reconcilers.ClientHelper{Client: cl, SetControllerReference: func(client.Object) error { return nil }}
+
+	require.NoError(t, helper.CreateOwned(ctx, getConfigMap()))
+	current := corev1.ConfigMap{}
+	require.NoError(t, cl.Get(ctx, types.NamespacedName{Name: "test", Namespace: "netobserv"}, &current))
+	assert.False(t, reconcilers.NeedsUpdate(&current, getConfigMap()))
+
+	//annotations set by other controllers are kept, unlike added labels
+	current.Annotations["injected"] = "true"
+	current.Labels = map[string]string{"injected": "true"}
+	require.NoError(t, cl.Update(ctx, &current))
+	updated := getConfigMap()
+	updated.Data["config.yaml"] = "port: 2056"
+	//the object modified by another writer is owned by another field manager
+	err := helper.UpdateOwned(ctx, updated)
+	require.Error(t, err)
+	assert.True(t, errors.IsConflict(err))
+	helper.ForceOwnership = true
+	require.NoError(t, helper.UpdateOwned(ctx, getConfigMap()))
+	//until the operator takes it over
+	helper.ForceOwnership = false
+	updated = getConfigMap()
+	updated.Data["config.yaml"] = "port: 2056"
+	require.NoError(t, helper.UpdateOwned(ctx, updated))
+	current = corev1.ConfigMap{}
+	require.NoError(t, cl.Get(ctx, types.NamespacedName{Name: "test", Namespace: "netobserv"}, &current))
+	assert.Equal(t, "port: 2056", current.Data["config.yaml"])
+	assert.Equal(t, "true", current.Annotations["injected"])
+	assert.Empty(t, current.Labels)
+}
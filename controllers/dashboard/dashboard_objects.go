@@ -0,0 +1,212 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+)
+
+const (
+	// name of the ConfigMap or GrafanaDashboard holding the dashboard
+	name = "netobserv-dashboard"
+	// dashboardFile is the ConfigMap key holding the dashboard, which the Grafana sidecar uses as file name
+	dashboardFile = "netobserv-flows.json"
+	dashboardUID  = "netobserv-flows"
+	title         = "Network Observability"
+)
+
+var grafanaDashboardGVK = schema.GroupVersionKind{Group: "integreatly.org", Version: "v1alpha1", Kind: "GrafanaDashboard"}
+
+// Grafana dashboard model, limited to the fields set by the operator
+type dashboard struct {
+	UID           string     `json:"uid"`
+	Title         string     `json:"title"`
+	Tags          []string   `json:"tags"`
+	Editable      bool       `json:"editable"`
+	SchemaVersion int        `json:"schemaVersion"`
+	Time          timeRange  `json:"time"`
+	Refresh       string     `json:"refresh"`
+	Templating    templating `json:"templating"`
+	Panels        []panel    `json:"panels"`
+}
+
+type timeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type templating struct {
+	List []variable `json:"list"`
+}
+
+// variable is a textbox filtering the flows on a Loki label, with a regular expression
+type variable struct {
+	Name    string          `json:"name"`
+	Label   string          `json:"label"`
+	Type    string          `json:"type"`
+	Query   string          `json:"query"`
+	Current variableValue   `json:"current"`
+	Options []variableValue `json:"options"`
+}
+
+type variableValue struct {
+	Text  string `json:"text"`
+	Value string `json:"value"`
+}
+
+type panel struct {
+	ID          int          `json:"id"`
+	Type        string       `json:"type"`
+	Title       string       `json:"title"`
+	Datasource  string       `json:"datasource,omitempty"`
+	GridPos     gridPos      `json:"gridPos"`
+	FieldConfig *fieldConfig `json:"fieldConfig,omitempty"`
+	Targets     []target     `json:"targets,omitempty"`
+}
+
+type gridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type fieldConfig struct {
+	Defaults fieldDefaults `json:"defaults"`
+}
+
+type fieldDefaults struct {
+	Unit string `json:"unit"`
+}
+
+type target struct {
+	RefID string `json:"refId"`
+	Expr  string `json:"expr"`
+}
+
+func buildLabels(desired *flowsv1beta1.FlowCollectorDashboard) map[string]string {
+	labels := map[string]string{
+		"app": name,
+	}
+	for k, v := range desired.Labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// buildStreamSelector returns the LogQL selector of the flow streams: the static labels, and the Loki labels
+// filtered by the dashboard variables
+func buildStreamSelector(loki *flowsv1beta1.FlowCollectorLoki) string {
+	var matchers []string
+	for k, v := range loki.StaticLabels {
+		matchers = append(matchers, fmt.Sprintf(`%s="%s"`, k, v))
+	}
+	sort.Strings(matchers)
+	for _, label := range loki.Labels {
+		matchers = append(matchers, fmt.Sprintf(`%s=~"$%s"`, label, label))
+	}
+	return "{" + strings.Join(matchers, ",") + "}"
+}
+
+// buildDashboard returns the JSON model of the dashboard: a variable per Loki label, a graph of the bytes rate
+// per value of each label, and the flow logs
+func buildDashboard(desired *flowsv1beta1.FlowCollectorDashboard, loki *flowsv1beta1.FlowCollectorLoki) string {
+	selector := buildStreamSelector(loki)
+	d := dashboard{
+		UID:           dashboardUID,
+		Title:         title,
+		Tags:          []string{"netobserv"},
+		SchemaVersion: 30,
+		Time:          timeRange{From: "now-30m", To: "now"},
+		Refresh:       "1m",
+		Templating:    templating{List: []variable{}},
+		Panels: []panel{{
+			ID:      1,
+			Type:    "row",
+			Title:   "Volumetry",
+			GridPos: gridPos{H: 1, W: 24},
+		}},
+	}
+	for i, label := range loki.Labels {
+		all := variableValue{Text: ".*", Value: ".*"}
+		d.Templating.List = append(d.Templating.List, variable{
+			Name:    label,
+			Label:   label,
+			Type:    "textbox",
+			Query:   ".*",
+			Current: all,
+			Options: []variableValue{all},
+		})
+		// Two graphs per line
+		d.Panels = append(d.Panels, panel{
+			ID:          len(d.Panels) + 1,
+			Type:        "timeseries",
+			Title:       "Volumetry by " + label,
+			Datasource:  desired.Datasource,
+			GridPos:     gridPos{H: 8, W: 12, X: 12 * (i % 2), Y: 1 + 8*(i/2)},
+			FieldConfig: &fieldConfig{Defaults: fieldDefaults{Unit: "Bps"}},
+			Targets: []target{{
+				RefID: "A",
+				Expr: fmt.Sprintf(`sum by(%s) (rate(%s | json | __error__="" | unwrap Bytes [1m]))`,
+					label, selector),
+			}},
+		})
+	}
+	// The flow logs are below the graphs
+	y := 1 + 8*((len(loki.Labels)+1)/2)
+	d.Panels = append(d.Panels, panel{
+		ID:      len(d.Panels) + 1,
+		Type:    "row",
+		Title:   "Flows",
+		GridPos: gridPos{H: 1, W: 24, Y: y},
+	}, panel{
+		ID:         len(d.Panels) + 2,
+		Type:       "logs",
+		Title:      "Flows",
+		Datasource: desired.Datasource,
+		GridPos:    gridPos{H: 14, W: 24, Y: y + 1},
+		Targets:    []target{{RefID: "A", Expr: selector}},
+	})
+	b, err := json.Marshal(d)
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
+}
+
+// buildConfigMap returns the dashboard ConfigMap, for the Grafana sidecar
+func buildConfigMap(desired *flowsv1beta1.FlowCollectorDashboard, dashboardJSON, ns string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels:    buildLabels(desired),
+		},
+		Data: map[string]string{
+			dashboardFile: dashboardJSON,
+		},
+	}
+}
+
+// buildGrafanaDashboard returns the dashboard for the Grafana operator. It is built as unstructured, so that the
+// Grafana operator API isn't a dependency.
+func buildGrafanaDashboard(desired *flowsv1beta1.FlowCollectorDashboard, dashboardJSON, ns string) *unstructured.Unstructured {
+	gd := unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"json": dashboardJSON,
+		},
+	}}
+	gd.SetGroupVersionKind(grafanaDashboardGVK)
+	gd.SetName(name)
+	gd.SetNamespace(ns)
+	gd.SetLabels(buildLabels(desired))
+	return &gd
+}
@@ -0,0 +1,124 @@
+// Package dashboard manages the Grafana dashboard of the flows stored in Loki: a GrafanaDashboard when the Grafana
+// operator is installed, or a ConfigMap for the Grafana sidecar otherwise
+package dashboard
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/reconcilers"
+	"github.com/netobserv/network-observability-operator/pkg/helper"
+)
+
+//+kubebuilder:rbac:groups=integreatly.org,resources=grafanadashboards,verbs=get;create;update;patch;delete
+
+// Reconciler reconciles the dashboard with the desired configuration
+type Reconciler struct {
+	reconcilers.ClientHelper
+	nobjMngr *reconcilers.NamespacedObjectManager
+	owned    ownedObjects
+}
+
+type ownedObjects struct {
+	configMap *corev1.ConfigMap
+}
+
+func NewReconciler(cl reconcilers.ClientHelper, ns, prevNS string) Reconciler {
+	owned := ownedObjects{
+		configMap: &corev1.ConfigMap{},
+	}
+	nobjMngr := reconcilers.NewNamespacedObjectManager(cl, ns, prevNS)
+	nobjMngr.AddManagedObject(name, owned.configMap)
+
+	return Reconciler{ClientHelper: cl, nobjMngr: nobjMngr, owned: owned}
+}
+
+// PrepareNamespaceChange cleans up old namespace
+func (r *Reconciler) PrepareNamespaceChange(ctx context.Context) error {
+	r.nobjMngr.CleanupNamespace(ctx)
+	return r.deleteGrafanaDashboard(ctx, r.nobjMngr.PreviousNamespace)
+}
+
+// Cleanup deletes the dashboard
+func (r *Reconciler) Cleanup(ctx context.Context) error {
+	if err := r.nobjMngr.FetchAll(ctx); err != nil {
+		return err
+	}
+	r.nobjMngr.DeleteAll(ctx)
+	return r.deleteGrafanaDashboard(ctx, r.nobjMngr.Namespace)
+}
+
+// Reconcile generates the dashboard from the Loki labels, when it is enabled and the flows are stored in Loki.
+// Otherwise, it is removed.
+func (r *Reconciler) Reconcile(ctx context.Context, desired *flowsv1beta1.FlowCollectorDashboard,
+	loki *flowsv1beta1.FlowCollectorLoki) error {
+	if err := r.nobjMngr.FetchAll(ctx); err != nil {
+		return err
+	}
+	if !desired.Enable || !helper.LokiEnabled(loki) {
+		r.nobjMngr.TryDelete(ctx, r.owned.configMap)
+		return r.deleteGrafanaDashboard(ctx, r.nobjMngr.Namespace)
+	}
+	ns := r.nobjMngr.Namespace
+	dashboardJSON := buildDashboard(desired, loki)
+	gd, err := r.getGrafanaDashboard(ctx, ns)
+	switch {
+	case meta.IsNoMatchError(err):
+		// The Grafana operator isn't installed: the dashboard is loaded by the Grafana sidecar
+		return r.reconcileConfigMap(ctx, buildConfigMap(desired, dashboardJSON, ns))
+	case errors.IsNotFound(err):
+		err = r.CreateOwned(ctx, buildGrafanaDashboard(desired, dashboardJSON, ns))
+	case err != nil:
+		return err
+	default:
+		newGD := buildGrafanaDashboard(desired, dashboardJSON, ns)
+		if !equality.Semantic.DeepEqual(gd.Object["spec"], newGD.Object["spec"]) ||
+			!equality.Semantic.DeepDerivative(newGD.GetLabels(), gd.GetLabels()) {
+			err = r.UpdateOwned(ctx, newGD)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	// The Grafana operator may have been installed since the ConfigMap was created
+	r.nobjMngr.TryDelete(ctx, r.owned.configMap)
+	return nil
+}
+
+func (r *Reconciler) reconcileConfigMap(ctx context.Context, desired *corev1.ConfigMap) error {
+	if !r.nobjMngr.Exists(r.owned.configMap) {
+		return r.CreateOwned(ctx, desired)
+	} else if reconcilers.NeedsUpdate(r.owned.configMap, desired) {
+		return r.UpdateOwned(ctx, desired)
+	}
+	return nil
+}
+
+// getGrafanaDashboard returns the GrafanaDashboard in the provided namespace. A NoMatch error is returned when
+// the Grafana operator API isn't available.
+func (r *Reconciler) getGrafanaDashboard(ctx context.Context, ns string) (*unstructured.Unstructured, error) {
+	gd := unstructured.Unstructured{}
+	gd.SetGroupVersionKind(grafanaDashboardGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: ns}, &gd); err != nil {
+		return nil, err
+	}
+	return &gd, nil
+}
+
+func (r *Reconciler) deleteGrafanaDashboard(ctx context.Context, ns string) error {
+	gd, err := r.getGrafanaDashboard(ctx, ns)
+	if err != nil {
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return nil
+		}
+		return err
+	}
+	return r.DeleteIfExists(ctx, gd)
+}
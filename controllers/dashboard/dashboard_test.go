@@ -0,0 +1,162 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/controllerstest"
+	"github.com/netobserv/network-observability-operator/controllers/reconcilers"
+)
+
+const testNamespace = "netobserv"
+
+func getConfig() (flowsv1beta1.FlowCollectorDashboard, flowsv1beta1.FlowCollectorLoki) {
+	enabled := true
+	return flowsv1beta1.FlowCollectorDashboard{
+		Enable:     true,
+		Datasource: "Loki",
+		Labels:     map[string]string{"grafana_dashboard": "1"},
+	}, flowsv1beta1.FlowCollectorLoki{
+		Enable:       &enabled,
+		Labels:       []string{"SrcNamespace", "DstNamespace"},
+		StaticLabels: map[string]string{"app": "netobserv-flowcollector"},
+	}
+}
+
+// noMatchClient emulates a cluster without the Grafana operator
+type noMatchClient struct {
+	client.Client
+}
+
+func (c *noMatchClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if obj.GetObjectKind().GroupVersionKind() == grafanaDashboardGVK {
+		return &meta.NoKindMatchError{GroupKind: grafanaDashboardGVK.GroupKind(), SearchedVersions: []string{"v1alpha1"}}
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func newTestReconciler(cl client.Client) Reconciler {
+	return NewReconciler(reconcilers.ClientHelper{
+		Client:                 controllerstest.NewFakeApplyClient(cl),
+		SetControllerReference: func(client.Object) error { return nil },
+		ForceOwnership:         true,
+	}, testNamespace, "")
+}
+
+func TestDashboard(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	desired, loki := getConfig()
+	var d dashboard
+	require.NoError(json.Unmarshal([]byte(buildDashboard(&desired, &loki)), &d))
+	assert.Equal(dashboardUID, d.UID)
+
+	// A variable and a graph per Loki label
+	require.Len(d.Templating.List, 2)
+	assert.Equal("SrcNamespace", d.Templating.List[0].Name)
+	assert.Equal("DstNamespace", d.Templating.List[1].Name)
+	require.Len(d.Panels, 5)
+	assert.Equal("Volumetry by SrcNamespace", d.Panels[1].Title)
+	assert.Equal("Loki", d.Panels[1].Datasource)
+	assert.Equal(`sum by(SrcNamespace) (rate({app="netobserv-flowcollector",SrcNamespace=~"$SrcNamespace",`+
+		`DstNamespace=~"$DstNamespace"} | json | __error__="" | unwrap Bytes [1m]))`, d.Panels[1].Targets[0].Expr)
+	assert.Equal(gridPos{H: 8, W: 12, X: 12, Y: 1}, d.Panels[2].GridPos)
+	assert.Equal("logs", d.Panels[4].Type)
+	assert.Equal(`{app="netobserv-flowcollector",SrcNamespace=~"$SrcNamespace",DstNamespace=~"$DstNamespace"}`,
+		d.Panels[4].Targets[0].Expr)
+	assert.Equal(gridPos{H: 14, W: 24, Y: 10}, d.Panels[4].GridPos)
+
+	// The datasource is configurable
+	desired.Datasource = "Flows"
+	require.NoError(json.Unmarshal([]byte(buildDashboard(&desired, &loki)), &d))
+	assert.Equal("Flows", d.Panels[1].Datasource)
+	assert.Equal("Flows", d.Panels[4].Datasource)
+}
+
+func TestConfigMapUpdateCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	desired, loki := getConfig()
+	cm := buildConfigMap(&desired, buildDashboard(&desired, &loki), testNamespace)
+	assert.Equal(map[string]string{"app": name, "grafana_dashboard": "1"}, cm.Labels)
+
+	// Unchanged configuration
+	current := controllerstest.AsWritten(cm)
+	assert.False(reconcilers.NeedsUpdate(current, buildConfigMap(&desired, buildDashboard(&desired, &loki), testNamespace)))
+	// Changed Loki labels
+	loki.Labels = []string{"SrcNamespace"}
+	assert.True(reconcilers.NeedsUpdate(current, buildConfigMap(&desired, buildDashboard(&desired, &loki), testNamespace)))
+	// Changed discovery labels
+	desired.Labels = map[string]string{"console.openshift.io/dashboard": "true"}
+	assert.True(reconcilers.NeedsUpdate(current, buildConfigMap(&desired, buildDashboard(&desired, &loki), testNamespace)))
+	// Modified fields
+	assert.Empty(controllerstest.UndetectedDrifts(cm))
+}
+
+func TestReconcile(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(clientgoscheme.AddToScheme(scheme))
+	cl := fake.NewClientBuilder().WithScheme(scheme).Build()
+	key := types.NamespacedName{Name: name, Namespace: testNamespace}
+	desired, loki := getConfig()
+
+	// Without the Grafana operator, the dashboard is a ConfigMap
+	r := newTestReconciler(&noMatchClient{Client: cl})
+	require.NoError(r.Reconcile(ctx, &desired, &loki))
+	cm := corev1.ConfigMap{}
+	require.NoError(cl.Get(ctx, key, &cm))
+	assert.Equal("1", cm.Labels["grafana_dashboard"])
+	assert.Contains(cm.Data[dashboardFile], "Volumetry by DstNamespace")
+
+	// The ConfigMap follows the Loki labels
+	loki.Labels = []string{"SrcNamespace"}
+	r = newTestReconciler(&noMatchClient{Client: cl})
+	require.NoError(r.Reconcile(ctx, &desired, &loki))
+	require.NoError(cl.Get(ctx, key, &cm))
+	assert.NotContains(cm.Data[dashboardFile], "Volumetry by DstNamespace")
+
+	// With the Grafana operator, the ConfigMap is replaced by a GrafanaDashboard
+	r = newTestReconciler(cl)
+	require.NoError(r.Reconcile(ctx, &desired, &loki))
+	assert.True(errors.IsNotFound(cl.Get(ctx, key, &corev1.ConfigMap{})))
+	gd := unstructured.Unstructured{}
+	gd.SetGroupVersionKind(grafanaDashboardGVK)
+	require.NoError(cl.Get(ctx, key, &gd))
+	dashboardJSON, _, err := unstructured.NestedString(gd.Object, "spec", "json")
+	require.NoError(err)
+	assert.Equal(buildDashboard(&desired, &loki), dashboardJSON)
+
+	// The GrafanaDashboard follows the datasource
+	desired.Datasource = "Flows"
+	r = newTestReconciler(cl)
+	require.NoError(r.Reconcile(ctx, &desired, &loki))
+	require.NoError(cl.Get(ctx, key, &gd))
+	dashboardJSON, _, err = unstructured.NestedString(gd.Object, "spec", "json")
+	require.NoError(err)
+	assert.Contains(dashboardJSON, `"datasource":"Flows"`)
+
+	// Disabling the dashboard removes it
+	desired.Enable = false
+	r = newTestReconciler(cl)
+	require.NoError(r.Reconcile(ctx, &desired, &loki))
+	assert.True(errors.IsNotFound(cl.Get(ctx, key, &gd)))
+}
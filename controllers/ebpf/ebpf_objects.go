@@ -0,0 +1,148 @@
+package ebpf
+
+import (
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/constants"
+	"github.com/netobserv/network-observability-operator/controllers/reconcilers"
+)
+
+const agentName = "netobserv-ebpf-agent"
+
+// PodConfigurationDigest is an annotation name to facilitate pod restart after any configuration change
+const PodConfigurationDigest = "flows.netobserv.io/ebpf-agent-config"
+
+func buildLabels() map[string]string {
+	return map[string]string{
+		"app": agentName,
+	}
+}
+
+func buildDaemonSet(desired *flowsv1beta1.FlowCollectorSpec, ns string) *appsv1.DaemonSet {
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      agentName,
+			Namespace: ns,
+			Labels:    buildLabels(),
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: buildLabels(),
+			},
+			Template: buildPodTemplate(desired, ns),
+		},
+	}
+}
+
+func buildPodTemplate(desired *flowsv1beta1.FlowCollectorSpec, ns string) corev1.PodTemplateSpec {
+	ebpf := &desired.Agent.EBPF
+	privileged := true
+	spec := corev1.PodSpec{
+		// Flows are collected from the node interfaces
+		HostNetwork: true,
+		DNSPolicy:   corev1.DNSClusterFirstWithHostNet,
+		// This allows deploying an instance in the master node, the same technique used in the
+		// companion ovnkube-node daemonset definition
+		Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+		Containers: []corev1.Container{{
+			Name:            agentName,
+			Image:           ebpf.Image,
+			ImagePullPolicy: corev1.PullPolicy(ebpf.ImagePullPolicy),
+			Resources:       *ebpf.Resources.DeepCopy(),
+			Env:             buildEnv(desired, ns),
+			SecurityContext: &corev1.SecurityContext{
+				// Required to load the eBPF programs and attach them to the node interfaces
+				Privileged: &privileged,
+			},
+		}},
+		ServiceAccountName: agentName,
+	}
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: buildLabels(),
+			Annotations: map[string]string{
+				PodConfigurationDigest: reconcilers.PodSpecDigest(&spec),
+			},
+		},
+		Spec: spec,
+	}
+}
+
+// buildEnv returns the agent configuration. Flows are exported as IPFIX to the collector running on the same node
+// when it is deployed as a DaemonSet, or to the collector Service when it is deployed as a Deployment.
+func buildEnv(desired *flowsv1beta1.FlowCollectorSpec, ns string) []corev1.EnvVar {
+	ebpf := &desired.Agent.EBPF
+	env := []corev1.EnvVar{
+		{Name: "EXPORT", Value: "ipfix+udp"},
+		{Name: "FLOWS_TARGET_PORT", Value: strconv.Itoa(int(desired.Processor.Port))},
+		{Name: "SAMPLING", Value: strconv.Itoa(int(ebpf.Sampling))},
+		{Name: "CACHE_ACTIVE_TIMEOUT", Value: ebpf.CacheActiveTimeout.Duration.String()},
+		{Name: "CACHE_MAX_FLOWS", Value: strconv.Itoa(int(ebpf.CacheMaxFlows))},
+		{Name: "LOG_LEVEL", Value: ebpf.LogLevel},
+	}
+	if len(ebpf.Interfaces) > 0 {
+		env = append(env, corev1.EnvVar{Name: "INTERFACES", Value: strings.Join(ebpf.Interfaces, ",")})
+	}
+	if len(ebpf.ExcludeInterfaces) > 0 {
+		env = append(env, corev1.EnvVar{Name: "EXCLUDE_INTERFACES", Value: strings.Join(ebpf.ExcludeInterfaces, ",")})
+	}
+	if desired.Processor.Kind == constants.DeploymentKind {
+		env = append(env, corev1.EnvVar{Name: "FLOWS_TARGET_HOST", Value: constants.GoflowKubeName + "." + ns + ".svc"})
+	} else {
+		env = append(env, corev1.EnvVar{Name: "FLOWS_TARGET_HOST", ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{FieldPath: "status.hostIP"},
+		}})
+	}
+	return env
+}
+
+func buildServiceAccount(ns string) *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      agentName,
+			Namespace: ns,
+			Labels:    buildLabels(),
+		},
+	}
+}
+
+func buildClusterRole() *rbacv1.ClusterRole {
+	return &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   agentName,
+			Labels: buildLabels(),
+		},
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups:     []string{"security.openshift.io"},
+			Verbs:         []string{"use"},
+			Resources:     []string{"securitycontextconstraints"},
+			ResourceNames: []string{"privileged"},
+		}},
+	}
+}
+
+func buildClusterRoleBinding(ns string) *rbacv1.ClusterRoleBinding {
+	return &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   agentName,
+			Labels: buildLabels(),
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "ClusterRole",
+			Name:     agentName,
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      "ServiceAccount",
+			Name:      agentName,
+			Namespace: ns,
+		}},
+	}
+}
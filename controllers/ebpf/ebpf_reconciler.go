@@ -0,0 +1,131 @@
+package ebpf
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/reconcilers"
+)
+
+// AgentReconciler reconciles the current eBPF agent state with the desired configuration
+type AgentReconciler struct {
+	reconcilers.ClientHelper
+	nobjMngr *reconcilers.NamespacedObjectManager
+	owned    ownedObjects
+}
+
+type ownedObjects struct {
+	daemonSet      *appsv1.DaemonSet
+	serviceAccount *corev1.ServiceAccount
+}
+
+func NewAgentReconciler(cl reconcilers.ClientHelper, ns, prevNS string) AgentReconciler {
+	owned := ownedObjects{
+		daemonSet:      &appsv1.DaemonSet{},
+		serviceAccount: &corev1.ServiceAccount{},
+	}
+	nobjMngr := reconcilers.NewNamespacedObjectManager(cl, ns, prevNS)
+	nobjMngr.AddManagedObject(agentName, owned.daemonSet)
+	nobjMngr.AddManagedObject(agentName, owned.serviceAccount)
+
+	return AgentReconciler{ClientHelper: cl, nobjMngr: nobjMngr, owned: owned}
+}
+
+// PrepareNamespaceChange cleans up old namespace
+func (r *AgentReconciler) PrepareNamespaceChange(ctx context.Context) {
+	// Switching namespace => delete everything in the previous namespace
+	r.nobjMngr.CleanupNamespace(ctx)
+}
+
+// Reconcile is the reconciler entry point to reconcile the current eBPF agent state with the desired configuration
+func (r *AgentReconciler) Reconcile(ctx context.Context, desired *flowsv1beta1.FlowCollectorSpec) error {
+	ns := r.nobjMngr.Namespace
+	// Retrieve current owned objects
+	err := r.nobjMngr.FetchAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := r.reconcilePermissions(ctx); err != nil {
+		return err
+	}
+
+	newDS := buildDaemonSet(desired, ns)
+	if !r.nobjMngr.Exists(r.owned.daemonSet) {
+		return r.CreateOwned(ctx, newDS)
+	} else if reconcilers.NeedsUpdate(r.owned.daemonSet, newDS) {
+		return r.UpdateOwned(ctx, newDS)
+	}
+	return nil
+}
+
+// FetchAll retrieves the current owned objects, so that CheckReadiness reports their state when they are not
+// reconciled
+func (r *AgentReconciler) FetchAll(ctx context.Context) error {
+	return r.nobjMngr.FetchAll(ctx)
+}
+
+// CheckReadiness tells whether the agent DaemonSet, as fetched during the last reconciliation,
+// has completed its rollout. A message describing the rollout state is also returned.
+func (r *AgentReconciler) CheckReadiness() (bool, string) {
+	if !r.nobjMngr.Exists(r.owned.daemonSet) {
+		return false, "DaemonSet " + agentName + " is being created"
+	}
+	return reconcilers.DaemonSetProgress(r.owned.daemonSet)
+}
+
+// Cleanup deletes the agent, when another type of agent is used
+func (r *AgentReconciler) Cleanup(ctx context.Context) error {
+	if err := r.nobjMngr.FetchAll(ctx); err != nil {
+		return err
+	}
+	r.nobjMngr.TryDelete(ctx, r.owned.daemonSet)
+	r.nobjMngr.TryDelete(ctx, r.owned.serviceAccount)
+	return r.CleanupClusterResources(ctx)
+}
+
+// CleanupClusterResources deletes the cluster-scoped resources, which are not reliably garbage collected
+// through their owner references
+func (r *AgentReconciler) CleanupClusterResources(ctx context.Context) error {
+	meta := metav1.ObjectMeta{Name: agentName}
+	if err := r.DeleteIfExists(ctx, &rbacv1.ClusterRoleBinding{ObjectMeta: meta}); err != nil {
+		return err
+	}
+	return r.DeleteIfExists(ctx, &rbacv1.ClusterRole{ObjectMeta: meta})
+}
+
+func (r *AgentReconciler) reconcilePermissions(ctx context.Context) error {
+	ns := r.nobjMngr.Namespace
+	if !r.nobjMngr.Exists(r.owned.serviceAccount) {
+		if err := r.CreateOwned(ctx, buildServiceAccount(ns)); err != nil {
+			return err
+		}
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: agentName}, &rbacv1.ClusterRole{}); err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		if err := r.CreateOwned(ctx, buildClusterRole()); err != nil {
+			return err
+		}
+	}
+	// Cluster role binding has to be updated when namespace changes (it is not namespace-scoped)
+	crb := rbacv1.ClusterRoleBinding{}
+	if err := r.Get(ctx, types.NamespacedName{Name: agentName}, &crb); err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		return r.CreateOwned(ctx, buildClusterRoleBinding(ns))
+	}
+	if len(crb.Subjects) != 1 || crb.Subjects[0].Namespace != ns {
+		return r.UpdateOwned(ctx, buildClusterRoleBinding(ns))
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package ebpf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/controllerstest"
+	"github.com/netobserv/network-observability-operator/controllers/reconcilers"
+)
+
+func getAgentSpec() flowsv1beta1.FlowCollectorSpec {
+	return flowsv1beta1.FlowCollectorSpec{
+		Agent: flowsv1beta1.FlowCollectorAgent{
+			Type: "EBPF",
+			EBPF: flowsv1beta1.FlowCollectorEBPF{
+				Image:              "quay.io/netobserv/netobserv-ebpf-agent:main",
+				ImagePullPolicy:    "IfNotPresent",
+				Sampling:           50,
+				CacheActiveTimeout: metav1.Duration{Duration: 5 * time.Second},
+				CacheMaxFlows:      1000,
+				ExcludeInterfaces:  []string{"lo"},
+				LogLevel:           "info",
+			},
+		},
+		Processor: flowsv1beta1.FlowCollectorProcessor{Kind: "DaemonSet", Port: 2055},
+	}
+}
+
+func envMap(container *corev1.Container) map[string]string {
+	env := map[string]string{}
+	for _, e := range container.Env {
+		env[e.Name] = e.Value
+		if e.ValueFrom != nil {
+			env[e.Name] = e.ValueFrom.FieldRef.FieldPath
+		}
+	}
+	return env
+}
+
+func TestDaemonSetWithNodeTarget(t *testing.T) {
+	assert := assert.New(t)
+
+	spec := getAgentSpec()
+	ds := buildDaemonSet(&spec, "netobserv")
+	require.Len(t, ds.Spec.Template.Spec.Containers, 1)
+	container := ds.Spec.Template.Spec.Containers[0]
+	assert.Equal("quay.io/netobserv/netobserv-ebpf-agent:main", container.Image)
+	assert.Equal(map[string]string{
+		"EXPORT":               "ipfix+udp",
+		"FLOWS_TARGET_HOST":    "status.hostIP",
+		"FLOWS_TARGET_PORT":    "2055",
+		"SAMPLING":             "50",
+		"CACHE_ACTIVE_TIMEOUT": "5s",
+		"CACHE_MAX_FLOWS":      "1000",
+		"LOG_LEVEL":            "info",
+		"EXCLUDE_INTERFACES":   "lo",
+	}, envMap(&container))
+	assert.True(ds.Spec.Template.Spec.HostNetwork)
+	assert.True(*container.SecurityContext.Privileged)
+}
+
+func TestDaemonSetWithServiceTarget(t *testing.T) {
+	spec := getAgentSpec()
+	spec.Processor.Kind = "Deployment"
+	spec.Agent.EBPF.Interfaces = []string{"eth0", "/^br-/"}
+	ds := buildDaemonSet(&spec, "netobserv")
+	env := envMap(&ds.Spec.Template.Spec.Containers[0])
+	assert.Equal(t, "goflow-kube.netobserv.svc", env["FLOWS_TARGET_HOST"])
+	assert.Equal(t, "eth0,/^br-/", env["INTERFACES"])
+}
+
+func TestDaemonSetNeedsUpdate(t *testing.T) {
+	assert := assert.New(t)
+
+	spec := getAgentSpec()
+	current := controllerstest.AsWritten(buildDaemonSet(&spec, "netobserv"))
+	assert.False(reconcilers.NeedsUpdate(current, buildDaemonSet(&spec, "netobserv")))
+
+	spec.Agent.EBPF.Sampling = 1
+	assert.True(reconcilers.NeedsUpdate(current, buildDaemonSet(&spec, "netobserv")))
+
+	spec = getAgentSpec()
+	assert.True(reconcilers.NeedsUpdate(current, buildDaemonSet(&spec, "other")))
+	assert.Empty(controllerstest.UndetectedDrifts(buildDaemonSet(&spec, "netobserv")))
+}
@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	osv1alpha1 "github.com/openshift/api/console/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/constants"
+	"github.com/netobserv/network-observability-operator/controllers/controllerstest"
+)
+
+const cnoNamespace = "openshift-network-operator"
+
+func newCleanupTestReconciler(t *testing.T, objs ...client.Object) (*FlowCollectorReconciler, client.Client) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, flowsv1beta1.AddToScheme(scheme))
+	require.NoError(t, osv1alpha1.AddToScheme(scheme))
+	fc := &flowsv1beta1.FlowCollector{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: flowsv1beta1.FlowCollectorSpec{
+			Namespace: "netobserv-test",
+			Agent: flowsv1beta1.FlowCollectorAgent{IPFIX: flowsv1beta1.FlowCollectorIPFIX{
+				ClusterNetworkOperator: flowsv1beta1.ClusterNetworkOperator{Namespace: cnoNamespace},
+			}},
+			Processor: flowsv1beta1.FlowCollectorProcessor{
+				Kind:  constants.DaemonSetKind,
+				Port:  2055,
+				Image: "quay.io/netobserv/goflow2-kube:main",
+			},
+			Console: flowsv1beta1.FlowCollectorConsole{Port: 9001},
+		},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(objs, fc)...).Build()
+	return &FlowCollectorReconciler{
+		Client:             controllerstest.NewFakeApplyClient(cl),
+		Scheme:             scheme,
+		consoleEnabled:     true,
+		serviceCAAvailable: true,
+		forceOwnership:     true,
+		checkLoki:          func(context.Context, *flowsv1beta1.FlowCollectorLoki, string) error { return nil },
+		recorder:           record.NewFakeRecorder(100),
+	}, cl
+}
+
+func assertNotFound(t *testing.T, cl client.Client, key types.NamespacedName, obj client.Object) {
+	err := cl.Get(context.Background(), key, obj)
+	assert.Truef(t, errors.IsNotFound(err), "%T %s: expected not found, got %v", obj, key, err)
+}
+
+// nextEvent returns the type of the next recorded event with the given reason, skipping the other events such as
+// the ones reporting the object changes
+func nextEvent(t *testing.T, recorder *record.FakeRecorder, reason string) string {
+	for {
+		select {
+		case event := <-recorder.Events:
+			fields := strings.SplitN(event, " ", 3)
+			if len(fields) > 1 && fields[1] == reason {
+				return fields[0]
+			}
+		default:
+			require.FailNow(t, "no event recorded with reason "+reason)
+			return ""
+		}
+	}
+}
+
+func TestFinalizerCleansUpResources(t *testing.T) {
+	require := require.New(t)
+	r, cl := newCleanupTestReconciler(t)
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster"}}
+
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+	fc := flowsv1beta1.FlowCollector{}
+	require.NoError(cl.Get(ctx, req.NamespacedName, &fc))
+	assert.Contains(t, fc.Finalizers, flowCollectorFinalizer)
+
+	ovsKey := types.NamespacedName{Name: ovsFlowsConfigMapName, Namespace: cnoNamespace}
+	gfKey := types.NamespacedName{Name: constants.GoflowKubeName}
+	pluginKey := types.NamespacedName{Name: "network-observability-plugin"}
+	nsKey := types.NamespacedName{Name: "netobserv-test"}
+	require.NoError(cl.Get(ctx, ovsKey, &corev1.ConfigMap{}))
+	require.NoError(cl.Get(ctx, gfKey, &rbacv1.ClusterRole{}))
+	require.NoError(cl.Get(ctx, gfKey, &rbacv1.ClusterRoleBinding{}))
+	require.NoError(cl.Get(ctx, pluginKey, &osv1alpha1.ConsolePlugin{}))
+	require.NoError(cl.Get(ctx, nsKey, &corev1.Namespace{}))
+
+	// Deletion is held by the finalizer until resources are cleaned up
+	require.NoError(cl.Delete(ctx, &fc))
+	require.NoError(cl.Get(ctx, req.NamespacedName, &fc))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+
+	assertNotFound(t, cl, ovsKey, &corev1.ConfigMap{})
+	assertNotFound(t, cl, gfKey, &rbacv1.ClusterRole{})
+	assertNotFound(t, cl, gfKey, &rbacv1.ClusterRoleBinding{})
+	assertNotFound(t, cl, pluginKey, &osv1alpha1.ConsolePlugin{})
+	assertNotFound(t, cl, nsKey, &corev1.Namespace{})
+	assertNotFound(t, cl, req.NamespacedName, &flowsv1beta1.FlowCollector{})
+}
+
+func TestFinalizerKeepsExistingNamespace(t *testing.T) {
+	require := require.New(t)
+	r, cl := newCleanupTestReconciler(t, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "netobserv-test"}})
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster"}}
+
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+	fc := flowsv1beta1.FlowCollector{}
+	require.NoError(cl.Get(ctx, req.NamespacedName, &fc))
+	require.NoError(cl.Delete(ctx, &fc))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+
+	// The namespace wasn't created by the operator: it must not be deleted
+	require.NoError(cl.Get(ctx, types.NamespacedName{Name: "netobserv-test"}, &corev1.Namespace{}))
+	assertNotFound(t, cl, req.NamespacedName, &flowsv1beta1.FlowCollector{})
+}
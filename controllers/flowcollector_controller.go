@@ -2,26 +2,45 @@ package controllers
 
 import (
 	"context"
-	"net"
+	"fmt"
 	"strings"
+	"time"
 
 	osv1alpha1 "github.com/openshift/api/console/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
 	ascv1 "k8s.io/api/autoscaling/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
-	flowsv1alpha1 "github.com/netobserv/network-observability-operator/api/v1alpha1"
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
 	"github.com/netobserv/network-observability-operator/controllers/consoleplugin"
+	"github.com/netobserv/network-observability-operator/controllers/constants"
+	"github.com/netobserv/network-observability-operator/controllers/dashboard"
+	"github.com/netobserv/network-observability-operator/controllers/ebpf"
 	"github.com/netobserv/network-observability-operator/controllers/goflowkube"
+	"github.com/netobserv/network-observability-operator/controllers/lokistack"
+	"github.com/netobserv/network-observability-operator/controllers/managedloki"
 	"github.com/netobserv/network-observability-operator/controllers/ovs"
 	"github.com/netobserv/network-observability-operator/controllers/reconcilers"
+	"github.com/netobserv/network-observability-operator/pkg/conditions"
+	"github.com/netobserv/network-observability-operator/pkg/helper"
+	"github.com/netobserv/network-observability-operator/pkg/metrics"
 )
 
 // Make sure it always matches config/default/kustomization.yaml:namespace
@@ -30,32 +49,48 @@ const operatorNamespace = "network-observability"
 
 const ovsFlowsConfigMapName = "ovs-flows-config"
 
+// flowCollectorFinalizer delays the FlowCollector deletion until the resources that can't be garbage collected
+// through owner references (cluster-scoped or in another namespace) are deleted
+const flowCollectorFinalizer = "flows.netobserv.io/finalizer"
+
+// lokiCheckInterval is the period after which the Loki reachability is checked again
+const lokiCheckInterval = time.Minute
+
 // FlowCollectorReconciler reconciles a FlowCollector object
 type FlowCollectorReconciler struct {
 	client.Client
 	Scheme         *runtime.Scheme
 	consoleEnabled bool
-	lookupIP       func(string) ([]net.IP, error)
+	// serviceCAAvailable tells whether the OpenShift service CA can issue the serving certificates
+	serviceCAAvailable bool
+	checkLoki          func(ctx context.Context, loki *flowsv1beta1.FlowCollectorLoki, ns string) error
+	lokiProbe          lokiProbe
+	secrets            *secretWatcher
+	recorder           record.EventRecorder
+	forceOwnership     bool
 }
 
-func NewFlowCollectorReconciler(client client.Client, scheme *runtime.Scheme) *FlowCollectorReconciler {
+func NewFlowCollectorReconciler(client client.Client, scheme *runtime.Scheme, forceOwnership bool) *FlowCollectorReconciler {
 	return &FlowCollectorReconciler{
 		Client:         client,
 		Scheme:         scheme,
 		consoleEnabled: false,
-		lookupIP:       net.LookupIP,
+		checkLoki:      newLokiChecker(client),
+		forceOwnership: forceOwnership,
 	}
 }
 
 //+kubebuilder:rbac:groups=apps,resources=deployments;daemonsets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=namespaces;services;serviceaccounts;configmaps,verbs=get;list;watch;create;update;patch;delete
-//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=get;create;delete
-//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterrolebindings,verbs=get;create;delete;update
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=get;list;watch;create;delete;patch
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterrolebindings,verbs=get;list;watch;create;delete;update;patch
 //+kubebuilder:rbac:groups=console.openshift.io,resources=consoleplugins,verbs=get;create;delete;update;patch;list
 //+kubebuilder:rbac:groups=flows.netobserv.io,resources=flowcollectors,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=flows.netobserv.io,resources=flowcollectors/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=flows.netobserv.io,resources=flowcollectors/finalizers,verbs=update
 //+kubebuilder:rbac:groups=security.openshift.io,resources=securitycontextconstraints,resourceNames=hostnetwork,verbs=use
+//+kubebuilder:rbac:groups=security.openshift.io,resources=securitycontextconstraints,resourceNames=privileged,verbs=use
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -68,7 +103,7 @@ func NewFlowCollectorReconciler(client client.Client, scheme *runtime.Scheme) *F
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.9.2/pkg/reconcile
 func (r *FlowCollectorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := log.FromContext(ctx)
-	desired := &flowsv1alpha1.FlowCollector{}
+	desired := &flowsv1beta1.FlowCollector{}
 	if err := r.Get(ctx, req.NamespacedName, desired); err != nil {
 		if errors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
@@ -83,81 +118,613 @@ func (r *FlowCollectorReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	}
 
 	if !desired.ObjectMeta.DeletionTimestamp.IsZero() {
-		log.Info("No need to reconcile status of a FlowCollector that is being deleted. Ignoring")
-		return ctrl.Result{}, nil
+		return ctrl.Result{}, r.finalize(ctx, desired)
+	}
+	if err := r.ensureFinalizer(ctx, desired); err != nil {
+		return ctrl.Result{}, err
 	}
 
 	ns := getNamespaceName(desired)
-	// If namespace does not exist, we create it
+	previousNamespace := desired.Status.Namespace
+	managed := managementState(desired.Spec.ManagementState, "") == constants.ManagementStateManaged
+	if !managed && previousNamespace != "" {
+		// The components are left where they are: a namespace change is handled once they are managed again
+		ns = previousNamespace
+	}
+	if managed {
+		if err := r.ensureNamespace(ctx, ns); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	if err := r.watchSecrets(ns); err != nil {
+		log.Error(err, "Failed to watch Secrets", "namespace", ns)
+		return ctrl.Result{}, err
+	}
+
+	clientHelper := r.newClientHelper(desired)
+
+	// Create reconcilers
+	gfReconciler := goflowkube.NewReconciler(clientHelper, ns, previousNamespace)
+	ovsConfigController := ovs.NewFlowsConfigController(clientHelper,
+		ns,
+		desired.Spec.Agent.IPFIX.ClusterNetworkOperator.Namespace,
+		ovsFlowsConfigMapName)
+	nodeAgentReconciler := ovs.NewNodeAgentReconciler(clientHelper, ns, previousNamespace)
+	ebpfReconciler := ebpf.NewAgentReconciler(clientHelper, ns, previousNamespace)
+	lsReconciler := lokistack.NewReconciler(clientHelper, ns, previousNamespace)
+	lokiReconciler := managedloki.NewReconciler(clientHelper, ns, previousNamespace)
+	dbReconciler := dashboard.NewReconciler(clientHelper, ns, previousNamespace)
+	cpReconciler := consoleplugin.NewReconciler(clientHelper, ns, previousNamespace, r.serviceCAAvailable)
+
+	if managed {
+		// Check namespace changed
+		if ns != previousNamespace {
+			if err := r.handleNamespaceChanged(ctx, previousNamespace, ns, desired, &gfReconciler, &cpReconciler, &nodeAgentReconciler, &ebpfReconciler, &lsReconciler, &lokiReconciler, &dbReconciler); err != nil {
+				log.Error(err, "Failed to handle namespace change")
+				return ctrl.Result{}, err
+			}
+		}
+
+		// Loki connection: in LokiStack mode, it is derived from the LokiStack, which requires some resources; in
+		// Deploy mode, from the Loki deployed by the operator. These resources are kept when the components
+		// aren't managed, so that the stored flows aren't lost.
+		if err := r.reconcileLoki(ctx, &lsReconciler, &lokiReconciler, desired); err != nil {
+			log.Error(err, "Failed to reconcile Loki resources")
+			setCondition(desired, conditions.ReconcileFailed(conditions.TypeLokiReachable, err))
+			return ctrl.Result{}, r.updateStatus(ctx, desired, err)
+		}
+	}
+	// The components are configured from a copy of the spec, so that the resolved settings aren't persisted
+	spec := desired.Spec.DeepCopy()
+	spec.Storage.Loki = *resolveLoki(&desired.Spec.Storage.Loki, ns)
+
+	// Goflow
+	if err := r.reconcileCollector(ctx, &gfReconciler, desired, spec); err != nil {
+		return ctrl.Result{}, r.updateStatus(ctx, desired, err)
+	}
+
+	// Flows reporter: OVS configuration (through the config map for CNO, or the node agent), or eBPF agent
+	// In case of failure, other components are still reconciled before requeuing
+	agentErr := r.reconcileAgent(ctx, ovsConfigController, &nodeAgentReconciler, &ebpfReconciler, desired)
+	metrics.ObserveReconcile(metrics.ComponentAgent, agentErr)
+
+	// Console plugin
+	if err := r.reconcileConsolePlugin(ctx, &cpReconciler, desired, spec); err != nil {
+		return ctrl.Result{}, r.updateStatus(ctx, desired, err)
+	}
+
+	// Grafana dashboard
+	if err := r.reconcileDashboard(ctx, &dbReconciler, desired, spec); err != nil {
+		return ctrl.Result{}, r.updateStatus(ctx, desired, err)
+	}
+
+	// Loki
+	result := r.checkLokiReachable(ctx, desired, &spec.Storage.Loki, ns)
+	// Only the certificates reported during this reconciliation are considered, as the status kept from previous
+	// ones may be stale (e.g. when the console plugin is unmanaged)
+	result = requeueForRenewal(result, time.Now(), cpReconciler.CertificateStatus())
+
+	if agentErr != nil {
+		// Returning the error makes the request requeued with exponential backoff
+		return ctrl.Result{}, r.updateStatus(ctx, desired, agentErr)
+	}
+	return result, r.updateStatus(ctx, desired, nil)
+}
+
+// ensureFinalizer adds the finalizer that cleans up the resources on deletion, if missing
+func (r *FlowCollectorReconciler) ensureFinalizer(ctx context.Context, desired *flowsv1beta1.FlowCollector) error {
+	if controllerutil.ContainsFinalizer(desired, flowCollectorFinalizer) {
+		return nil
+	}
+	controllerutil.AddFinalizer(desired, flowCollectorFinalizer)
+	if err := r.Update(ctx, desired); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to add finalizer")
+		return err
+	}
+	return nil
+}
+
+// watchSecrets watches the Secrets of the namespace of the components, where the referenced certificates and
+// credentials are read. There is no watch when the reconciler isn't run by a manager.
+func (r *FlowCollectorReconciler) watchSecrets(ns string) error {
+	if r.secrets == nil {
+		return nil
+	}
+	return r.secrets.watch(ns)
+}
+
+// ensureNamespace creates the namespace of the components if it doesn't exist
+func (r *FlowCollectorReconciler) ensureNamespace(ctx context.Context, ns string) error {
 	nsExist, err := r.namespaceExist(ctx, ns)
+	if err != nil || nsExist {
+		return err
+	}
+	if err := r.Create(ctx, buildNamespace(ns)); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to create Namespace")
+		return err
+	}
+	return nil
+}
+
+// managementState returns the management state of a component, which is overridden by the global one unless
+// the latter is Managed. Unset states are considered Managed.
+func managementState(global, component string) string {
+	if global != "" && global != constants.ManagementStateManaged {
+		return global
+	}
+	if component == "" {
+		return constants.ManagementStateManaged
+	}
+	return component
+}
+
+// reconcileCollector reconciles goflow-kube according to its management state, reporting its state in the
+// CollectorReady condition
+func (r *FlowCollectorReconciler) reconcileCollector(ctx context.Context, gfReconciler *goflowkube.GFKReconciler,
+	desired *flowsv1beta1.FlowCollector, spec *flowsv1beta1.FlowCollectorSpec) error {
+	var err error
+	state := managementState(desired.Spec.ManagementState, desired.Spec.Processor.ManagementState)
+	switch state {
+	case constants.ManagementStateRemoved:
+		meta.RemoveStatusCondition(&desired.Status.Conditions, conditions.TypeCollectorReady)
+		err = gfReconciler.Cleanup(ctx)
+	case constants.ManagementStateUnmanaged:
+		err = gfReconciler.FetchAll(ctx)
+	default:
+		err = gfReconciler.Reconcile(ctx, &spec.Processor, &spec.Kafka, &spec.Storage.Loki, spec.Exporters)
+	}
+	metrics.ObserveReconcile(metrics.ComponentCollector, err)
 	if err != nil {
-		return ctrl.Result{}, err
+		log.FromContext(ctx).Error(err, "Failed to reconcile goflow-kube", "managementState", state)
+		setCondition(desired, conditions.ReconcileFailed(conditions.TypeCollectorReady, err))
+		return err
 	}
-	if !nsExist {
-		err = r.Create(ctx, buildNamespace(ns))
-		if err != nil {
-			log.Error(err, "Failed to create Namespace")
-			return ctrl.Result{}, err
+	if state != constants.ManagementStateRemoved {
+		ready, msg := gfReconciler.CheckReadiness(&desired.Spec.Processor, &desired.Spec.Kafka)
+		setCondition(desired, componentCondition(conditions.TypeCollectorReady, state, ready, msg))
+	}
+	return nil
+}
+
+// reconcileConsolePlugin reconciles the console plugin according to its management state, reporting its state
+// in the ConsolePluginReady condition
+func (r *FlowCollectorReconciler) reconcileConsolePlugin(ctx context.Context, cpReconciler *consoleplugin.CPReconciler,
+	desired *flowsv1beta1.FlowCollector, spec *flowsv1beta1.FlowCollectorSpec) error {
+	var err error
+	state := managementState(desired.Spec.ManagementState, desired.Spec.Console.ManagementState)
+	if !r.pluginDeployed(&desired.Spec) {
+		// Without the OpenShift console, the plugin is only deployed in standalone mode
+		state = constants.ManagementStateRemoved
+	}
+	switch state {
+	case constants.ManagementStateRemoved:
+		meta.RemoveStatusCondition(&desired.Status.Conditions, conditions.TypeConsolePluginReady)
+		desired.Status.Certificates = nil
+		err = cpReconciler.Cleanup(ctx)
+	case constants.ManagementStateUnmanaged:
+		err = cpReconciler.FetchAll(ctx)
+	default:
+		err = cpReconciler.Reconcile(ctx, spec)
+	}
+	metrics.ObserveReconcile(metrics.ComponentConsolePlugin, err)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to reconcile console plugin", "managementState", state)
+		setCondition(desired, conditions.ReconcileFailed(conditions.TypeConsolePluginReady, err))
+		return err
+	}
+	if state != constants.ManagementStateRemoved {
+		ready, msg := cpReconciler.CheckReadiness()
+		setCondition(desired, componentCondition(conditions.TypeConsolePluginReady, state, ready, msg))
+	}
+	if cert := cpReconciler.CertificateStatus(); cert != nil {
+		desired.Status.Certificates = []flowsv1beta1.CertificateStatus{*cert}
+	}
+	return nil
+}
+
+// minRenewalRequeue is the minimum delay before reconciling again for a certificate renewal, so that a renewal time
+// already past doesn't override other requeues nor make the request requeued immediately
+const minRenewalRequeue = 5 * time.Second
+
+// requeueForRenewal makes the FlowCollector reconciled again when the first of the provided certificates must be
+// renewed, as nothing else would trigger it. Nil certificates are ignored.
+func requeueForRenewal(result ctrl.Result, now time.Time, certs ...*flowsv1beta1.CertificateStatus) ctrl.Result {
+	for _, cert := range certs {
+		if cert == nil || cert.RenewAfter == nil {
+			continue
+		}
+		// A bit after the renewal time, before which the certificate is kept
+		after := cert.RenewAfter.Sub(now) + time.Second
+		if after < minRenewalRequeue {
+			after = minRenewalRequeue
+		}
+		if result.RequeueAfter == 0 || after < result.RequeueAfter {
+			result.RequeueAfter = after
 		}
 	}
+	return result
+}
+
+// pluginDeployed tells whether the console plugin is deployed: it is registered in the OpenShift console when
+// available, or exposed through an Ingress in standalone mode
+func (r *FlowCollectorReconciler) pluginDeployed(spec *flowsv1beta1.FlowCollectorSpec) bool {
+	return r.consoleEnabled || spec.Console.Standalone.Enable
+}
 
-	clientHelper := reconcilers.ClientHelper{
+// reconcileDashboard reconciles the Grafana dashboard according to the global management state, as it has none
+// of its own
+func (r *FlowCollectorReconciler) reconcileDashboard(ctx context.Context, dbReconciler *dashboard.Reconciler,
+	desired *flowsv1beta1.FlowCollector, spec *flowsv1beta1.FlowCollectorSpec) error {
+	var err error
+	state := managementState(desired.Spec.ManagementState, "")
+	switch state {
+	case constants.ManagementStateRemoved:
+		err = dbReconciler.Cleanup(ctx)
+	case constants.ManagementStateUnmanaged:
+		return nil
+	default:
+		err = dbReconciler.Reconcile(ctx, &spec.Dashboard, &spec.Storage.Loki)
+	}
+	metrics.ObserveReconcile(metrics.ComponentDashboard, err)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to reconcile the Grafana dashboard", "managementState", state)
+	}
+	return err
+}
+
+func (r *FlowCollectorReconciler) newClientHelper(desired *flowsv1beta1.FlowCollector) reconcilers.ClientHelper {
+	return reconcilers.ClientHelper{
 		Client: r.Client,
 		SetControllerReference: func(obj client.Object) error {
 			return ctrl.SetControllerReference(desired, obj, r.Scheme)
 		},
+		ForceOwnership: r.forceOwnership,
+		Recorder:       r.recorder,
+		Owner:          desired,
 	}
-	previousNamespace := desired.Status.Namespace
+}
 
-	// Create reconcilers
-	gfReconciler := goflowkube.NewReconciler(clientHelper, ns, previousNamespace)
+// finalize cleans up the resources that are not garbage collected, then removes the finalizer to let the
+// FlowCollector deletion complete. Failures are notified through a Warning event on the FlowCollector.
+func (r *FlowCollectorReconciler) finalize(ctx context.Context, desired *flowsv1beta1.FlowCollector) error {
+	if !controllerutil.ContainsFinalizer(desired, flowCollectorFinalizer) {
+		return nil
+	}
+	log := log.FromContext(ctx)
+	log.Info("FlowCollector is being deleted: cleaning up resources")
+	if err := r.cleanup(ctx, desired); err != nil {
+		log.Error(err, "Failed to clean up FlowCollector resources")
+		r.recorder.Eventf(desired, corev1.EventTypeWarning, "CleanupFailed", "Failed to clean up resources: %v", err)
+		return err
+	}
+	controllerutil.RemoveFinalizer(desired, flowCollectorFinalizer)
+	if err := r.Update(ctx, desired); err != nil {
+		log.Error(err, "Failed to remove finalizer")
+		return err
+	}
+	return nil
+}
+
+func (r *FlowCollectorReconciler) cleanup(ctx context.Context, desired *flowsv1beta1.FlowCollector) error {
+	clientHelper := r.newClientHelper(desired)
+	// Resources are deployed in the namespace from the status, which can differ from the spec one if
+	// the namespace has been changed without being reconciled yet
+	ns := desired.Status.Namespace
+	if ns == "" {
+		ns = getNamespaceName(desired)
+	}
+
+	// OVS configuration first, so that OVS stops exporting flows to a collector that is going away
 	ovsConfigController := ovs.NewFlowsConfigController(clientHelper,
 		ns,
-		desired.Spec.CNO.Namespace,
-		ovsFlowsConfigMapName,
-		r.lookupIP)
-	var cpReconciler consoleplugin.CPReconciler
-	if r.consoleEnabled {
-		cpReconciler = consoleplugin.NewReconciler(clientHelper, ns, previousNamespace)
+		desired.Spec.Agent.IPFIX.ClusterNetworkOperator.Namespace,
+		ovsFlowsConfigMapName)
+	if err := ovsConfigController.Cleanup(ctx); err != nil {
+		return err
+	}
+	nodeAgentReconciler := ovs.NewNodeAgentReconciler(clientHelper, ns, "")
+	if err := nodeAgentReconciler.Cleanup(ctx); err != nil {
+		return err
+	}
+	ebpfReconciler := ebpf.NewAgentReconciler(clientHelper, ns, "")
+	if err := ebpfReconciler.CleanupClusterResources(ctx); err != nil {
+		return err
+	}
+	gfReconciler := goflowkube.NewReconciler(clientHelper, ns, "")
+	if err := gfReconciler.CleanupClusterResources(ctx); err != nil {
+		return err
+	}
+	lsReconciler := lokistack.NewReconciler(clientHelper, ns, "")
+	if err := lsReconciler.CleanupClusterResources(ctx); err != nil {
+		return err
+	}
+	cpReconciler := consoleplugin.NewReconciler(clientHelper, ns, "", r.serviceCAAvailable)
+	if err := cpReconciler.CleanupClusterResources(ctx); err != nil {
+		return err
 	}
 
-	// Check namespace changed
-	if ns != previousNamespace {
-		if err := r.handleNamespaceChanged(ctx, previousNamespace, ns, desired, &gfReconciler, &cpReconciler); err != nil {
-			log.Error(err, "Failed to handle namespace change")
-			return ctrl.Result{}, err
+	// The namespace is only deleted if it was created by the operator, as it might host other workloads
+	namespace := corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: ns}, &namespace); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if namespace.Labels[createdByOperatorLabel] != "true" {
+		return nil
+	}
+	return clientHelper.DeleteIfExists(ctx, &namespace)
+}
+
+// checkLokiReachable reports whether Loki is reachable in the LokiReachable condition, unless the flows aren't
+// stored in Loki. The check runs in the background: until its first result, the condition is left unchanged.
+// When Loki isn't reachable, the returned result requeues the request.
+func (r *FlowCollectorReconciler) checkLokiReachable(ctx context.Context, desired *flowsv1beta1.FlowCollector,
+	loki *flowsv1beta1.FlowCollectorLoki, ns string) ctrl.Result {
+	if !helper.LokiEnabled(loki) {
+		meta.RemoveStatusCondition(&desired.Status.Conditions, conditions.TypeLokiReachable)
+		return ctrl.Result{}
+	}
+	checked, err := r.lokiProbe.reachability(r.checkLoki, desired.Name, loki, ns)
+	if !checked {
+		return ctrl.Result{}
+	}
+	if err != nil {
+		log.FromContext(ctx).Info("Loki is not reachable from the operator", "URL", loki.URL, "error", err.Error())
+		setCondition(desired, conditions.New(conditions.TypeLokiReachable, false, conditions.ReasonUnreachable, err.Error()))
+		// Nothing would trigger a new reconciliation when Loki becomes reachable: check again later
+		return ctrl.Result{RequeueAfter: lokiCheckInterval}
+	}
+	setCondition(desired, conditions.New(conditions.TypeLokiReachable, true, conditions.ReasonReachable,
+		"Loki is reachable at "+loki.URL))
+	return ctrl.Result{}
+}
+
+// reconcileLoki reconciles the resources required by the Loki mode, and removes the ones of the other modes
+func (r *FlowCollectorReconciler) reconcileLoki(ctx context.Context, lsReconciler *lokistack.Reconciler,
+	lokiReconciler *managedloki.Reconciler, desired *flowsv1beta1.FlowCollector) error {
+	err := lsReconciler.Reconcile(ctx, &desired.Spec.Storage.Loki)
+	if err == nil {
+		err = lokiReconciler.Reconcile(ctx, &desired.Spec.Storage.Loki)
+	}
+	metrics.ObserveReconcile(metrics.ComponentLoki, err)
+	return err
+}
+
+// resolveLoki returns the Loki settings used by the components deployed in the provided namespace, which are
+// derived from the LokiStack or from the deployed Loki, depending on the mode
+func resolveLoki(loki *flowsv1beta1.FlowCollectorLoki, ns string) *flowsv1beta1.FlowCollectorLoki {
+	switch loki.Mode {
+	case constants.LokiModeLokiStack:
+		return lokistack.Resolve(loki, ns)
+	case constants.LokiModeDeploy:
+		return managedloki.Resolve(loki, ns)
+	}
+	return loki
+}
+
+// reconcileAgent reconciles the flows reporter according to its management state
+func (r *FlowCollectorReconciler) reconcileAgent(ctx context.Context, c *ovs.FlowsConfigController,
+	nodeAgent *ovs.NodeAgentReconciler, ebpfAgent *ebpf.AgentReconciler, desired *flowsv1beta1.FlowCollector) error {
+	switch managementState(desired.Spec.ManagementState, desired.Spec.Agent.ManagementState) {
+	case constants.ManagementStateRemoved:
+		return r.removeAgent(ctx, c, nodeAgent, ebpfAgent, desired)
+	case constants.ManagementStateUnmanaged:
+		return r.checkUnmanagedAgent(ctx, c, nodeAgent, ebpfAgent, desired)
+	}
+	if desired.Spec.Agent.Type == constants.AgentEBPF {
+		return r.reconcileEBPFAgent(ctx, c, nodeAgent, ebpfAgent, desired)
+	}
+	return r.reconcileIPFIXAgent(ctx, c, nodeAgent, ebpfAgent, desired)
+}
+
+// removeAgent removes the OVS IPFIX configuration and the agents of both types
+func (r *FlowCollectorReconciler) removeAgent(ctx context.Context, c *ovs.FlowsConfigController,
+	nodeAgent *ovs.NodeAgentReconciler, ebpfAgent *ebpf.AgentReconciler, desired *flowsv1beta1.FlowCollector) error {
+	meta.RemoveStatusCondition(&desired.Status.Conditions, conditions.TypeOVSConfigured)
+	meta.RemoveStatusCondition(&desired.Status.Conditions, conditions.TypeEBPFAgentReady)
+	desired.Status.OVSNodes = nil
+	err := c.Cleanup(ctx)
+	if err == nil {
+		err = nodeAgent.Cleanup(ctx)
+	}
+	if err == nil {
+		err = ebpfAgent.Cleanup(ctx)
+	}
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to remove flows reporter")
+		setCondition(desired, conditions.ReconcileFailed(agentConditionType(desired), err))
+	}
+	return err
+}
+
+// checkUnmanagedAgent reports the state of the flows reporter, which is not reconciled
+func (r *FlowCollectorReconciler) checkUnmanagedAgent(ctx context.Context, c *ovs.FlowsConfigController,
+	nodeAgent *ovs.NodeAgentReconciler, ebpfAgent *ebpf.AgentReconciler, desired *flowsv1beta1.FlowCollector) error {
+	var ready bool
+	var msg string
+	var err error
+	switch {
+	case desired.Spec.Agent.Type == constants.AgentEBPF:
+		meta.RemoveStatusCondition(&desired.Status.Conditions, conditions.TypeOVSConfigured)
+		desired.Status.OVSNodes = nil
+		if err = ebpfAgent.FetchAll(ctx); err == nil {
+			ready, msg = ebpfAgent.CheckReadiness()
+		}
+	case desired.Spec.Agent.IPFIX.OVSConfigMode == constants.OVSConfigModeNodeAgent:
+		meta.RemoveStatusCondition(&desired.Status.Conditions, conditions.TypeEBPFAgentReady)
+		var nodes []flowsv1beta1.OVSNodeStatus
+		if err = nodeAgent.FetchAll(ctx); err == nil {
+			nodes, err = nodeAgent.NodeStatuses(ctx)
+		}
+		desired.Status.OVSNodes = nodes
+		ready, msg = nodeAgent.CheckReadiness(nodes)
+	default:
+		meta.RemoveStatusCondition(&desired.Status.Conditions, conditions.TypeEBPFAgentReady)
+		desired.Status.OVSNodes = nil
+		target := "ConfigMap " + desired.Spec.Agent.IPFIX.ClusterNetworkOperator.Namespace + "/" + ovsFlowsConfigMapName
+		if ready, err = c.IsConfigured(ctx); ready {
+			msg = target + " exists"
+		} else {
+			msg = target + " is missing"
 		}
 	}
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to check flows reporter")
+		setCondition(desired, conditions.ReconcileFailed(agentConditionType(desired), err))
+		return err
+	}
+	setCondition(desired, componentCondition(agentConditionType(desired), constants.ManagementStateUnmanaged, ready, msg))
+	return nil
+}
 
-	// Goflow
-	if err := gfReconciler.Reconcile(ctx, &desired.Spec.GoflowKube, &desired.Spec.Loki); err != nil {
-		log.Error(err, "Failed to reconcile goflow-kube")
-		return ctrl.Result{}, err
+// agentConditionType returns the type of the condition that reports the state of the flows reporter
+func agentConditionType(desired *flowsv1beta1.FlowCollector) string {
+	if desired.Spec.Agent.Type == constants.AgentEBPF {
+		return conditions.TypeEBPFAgentReady
+	}
+	return conditions.TypeOVSConfigured
+}
+
+// reconcileIPFIXAgent removes the eBPF agent, if any, then reconciles the OVS IPFIX configuration
+func (r *FlowCollectorReconciler) reconcileIPFIXAgent(ctx context.Context, c *ovs.FlowsConfigController,
+	nodeAgent *ovs.NodeAgentReconciler, ebpfAgent *ebpf.AgentReconciler, desired *flowsv1beta1.FlowCollector) error {
+	meta.RemoveStatusCondition(&desired.Status.Conditions, conditions.TypeEBPFAgentReady)
+	if err := ebpfAgent.Cleanup(ctx); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to remove eBPF agent")
+		setCondition(desired, conditions.ReconcileFailed(conditions.TypeOVSConfigured, err))
+		return err
 	}
+	return r.reconcileOVSConfig(ctx, c, nodeAgent, desired)
+}
 
-	// OVS config map for CNO
-	if err := ovsConfigController.Reconcile(ctx, desired); err != nil {
-		log.Error(err, "Failed to reconcile ovs-flows-config ConfigMap")
+// reconcileEBPFAgent removes the OVS IPFIX configuration, if any, then reconciles the eBPF agent, reporting its
+// state in the EBPFAgentReady condition
+func (r *FlowCollectorReconciler) reconcileEBPFAgent(ctx context.Context, c *ovs.FlowsConfigController,
+	nodeAgent *ovs.NodeAgentReconciler, ebpfAgent *ebpf.AgentReconciler, desired *flowsv1beta1.FlowCollector) error {
+	meta.RemoveStatusCondition(&desired.Status.Conditions, conditions.TypeOVSConfigured)
+	desired.Status.OVSNodes = nil
+	err := c.Cleanup(ctx)
+	if err == nil {
+		err = nodeAgent.Cleanup(ctx)
+	}
+	if err == nil {
+		err = ebpfAgent.Reconcile(ctx, &desired.Spec)
 	}
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to reconcile eBPF agent")
+		setCondition(desired, conditions.ReconcileFailed(conditions.TypeEBPFAgentReady, err))
+		return err
+	}
+	ready, msg := ebpfAgent.CheckReadiness()
+	setCondition(desired, componentCondition(conditions.TypeEBPFAgentReady, constants.ManagementStateManaged, ready, msg))
+	return nil
+}
 
-	// Console plugin
-	if r.consoleEnabled {
-		err := cpReconciler.Reconcile(ctx, &desired.Spec)
+// reconcileOVSConfig reconciles the OVS IPFIX configuration, either through the ConfigMap consumed by CNO or
+// through the node agent according to the configuration mode, reporting its state in the OVSConfigured condition.
+// The resources of the other mode are removed. Failures are also notified through a Warning event on the
+// FlowCollector, and recovery through a Normal event.
+func (r *FlowCollectorReconciler) reconcileOVSConfig(ctx context.Context, c *ovs.FlowsConfigController,
+	agent *ovs.NodeAgentReconciler, desired *flowsv1beta1.FlowCollector) error {
+	previouslyFailed := meta.IsStatusConditionFalse(desired.Status.Conditions, conditions.TypeOVSConfigured)
+	var target string
+	var err error
+	if desired.Spec.Agent.IPFIX.OVSConfigMode == constants.OVSConfigModeNodeAgent {
+		target = "node agent"
+		if err = c.Cleanup(ctx); err == nil {
+			err = agent.Reconcile(ctx, desired)
+		}
+	} else {
+		target = "ConfigMap " + desired.Spec.Agent.IPFIX.ClusterNetworkOperator.Namespace + "/" + ovsFlowsConfigMapName
+		desired.Status.OVSNodes = nil
+		if err = agent.Cleanup(ctx); err == nil {
+			err = c.Reconcile(ctx, desired)
+		}
+	}
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to reconcile OVS configuration", "target", target)
+		setCondition(desired, conditions.ReconcileFailed(conditions.TypeOVSConfigured, err))
+		r.recorder.Eventf(desired, corev1.EventTypeWarning, "OVSConfigFailed",
+			"Failed to reconcile %s: %v", target, err)
+		return fmt.Errorf("reconciling %s: %w", target, err)
+	}
+
+	if desired.Spec.Agent.IPFIX.OVSConfigMode == constants.OVSConfigModeNodeAgent {
+		nodes, err := agent.NodeStatuses(ctx)
 		if err != nil {
-			log.Error(err, "Failed to reconcile console plugin")
-			return ctrl.Result{}, err
+			log.FromContext(ctx).Error(err, "Failed to get OVS node agent statuses")
+			setCondition(desired, conditions.ReconcileFailed(conditions.TypeOVSConfigured, err))
+			return fmt.Errorf("getting node agent statuses: %w", err)
 		}
+		desired.Status.OVSNodes = nodes
+		configured, msg := agent.CheckReadiness(nodes)
+		if !configured {
+			setCondition(desired, conditions.New(conditions.TypeOVSConfigured, false, conditions.ReasonDeploying, msg))
+			return nil
+		}
+		setCondition(desired, conditions.New(conditions.TypeOVSConfigured, true, conditions.ReasonConfigured, msg))
+		if previouslyFailed {
+			r.recorder.Event(desired, corev1.EventTypeNormal, "OVSConfigured", msg)
+		}
+		return nil
 	}
+	setCondition(desired, conditions.New(conditions.TypeOVSConfigured, true, conditions.ReasonConfigured,
+		target+" is up to date"))
+	if previouslyFailed {
+		r.recorder.Eventf(desired, corev1.EventTypeNormal, "OVSConfigured", "%s is up to date", target)
+	}
+	return nil
+}
 
-	return ctrl.Result{}, nil
+// setCondition adds or updates the provided condition in the FlowCollector status
+func setCondition(desired *flowsv1beta1.FlowCollector, cond metav1.Condition) {
+	cond.ObservedGeneration = desired.Generation
+	meta.SetStatusCondition(&desired.Status.Conditions, cond)
+}
+
+// componentCondition reports the readiness of a component. The Unmanaged reason tells that it is not reconciled.
+func componentCondition(condType, state string, ready bool, msg string) metav1.Condition {
+	if state == constants.ManagementStateUnmanaged {
+		return conditions.New(condType, ready, conditions.ReasonUnmanaged, msg)
+	}
+	if ready {
+		return conditions.New(condType, true, conditions.ReasonReady, msg)
+	}
+	return conditions.New(condType, false, conditions.ReasonDeploying, msg)
+}
+
+// updateStatus computes the aggregated Ready condition and updates the FlowCollector status if any
+// condition has changed. The reconciliation error, if any, is returned unless the update itself fails. Otherwise,
+// the reconciliation is recorded as successful in the operator metrics.
+func (r *FlowCollectorReconciler) updateStatus(ctx context.Context, desired *flowsv1beta1.FlowCollector, reconcileErr error) error {
+	setCondition(desired, conditions.Aggregate(desired.Status.Conditions))
+	current := flowsv1beta1.FlowCollector{}
+	if err := r.Get(ctx, types.NamespacedName{Name: desired.Name}, &current); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to get FlowCollector status")
+		return err
+	}
+	if !equality.Semantic.DeepEqual(current.Status, desired.Status) {
+		if err := r.Status().Update(ctx, desired); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to update FlowCollector status")
+			return err
+		}
+	}
+	if reconcileErr == nil {
+		metrics.ObserveSuccessfulReconcile(time.Now())
+	}
+	return reconcileErr
 }
 
 func (r *FlowCollectorReconciler) handleNamespaceChanged(
 	ctx context.Context,
 	oldNS, newNS string,
-	desired *flowsv1alpha1.FlowCollector,
+	desired *flowsv1beta1.FlowCollector,
 	gfReconciler *goflowkube.GFKReconciler,
 	cpReconciler *consoleplugin.CPReconciler,
+	nodeAgentReconciler *ovs.NodeAgentReconciler,
+	ebpfReconciler *ebpf.AgentReconciler,
+	lsReconciler *lokistack.Reconciler,
+	lokiReconciler *managedloki.Reconciler,
+	dbReconciler *dashboard.Reconciler,
 ) error {
 	log := log.FromContext(ctx)
 	if oldNS == "" {
@@ -167,7 +734,7 @@ func (r *FlowCollectorReconciler) handleNamespaceChanged(
 		if err != nil {
 			return err
 		}
-		if r.consoleEnabled {
+		if r.pluginDeployed(&desired.Spec) {
 			err := cpReconciler.InitStaticResources(ctx)
 			if err != nil {
 				return err
@@ -180,12 +747,17 @@ func (r *FlowCollectorReconciler) handleNamespaceChanged(
 		if err != nil {
 			return err
 		}
-		if r.consoleEnabled {
-			err := cpReconciler.PrepareNamespaceChange(ctx)
-			if err != nil {
-				return err
-			}
+		nodeAgentReconciler.PrepareNamespaceChange(ctx)
+		ebpfReconciler.PrepareNamespaceChange(ctx)
+		lsReconciler.PrepareNamespaceChange(ctx)
+		lokiReconciler.PrepareNamespaceChange(ctx)
+		if err := dbReconciler.PrepareNamespaceChange(ctx); err != nil {
+			return err
+		}
+		if err := cpReconciler.PrepareNamespaceChange(ctx); err != nil {
+			return err
 		}
+		metrics.ObserveNamespaceMigration()
 	}
 
 	// Update namespace in status
@@ -194,11 +766,7 @@ func (r *FlowCollectorReconciler) handleNamespaceChanged(
 	return r.Status().Update(ctx, desired)
 }
 
-func isConsoleEnabled(mgr ctrl.Manager) (bool, error) {
-	discoveryClient, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
-	if err != nil {
-		return false, err
-	}
+func isConsoleEnabled(discoveryClient discovery.DiscoveryInterface) (bool, error) {
 	groupsList, err := discoveryClient.ServerGroups()
 	if err != nil {
 		return false, err
@@ -211,28 +779,142 @@ func isConsoleEnabled(mgr ctrl.Manager) (bool, error) {
 	return false, nil
 }
 
+// isServiceCAAvailable tells whether the OpenShift service CA operator, which issues the serving certificates
+// requested through the Service annotations, is deployed
+func isServiceCAAvailable(discoveryClient discovery.DiscoveryInterface) (bool, error) {
+	resources, err := discoveryClient.ServerResourcesForGroupVersion("operator.openshift.io/v1")
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	for i := range resources.APIResources {
+		if resources.APIResources[i].Name == "servicecas" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *FlowCollectorReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	builder := ctrl.NewControllerManagedBy(mgr).
-		For(&flowsv1alpha1.FlowCollector{}).
+		For(&flowsv1beta1.FlowCollector{}).
 		Owns(&corev1.ConfigMap{}).
 		Owns(&appsv1.Deployment{}).
 		Owns(&appsv1.DaemonSet{}).
+		Owns(&appsv1.StatefulSet{}).
 		Owns(&ascv1.HorizontalPodAutoscaler{}).
-		Owns(&corev1.Service{})
+		Owns(&corev1.Service{}).
+		Owns(&networkingv1.Ingress{}).
+		// Certificates and credentials referenced in the FlowCollector aren't owned, but their rotation must
+		// be rolled out. The ConfigMaps are already cached for the owned ones, while the Secrets are only watched
+		// in the namespace of the components, see watchSecrets.
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, handler.EnqueueRequestsFromMapFunc(r.referencingFlowCollectors))
 
-	var err error
-	r.consoleEnabled, err = isConsoleEnabled(mgr)
+	r.recorder = mgr.GetEventRecorderFor("flowcollector-controller")
+
+	// The FlowCollector is reconciled again once the Loki reachability is checked
+	lokiChecks := make(chan event.GenericEvent)
+	r.lokiProbe.notify = func(name string) {
+		lokiChecks <- event.GenericEvent{Object: &flowsv1beta1.FlowCollector{ObjectMeta: metav1.ObjectMeta{Name: name}}}
+	}
+	builder = builder.Watches(&source.Channel{Source: lokiChecks}, &handler.EnqueueRequestForObject{})
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	r.consoleEnabled, err = isConsoleEnabled(discoveryClient)
+	if err != nil {
+		return err
+	}
+	r.serviceCAAvailable, err = isServiceCAAvailable(discoveryClient)
 	if err != nil {
 		return err
 	}
 	if r.consoleEnabled {
 		builder = builder.Owns(&osv1alpha1.ConsolePlugin{})
 	}
-	return builder.Complete(r)
+	c, err := builder.Build(r)
+	if err != nil {
+		return err
+	}
+	r.secrets = newSecretWatcher(mgr, c, handler.EnqueueRequestsFromMapFunc(r.referencingFlowCollectors))
+	return nil
+}
+
+// referencingFlowCollectors returns the reconcile requests of the FlowCollectors that refer to the provided
+// Secret or ConfigMap
+func (r *FlowCollectorReconciler) referencingFlowCollectors(obj client.Object) []reconcile.Request {
+	list := flowsv1beta1.FlowCollectorList{}
+	if err := r.List(context.Background(), &list); err != nil {
+		log.Log.Error(err, "Failed to list FlowCollectors")
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range list.Items {
+		fc := &list.Items[i]
+		if referencesObject(fc, obj) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: fc.Name}})
+		}
+	}
+	return requests
+}
+
+// referencesObject tells whether the provided FlowCollector refers to the provided Secret or ConfigMap
+func referencesObject(fc *flowsv1beta1.FlowCollector, obj client.Object) bool {
+	ns := getNamespaceName(fc)
+	_, isSecret := obj.(*corev1.Secret)
+	// In LokiStack mode, the gateway CA bundle is copied from the LokiStack namespace
+	if loki := &fc.Spec.Storage.Loki; loki.Mode == constants.LokiModeLokiStack && !isSecret &&
+		obj.GetNamespace() == lokistack.Namespace(loki, ns) &&
+		obj.GetName() == lokistack.GatewayCABundleName(loki.LokiStack.Name) {
+		return true
+	}
+	if obj.GetNamespace() != ns {
+		return false
+	}
+	secrets, configMaps := referencedObjects(&fc.Spec)
+	// The plugin serving certificate is rotated by its issuer
+	secrets = append(secrets, constants.PluginServingCertSecret)
+	if isSecret {
+		return helper.ContainsString(secrets, obj.GetName())
+	}
+	return helper.ContainsString(configMaps, obj.GetName())
+}
+
+// referencedObjects returns the names of the Secrets and ConfigMaps that hold the certificates and credentials
+// referenced in the provided spec
+func referencedObjects(spec *flowsv1beta1.FlowCollectorSpec) (secrets, configMaps []string) {
+	addCertificate := func(ref *flowsv1beta1.CertificateReference) {
+		if ref.Name == "" {
+			return
+		}
+		if ref.Type == "configmap" {
+			configMaps = append(configMaps, ref.Name)
+		} else {
+			secrets = append(secrets, ref.Name)
+		}
+	}
+	clientTLSs := []*flowsv1beta1.ClientTLS{&spec.Kafka.TLS, &spec.Storage.Loki.TLS}
+	for i := range spec.Exporters {
+		clientTLSs = append(clientTLSs, &spec.Exporters[i].OpenTelemetry.TLS)
+	}
+	for _, clientTLS := range clientTLSs {
+		if clientTLS.Enable {
+			addCertificate(&clientTLS.CACert)
+			addCertificate(&clientTLS.UserCert)
+		}
+	}
+	if auth := &spec.Storage.Loki.Auth; auth.Type == "BearerToken" || auth.Type == "BasicAuth" {
+		secrets = append(secrets, auth.SecretName)
+	}
+	return secrets, configMaps
 }
 
-func getNamespaceName(desired *flowsv1alpha1.FlowCollector) string {
+func getNamespaceName(desired *flowsv1beta1.FlowCollector) string {
 	if desired.Spec.Namespace != "" {
 		return desired.Spec.Namespace
 	}
@@ -10,13 +10,16 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	ascv1 "k8s.io/api/autoscaling/v1"
 	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
-	flowsv1alpha1 "github.com/netobserv/network-observability-operator/api/v1alpha1"
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
 	"github.com/netobserv/network-observability-operator/controllers/constants"
 	. "github.com/netobserv/network-observability-operator/controllers/controllerstest"
 	"github.com/netobserv/network-observability-operator/controllers/goflowkube"
+	"github.com/netobserv/network-observability-operator/pkg/conditions"
 	"github.com/netobserv/network-observability-operator/pkg/helper"
 )
 
@@ -25,10 +28,6 @@ var _ = Describe("FlowCollector Controller", func() {
 	const timeout = time.Second * 10
 	const interval = 50 * time.Millisecond
 	const otherNamespace = "other-namespace"
-	ipResolver.On("LookupIP", constants.GoflowKubeName+"."+operatorNamespace).
-		Return([]net.IP{net.IPv4(11, 22, 33, 44)}, nil)
-	ipResolver.On("LookupIP", constants.GoflowKubeName+"."+otherNamespace).
-		Return([]net.IP{net.IPv4(111, 122, 133, 144)}, nil)
 	crKey := types.NamespacedName{
 		Name: "cluster",
 	}
@@ -69,27 +68,30 @@ var _ = Describe("FlowCollector Controller", func() {
 		var oldGoflowConfigDigest string
 		It("Should create successfully", func() {
 
-			created := &flowsv1alpha1.FlowCollector{
+			created := &flowsv1beta1.FlowCollector{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: crKey.Name,
 				},
-				Spec: flowsv1alpha1.FlowCollectorSpec{
-					GoflowKube: flowsv1alpha1.FlowCollectorGoflowKube{
+				Spec: flowsv1beta1.FlowCollectorSpec{
+					Processor: flowsv1beta1.FlowCollectorProcessor{
 						Kind:            "Deployment",
 						Port:            999,
 						ImagePullPolicy: "Never",
 						LogLevel:        "error",
 						Image:           "testimg:latest",
-						HPA: &flowsv1alpha1.FlowCollectorHPA{
+						HPA: &flowsv1beta1.FlowCollectorHPA{
 							MinReplicas:                    helper.Int32Ptr(1),
 							MaxReplicas:                    1,
 							TargetCPUUtilizationPercentage: helper.Int32Ptr(90),
 						},
 					},
-					IPFIX: flowsv1alpha1.FlowCollectorIPFIX{
-						Sampling: 200,
+					Agent: flowsv1beta1.FlowCollectorAgent{
+						IPFIX: flowsv1beta1.FlowCollectorIPFIX{
+							CacheActiveTimeout: metav1.Duration{Duration: 10 * time.Second},
+							Sampling:           200,
+						},
 					},
-					ConsolePlugin: flowsv1alpha1.FlowCollectorConsolePlugin{
+					Console: flowsv1beta1.FlowCollectorConsole{
 						Port:            9001,
 						ImagePullPolicy: "Never",
 						Image:           "testimg:latest",
@@ -138,21 +140,38 @@ var _ = Describe("FlowCollector Controller", func() {
 				return ofc.Data
 			}, timeout, interval).Should(Equal(map[string]string{
 				"sampling":           "200",
-				"sharedTarget":       "11.22.33.44:999",
+				"sharedTarget":       net.JoinHostPort(svc.Spec.ClusterIP, "999"),
 				"cacheMaxFlows":      "100",
 				"cacheActiveTimeout": "10s",
 			}))
+
+			By("Reporting the components state in the FlowCollector status")
+			Eventually(func() interface{} {
+				fc := flowsv1beta1.FlowCollector{}
+				if err := k8sClient.Get(ctx, crKey, &fc); err != nil {
+					return err
+				}
+				return conditionsByType(fc.Status.Conditions)
+			}, timeout, interval).Should(Equal(map[string]metav1.ConditionStatus{
+				conditions.TypeReady:              metav1.ConditionFalse,
+				conditions.TypeCollectorReady:     metav1.ConditionFalse,
+				conditions.TypeConsolePluginReady: metav1.ConditionFalse,
+				conditions.TypeOVSConfigured:      metav1.ConditionTrue,
+				conditions.TypeLokiReachable:      metav1.ConditionTrue,
+			}))
 		})
 
 		It("Should update successfully", func() {
+			svc := v1.Service{}
+			Expect(k8sClient.Get(ctx, gfKey1, &svc)).To(Succeed())
 			Eventually(func() error {
-				fc := flowsv1alpha1.FlowCollector{}
+				fc := flowsv1beta1.FlowCollector{}
 				if err := k8sClient.Get(ctx, crKey, &fc); err != nil {
 					return err
 				}
-				fc.Spec.IPFIX.CacheActiveTimeout = "30s"
-				fc.Spec.IPFIX.Sampling = 1234
-				fc.Spec.GoflowKube.Port = 1999
+				fc.Spec.Agent.IPFIX.CacheActiveTimeout = metav1.Duration{Duration: 30 * time.Second}
+				fc.Spec.Agent.IPFIX.Sampling = 1234
+				fc.Spec.Processor.Port = 1999
 				return k8sClient.Update(ctx, &fc)
 			}).Should(Succeed())
 
@@ -174,19 +193,46 @@ var _ = Describe("FlowCollector Controller", func() {
 				return ofc.Data
 			}, timeout, interval).Should(Equal(map[string]string{
 				"sampling":           "1234",
-				"sharedTarget":       "11.22.33.44:1999",
+				"sharedTarget":       net.JoinHostPort(svc.Spec.ClusterIP, "1999"),
 				"cacheMaxFlows":      "100",
 				"cacheActiveTimeout": "30s",
 			}))
 		})
 
+		It("Should update ovn-flows-configmap when the goflow-kube Service is recreated", func() {
+			oldSvc := v1.Service{}
+			Expect(k8sClient.Get(ctx, gfKey1, &oldSvc)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, &oldSvc)).To(Succeed())
+
+			By("Expecting the Service to be recreated")
+			newSvc := v1.Service{}
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, gfKey1, &newSvc); err != nil {
+					return err
+				}
+				if newSvc.UID == oldSvc.UID {
+					return fmt.Errorf("service %s not recreated yet", gfKey1)
+				}
+				return nil
+			}, timeout, interval).Should(Succeed())
+
+			By("Expecting the ovn-flows-configmap to target the new Service IP")
+			Eventually(func() interface{} {
+				ofc := v1.ConfigMap{}
+				if err := k8sClient.Get(ctx, ovsConfigMapKey, &ofc); err != nil {
+					return err
+				}
+				return ofc.Data["sharedTarget"]
+			}, timeout, interval).Should(Equal(net.JoinHostPort(newSvc.Spec.ClusterIP, "1999")))
+		})
+
 		It("Should redeploy if the spec doesn't change but the external goflow-kube-config does", func() {
 			Eventually(func() error {
-				fc := flowsv1alpha1.FlowCollector{}
+				fc := flowsv1beta1.FlowCollector{}
 				if err := k8sClient.Get(ctx, crKey, &fc); err != nil {
 					return err
 				}
-				fc.Spec.Loki.MaxRetries = 7
+				fc.Spec.Storage.Loki.MaxRetries = 7
 				return k8sClient.Update(ctx, &fc)
 			}).Should(Succeed())
 
@@ -212,10 +258,10 @@ var _ = Describe("FlowCollector Controller", func() {
 			Expect(hpa.Spec.MaxReplicas).To(Equal(int32(1)))
 			Expect(*hpa.Spec.TargetCPUUtilizationPercentage).To(Equal(int32(90)))
 			// update FlowCollector and verify that HPA spec also changed
-			fc := flowsv1alpha1.FlowCollector{}
+			fc := flowsv1beta1.FlowCollector{}
 			Expect(k8sClient.Get(ctx, crKey, &fc)).To(Succeed())
-			fc.Spec.GoflowKube.HPA.MinReplicas = helper.Int32Ptr(2)
-			fc.Spec.GoflowKube.HPA.MaxReplicas = 2
+			fc.Spec.Processor.HPA.MinReplicas = helper.Int32Ptr(2)
+			fc.Spec.Processor.HPA.MaxReplicas = 2
 			Expect(k8sClient.Update(ctx, &fc)).To(Succeed())
 
 			By("Changing the Horizontal Pod Autoscaler instance")
@@ -237,19 +283,18 @@ var _ = Describe("FlowCollector Controller", func() {
 	Context("Deploying as DaemonSet", func() {
 		var oldGoflowConfigDigest string
 		It("Should update successfully", func() {
-			fc := flowsv1alpha1.FlowCollector{}
+			fc := flowsv1beta1.FlowCollector{}
 			Expect(k8sClient.Get(ctx, crKey, &fc)).Should(Succeed())
-			fc.Spec.GoflowKube = flowsv1alpha1.FlowCollectorGoflowKube{
+			fc.Spec.Processor = flowsv1beta1.FlowCollectorProcessor{
 				Kind:            "DaemonSet",
 				Port:            7891,
 				ImagePullPolicy: "Never",
 				LogLevel:        "error",
 				Image:           "testimg:latest",
 			}
-			fc.Spec.Loki = flowsv1alpha1.FlowCollectorLoki{}
-			fc.Spec.IPFIX = flowsv1alpha1.FlowCollectorIPFIX{
-				Sampling: 200,
-			}
+			fc.Spec.Storage.Loki = flowsv1beta1.FlowCollectorLoki{}
+			fc.Spec.Agent.IPFIX.CacheActiveTimeout = metav1.Duration{Duration: 10 * time.Second}
+			fc.Spec.Agent.IPFIX.Sampling = 200
 			// Update
 			Expect(k8sClient.Update(ctx, &fc)).Should(Succeed())
 
@@ -306,11 +351,11 @@ var _ = Describe("FlowCollector Controller", func() {
 		})
 		It("Should redeploy if the spec doesn't change but the external goflow-kube-config does", func() {
 			Eventually(func() error {
-				fc := flowsv1alpha1.FlowCollector{}
+				fc := flowsv1beta1.FlowCollector{}
 				if err := k8sClient.Get(ctx, crKey, &fc); err != nil {
 					return err
 				}
-				fc.Spec.Loki.MaxRetries = 7
+				fc.Spec.Storage.Loki.MaxRetries = 7
 				return k8sClient.Update(ctx, &fc)
 			}).Should(Succeed())
 
@@ -353,12 +398,12 @@ var _ = Describe("FlowCollector Controller", func() {
 
 		It("Should update successfully", func() {
 			Eventually(func() error {
-				fc := flowsv1alpha1.FlowCollector{}
+				fc := flowsv1beta1.FlowCollector{}
 				if err := k8sClient.Get(ctx, crKey, &fc); err != nil {
 					return err
 				}
-				fc.Spec.ConsolePlugin.Port = 9099
-				fc.Spec.ConsolePlugin.Replicas = 2
+				fc.Spec.Console.Port = 9099
+				fc.Spec.Console.Replicas = 2
 				return k8sClient.Update(ctx, &fc)
 			}).Should(Succeed())
 
@@ -389,11 +434,11 @@ var _ = Describe("FlowCollector Controller", func() {
 		})
 		It("Should update the Loki URL in the Console Plugin if it changes in the Spec", func() {
 			Expect(func() error {
-				upd := flowsv1alpha1.FlowCollector{}
+				upd := flowsv1beta1.FlowCollector{}
 				if err := k8sClient.Get(ctx, crKey, &upd); err != nil {
 					return err
 				}
-				upd.Spec.Loki.URL = "http://loki.namespace:8888"
+				upd.Spec.Storage.Loki.URL = "http://loki.namespace:8888"
 				return k8sClient.Update(ctx, &upd)
 			}()).Should(Succeed())
 			Eventually(getContainerArgumentAfter("network-observability-plugin", "-loki"),
@@ -401,11 +446,11 @@ var _ = Describe("FlowCollector Controller", func() {
 		})
 		It("Should use the Loki Querier URL instead of the Loki URL, if the first is defined", func() {
 			Expect(func() error {
-				upd := flowsv1alpha1.FlowCollector{}
+				upd := flowsv1beta1.FlowCollector{}
 				if err := k8sClient.Get(ctx, crKey, &upd); err != nil {
 					return err
 				}
-				upd.Spec.Loki.QuerierURL = "http://loki-querier:6789"
+				upd.Spec.Storage.Loki.QuerierURL = "http://loki-querier:6789"
 				return k8sClient.Update(ctx, &upd)
 			}()).Should(Succeed())
 			Eventually(getContainerArgumentAfter("network-observability-plugin", "-loki"),
@@ -416,16 +461,15 @@ var _ = Describe("FlowCollector Controller", func() {
 	Context("Changing namespace", func() {
 		It("Should update namespace successfully", func() {
 			Eventually(func() error {
-				fc := flowsv1alpha1.FlowCollector{}
+				fc := flowsv1beta1.FlowCollector{}
 				if err := k8sClient.Get(ctx, crKey, &fc); err != nil {
 					return err
 				}
-				fc.Spec.GoflowKube.Kind = "Deployment"
-				fc.Spec.GoflowKube.Port = 999
+				fc.Spec.Processor.Kind = "Deployment"
+				fc.Spec.Processor.Port = 999
 				fc.Spec.Namespace = otherNamespace
-				fc.Spec.IPFIX = flowsv1alpha1.FlowCollectorIPFIX{
-					Sampling: 200,
-				}
+				fc.Spec.Agent.IPFIX.CacheActiveTimeout = metav1.Duration{Duration: 10 * time.Second}
+				fc.Spec.Agent.IPFIX.Sampling = 200
 				return k8sClient.Update(ctx, &fc)
 			}).Should(Succeed())
 		})
@@ -458,6 +502,8 @@ var _ = Describe("FlowCollector Controller", func() {
 		})
 
 		It("Should update ovn-flows-configmap with new IP", func() {
+			svc := v1.Service{}
+			Expect(k8sClient.Get(ctx, gfKey2, &svc)).To(Succeed())
 			Eventually(func() interface{} {
 				ofc := v1.ConfigMap{}
 				if err := k8sClient.Get(ctx, ovsConfigMapKey, &ofc); err != nil {
@@ -466,7 +512,7 @@ var _ = Describe("FlowCollector Controller", func() {
 				return ofc.Data
 			}, timeout, interval).Should(Equal(map[string]string{
 				"sampling":           "200",
-				"sharedTarget":       "111.122.133.144:999",
+				"sharedTarget":       net.JoinHostPort(svc.Spec.ClusterIP, "999"),
 				"cacheMaxFlows":      "100",
 				"cacheActiveTimeout": "10s",
 			}))
@@ -497,7 +543,7 @@ var _ = Describe("FlowCollector Controller", func() {
 
 	Context("Cleanup", func() {
 		// Retrieve CR to get its UID
-		flowCR := flowsv1alpha1.FlowCollector{}
+		flowCR := flowsv1beta1.FlowCollector{}
 		It("Should get CR", func() {
 			Eventually(func() error {
 				return k8sClient.Get(ctx, crKey, &flowCR)
@@ -539,13 +585,6 @@ var _ = Describe("FlowCollector Controller", func() {
 				return &svc
 			}, timeout, interval).Should(BeGarbageCollectedBy(&flowCR))
 
-			By("Expecting ovn-flows-configmap to be garbage collected")
-			Eventually(func() interface{} {
-				cm := v1.ConfigMap{}
-				_ = k8sClient.Get(ctx, ovsConfigMapKey, &cm)
-				return &cm
-			}, timeout, interval).Should(BeGarbageCollectedBy(&flowCR))
-
 			By("Expecting goflow-kube configmap to be garbage collected")
 			Eventually(func() interface{} {
 				cm := v1.ConfigMap{}
@@ -556,6 +595,26 @@ var _ = Describe("FlowCollector Controller", func() {
 				return &cm
 			}, timeout, interval).Should(BeGarbageCollectedBy(&flowCR))
 		})
+
+		It("Should be cleaned up by the finalizer", func() {
+			By("Expecting ovn-flows-configmap to be deleted")
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, ovsConfigMapKey, &v1.ConfigMap{})
+				return errors.IsNotFound(err)
+			}, timeout, interval).Should(BeTrue())
+
+			By("Expecting goflow-kube cluster role binding to be deleted")
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: constants.GoflowKubeName}, &rbacv1.ClusterRoleBinding{})
+				return errors.IsNotFound(err)
+			}, timeout, interval).Should(BeTrue())
+
+			By("Expecting the FlowCollector to be deleted once cleaned up")
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, crKey, &flowsv1beta1.FlowCollector{})
+				return errors.IsNotFound(err)
+			}, timeout, interval).Should(BeTrue())
+		})
 	})
 })
 
@@ -590,3 +649,11 @@ func getContainerArgumentAfter(containerName, argName string) func() interface{}
 		return fmt.Errorf("container not found: %v", containerName)
 	}
 }
+
+func conditionsByType(conds []metav1.Condition) map[string]metav1.ConditionStatus {
+	statuses := map[string]metav1.ConditionStatus{}
+	for i := range conds {
+		statuses[conds[i].Type] = conds[i].Status
+	}
+	return statuses
+}
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	osv1alpha1 "github.com/openshift/api/console/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/constants"
+	"github.com/netobserv/network-observability-operator/controllers/controllerstest"
+	"github.com/netobserv/network-observability-operator/pkg/conditions"
+)
+
+func TestModifiedObjectsAreRestored(t *testing.T) {
+	ctx := context.Background()
+	r, cl := newCleanupTestReconciler(t)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster"}}
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	ns := "netobserv-test"
+	pluginKey := types.NamespacedName{Name: constants.PluginName, Namespace: ns}
+	for _, managed := range []struct {
+		key types.NamespacedName
+		obj client.Object
+	}{
+		{key: types.NamespacedName{Name: constants.GoflowKubeName, Namespace: ns}, obj: &appsv1.DaemonSet{}},
+		{key: types.NamespacedName{Name: "goflow-kube-config", Namespace: ns}, obj: &corev1.ConfigMap{}},
+		{key: pluginKey, obj: &appsv1.Deployment{}},
+		{key: pluginKey, obj: &corev1.Service{}},
+		{key: types.NamespacedName{Name: constants.PluginName}, obj: &osv1alpha1.ConsolePlugin{}},
+	} {
+		written := managed.obj
+		require.NoError(t, cl.Get(ctx, managed.key, written))
+		written.SetResourceVersion("")
+
+		// Every field is modified, then an element is added to every list and map
+		for _, mutated := range []client.Object{controllerstest.ModifyAll(written), controllerstest.AddAll(written)} {
+			current := written.DeepCopyObject().(client.Object)
+			require.NoError(t, cl.Get(ctx, managed.key, current))
+			mutated.SetResourceVersion(current.GetResourceVersion())
+			require.NoError(t, cl.Update(ctx, mutated))
+
+			_, err := r.Reconcile(ctx, req)
+			require.NoError(t, err)
+
+			restored := written.DeepCopyObject().(client.Object)
+			require.NoError(t, cl.Get(ctx, managed.key, restored))
+			restored.SetResourceVersion("")
+			assert.Equalf(t, written, restored, "%T %s: not restored", written, managed.key)
+		}
+	}
+}
+
+func TestFieldOwnedByAnotherManagerIsReverted(t *testing.T) {
+	ctx := context.Background()
+	r, cl := newCleanupTestReconciler(t)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster"}}
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	dsKey := types.NamespacedName{Name: constants.GoflowKubeName, Namespace: "netobserv-test"}
+	ds := appsv1.DaemonSet{}
+	require.NoError(t, cl.Get(ctx, dsKey, &ds))
+	image := ds.Spec.Template.Spec.Containers[0].Image
+	// The image written by another field manager, e.g. by a manual edit or an earlier operator version
+	modifyImage := func() {
+		ds := appsv1.DaemonSet{}
+		require.NoError(t, cl.Get(ctx, dsKey, &ds))
+		ds.Spec.Template.Spec.Containers[0].Image = "quay.io/someone/goflow-kube:test"
+		require.NoError(t, cl.Update(ctx, &ds))
+	}
+
+	// The operator takes the ownership over by default
+	modifyImage()
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	ds = appsv1.DaemonSet{}
+	require.NoError(t, cl.Get(ctx, dsKey, &ds))
+	assert.Equal(t, image, ds.Spec.Template.Spec.Containers[0].Image)
+
+	// Otherwise, the conflict is reported
+	r.forceOwnership = false
+	modifyImage()
+	_, _ = r.Reconcile(ctx, req)
+	ds = appsv1.DaemonSet{}
+	require.NoError(t, cl.Get(ctx, dsKey, &ds))
+	assert.Equal(t, "quay.io/someone/goflow-kube:test", ds.Spec.Template.Spec.Containers[0].Image)
+	fc := flowsv1beta1.FlowCollector{}
+	require.NoError(t, cl.Get(ctx, req.NamespacedName, &fc))
+	cond := meta.FindStatusCondition(fc.Status.Conditions, conditions.TypeCollectorReady)
+	require.NotNil(t, cond)
+	assert.Equal(t, conditions.ReasonFieldConflict, cond.Reason)
+}
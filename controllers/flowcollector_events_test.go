@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+)
+
+func drainEvents(recorder *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestObjectChangesAreNotified(t *testing.T) {
+	require := require.New(t)
+	r, cl := newCleanupTestReconciler(t)
+	recorder := r.recorder.(*record.FakeRecorder)
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster"}}
+
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+	events := drainEvents(recorder)
+	assert.Contains(t, events, corev1.EventTypeNormal+" Created DaemonSet netobserv-test/goflow-kube")
+	assert.Contains(t, events, corev1.EventTypeNormal+" Created ClusterRole goflow-kube")
+	assert.Contains(t, events, corev1.EventTypeNormal+" Created ConfigMap "+cnoNamespace+"/"+ovsFlowsConfigMapName)
+
+	// Nothing is notified when nothing changes
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Empty(t, drainEvents(recorder))
+
+	// The OVS configuration changes are notified as well
+	fc := flowsv1beta1.FlowCollector{}
+	require.NoError(cl.Get(ctx, req.NamespacedName, &fc))
+	fc.Spec.Agent.IPFIX.Sampling = 200
+	require.NoError(cl.Update(ctx, &fc))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Contains(t, drainEvents(recorder), corev1.EventTypeNormal+" Updated ConfigMap "+cnoNamespace+"/"+ovsFlowsConfigMapName)
+
+	// The objects removed from the previous namespace are notified
+	fc = flowsv1beta1.FlowCollector{}
+	require.NoError(cl.Get(ctx, req.NamespacedName, &fc))
+	fc.Spec.Namespace = "netobserv-moved"
+	require.NoError(cl.Update(ctx, &fc))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	events = drainEvents(recorder)
+	assert.Contains(t, events, corev1.EventTypeNormal+" Deleted DaemonSet netobserv-test/goflow-kube")
+	assert.Contains(t, events, corev1.EventTypeNormal+" Created DaemonSet netobserv-moved/goflow-kube")
+}
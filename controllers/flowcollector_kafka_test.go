@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+)
+
+func TestKafkaConsumer(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	r, cl := newCleanupTestReconciler(t)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster"}}
+	consumerKey := types.NamespacedName{Name: "goflow-kube-consumer", Namespace: "netobserv-test"}
+	configKey := types.NamespacedName{Name: "goflow-kube-config", Namespace: "netobserv-test"}
+
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+	assertNotFound(t, cl, consumerKey, &appsv1.Deployment{})
+
+	// Enabling Kafka deploys the consumer, and makes the collector produce to Kafka
+	fc := flowsv1beta1.FlowCollector{}
+	require.NoError(cl.Get(ctx, req.NamespacedName, &fc))
+	fc.Spec.Kafka = flowsv1beta1.FlowCollectorKafka{
+		Enable:           true,
+		Brokers:          []string{"kafka:9092"},
+		Topic:            "network-flows",
+		ConsumerReplicas: 2,
+	}
+	require.NoError(cl.Update(ctx, &fc))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	consumer := appsv1.Deployment{}
+	require.NoError(cl.Get(ctx, consumerKey, &consumer))
+	assert.Equal(int32(2), *consumer.Spec.Replicas)
+	require.NoError(cl.Get(ctx, types.NamespacedName{Name: "goflow-kube-consumer-config", Namespace: "netobserv-test"}, &corev1.ConfigMap{}))
+	cm := corev1.ConfigMap{}
+	require.NoError(cl.Get(ctx, configKey, &cm))
+	assert.True(strings.Contains(cm.Data["config.yaml"], `"kafkaOutput"`), cm.Data["config.yaml"])
+
+	// Disabling it removes the consumer
+	require.NoError(cl.Get(ctx, req.NamespacedName, &fc))
+	fc.Spec.Kafka.Enable = false
+	require.NoError(cl.Update(ctx, &fc))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assertNotFound(t, cl, consumerKey, &appsv1.Deployment{})
+	assertNotFound(t, cl, types.NamespacedName{Name: "goflow-kube-consumer-config", Namespace: "netobserv-test"}, &corev1.ConfigMap{})
+	cm = corev1.ConfigMap{}
+	require.NoError(cl.Get(ctx, configKey, &cm))
+	assert.True(strings.Contains(cm.Data["config.yaml"], `"loki"`), cm.Data["config.yaml"])
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/goflowkube"
+	"github.com/netobserv/network-observability-operator/pkg/conditions"
+)
+
+func TestLokiStackMode(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	gatewayCA := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "lokistack-gateway-ca-bundle", Namespace: "openshift-logging"},
+		Data:       map[string]string{"service-ca.crt": "CA"},
+	}
+	r, cl := newCleanupTestReconciler(t, gatewayCA)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster"}}
+	caKey := types.NamespacedName{Name: "netobserv-loki-gateway-ca-bundle", Namespace: "netobserv-test"}
+
+	fc := flowsv1beta1.FlowCollector{}
+	require.NoError(cl.Get(ctx, req.NamespacedName, &fc))
+	fc.Spec.Storage.Loki.Mode = "LokiStack"
+	fc.Spec.Storage.Loki.LokiStack = flowsv1beta1.LokiStackRef{Name: "lokistack", Namespace: "openshift-logging"}
+	require.NoError(cl.Update(ctx, &fc))
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+
+	// The gateway CA is copied next to the components, which are granted access to the network tenant
+	ca := corev1.ConfigMap{}
+	require.NoError(cl.Get(ctx, caKey, &ca))
+	assert.Equal("CA", ca.Data["service-ca.crt"])
+	for name, sa := range map[string]string{
+		"netobserv-loki-writer": "goflow-kube",
+		"netobserv-loki-reader": "network-observability-plugin",
+	} {
+		role := rbacv1.ClusterRole{}
+		require.NoError(cl.Get(ctx, types.NamespacedName{Name: name}, &role))
+		assert.Equal([]string{"network"}, role.Rules[0].Resources)
+		crb := rbacv1.ClusterRoleBinding{}
+		require.NoError(cl.Get(ctx, types.NamespacedName{Name: name}, &crb))
+		assert.Equal(sa, crb.Subjects[0].Name)
+		assert.Equal("netobserv-test", crb.Subjects[0].Namespace)
+	}
+
+	// The components are configured for the gateway
+	cm := corev1.ConfigMap{}
+	require.NoError(cl.Get(ctx, types.NamespacedName{Name: "goflow-kube-config", Namespace: "netobserv-test"}, &cm))
+	var config goflowkube.ConfigMap
+	require.NoError(json.Unmarshal([]byte(cm.Data["config.yaml"]), &config))
+	assert.Equal("https://lokistack-gateway-http.openshift-logging.svc:8080/api/logs/v1/network/", config.Loki.URL)
+	assert.Equal("network", config.Loki.TenantID)
+	assert.Equal("/var/loki-ca/service-ca.crt", config.Loki.TLS.CACertPath)
+	plugin := appsv1.Deployment{}
+	require.NoError(cl.Get(ctx, types.NamespacedName{Name: "network-observability-plugin", Namespace: "netobserv-test"}, &plugin))
+	assert.Contains(plugin.Spec.Template.Spec.Containers[0].Args, "-loki-tenant-id")
+	// The resolved settings aren't persisted in the FlowCollector
+	require.NoError(cl.Get(ctx, req.NamespacedName, &fc))
+	assert.Empty(fc.Spec.Storage.Loki.TenantID)
+
+	// The rotation of the gateway CA is watched in the LokiStack namespace
+	assert.Len(r.referencingFlowCollectors(gatewayCA), 1)
+
+	// Back to Manual mode, LokiStack resources are removed
+	fc.Spec.Storage.Loki.Mode = "Manual"
+	require.NoError(cl.Update(ctx, &fc))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assertNotFound(t, cl, caKey, &corev1.ConfigMap{})
+	assertNotFound(t, cl, types.NamespacedName{Name: "netobserv-loki-writer"}, &rbacv1.ClusterRoleBinding{})
+	assertNotFound(t, cl, types.NamespacedName{Name: "netobserv-loki-reader"}, &rbacv1.ClusterRole{})
+}
+
+func TestLokiStackModeWithoutGateway(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	r, cl := newCleanupTestReconciler(t)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster"}}
+	fc := flowsv1beta1.FlowCollector{}
+	require.NoError(cl.Get(ctx, req.NamespacedName, &fc))
+	fc.Spec.Storage.Loki.Mode = "LokiStack"
+	fc.Spec.Storage.Loki.LokiStack.Name = "lokistack"
+	require.NoError(cl.Update(ctx, &fc))
+	_, err := r.Reconcile(ctx, req)
+	require.Error(err)
+
+	fc = flowsv1beta1.FlowCollector{}
+	require.NoError(cl.Get(ctx, req.NamespacedName, &fc))
+	cond := meta.FindStatusCondition(fc.Status.Conditions, conditions.TypeLokiReachable)
+	require.NotNil(cond)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Contains(t, cond.Message, "lokistack-gateway-ca-bundle")
+}
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/goflowkube"
+)
+
+func TestDeployLoki(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	r, cl := newCleanupTestReconciler(t)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster"}}
+	lokiKey := types.NamespacedName{Name: "netobserv-loki", Namespace: "netobserv-test"}
+
+	fc := flowsv1beta1.FlowCollector{}
+	require.NoError(cl.Get(ctx, req.NamespacedName, &fc))
+	fc.Spec.Storage.Loki.Mode = "Deploy"
+	fc.Spec.Storage.Loki.Deploy = flowsv1beta1.FlowCollectorLokiDeploy{
+		Image:           "grafana/loki:2.4.1",
+		StorageSize:     resource.MustParse("10Gi"),
+		RetentionPeriod: metav1.Duration{Duration: 24 * time.Hour},
+	}
+	require.NoError(cl.Update(ctx, &fc))
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+
+	// Loki is deployed, and the components are connected to it
+	require.NoError(cl.Get(ctx, lokiKey, &appsv1.StatefulSet{}))
+	require.NoError(cl.Get(ctx, lokiKey, &corev1.PersistentVolumeClaim{}))
+	require.NoError(cl.Get(ctx, lokiKey, &corev1.Service{}))
+	require.NoError(cl.Get(ctx, types.NamespacedName{Name: "netobserv-loki-config", Namespace: "netobserv-test"}, &corev1.ConfigMap{}))
+	cm := corev1.ConfigMap{}
+	require.NoError(cl.Get(ctx, types.NamespacedName{Name: "goflow-kube-config", Namespace: "netobserv-test"}, &cm))
+	var config goflowkube.ConfigMap
+	require.NoError(json.Unmarshal([]byte(cm.Data["config.yaml"]), &config))
+	assert.Equal("http://netobserv-loki.netobserv-test.svc:3100/", config.Loki.URL)
+	plugin := appsv1.Deployment{}
+	require.NoError(cl.Get(ctx, types.NamespacedName{Name: "network-observability-plugin", Namespace: "netobserv-test"}, &plugin))
+	assert.Contains(plugin.Spec.Template.Spec.Containers[0].Args, "http://netobserv-loki.netobserv-test.svc:3100/")
+
+	// Increasing the storage size expands the volume
+	require.NoError(cl.Get(ctx, req.NamespacedName, &fc))
+	fc.Spec.Storage.Loki.Deploy.StorageSize = resource.MustParse("20Gi")
+	require.NoError(cl.Update(ctx, &fc))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	pvc := corev1.PersistentVolumeClaim{}
+	require.NoError(cl.Get(ctx, lokiKey, &pvc))
+	assert.Equal(resource.MustParse("20Gi"), pvc.Spec.Resources.Requests[corev1.ResourceStorage])
+
+	// Back to Manual mode, the deployed Loki and its storage are removed
+	require.NoError(cl.Get(ctx, req.NamespacedName, &fc))
+	fc.Spec.Storage.Loki.Mode = "Manual"
+	require.NoError(cl.Update(ctx, &fc))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assertNotFound(t, cl, lokiKey, &appsv1.StatefulSet{})
+	assertNotFound(t, cl, lokiKey, &corev1.PersistentVolumeClaim{})
+	assertNotFound(t, cl, lokiKey, &corev1.Service{})
+}
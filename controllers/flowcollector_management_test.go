@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	osv1alpha1 "github.com/openshift/api/console/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/constants"
+	"github.com/netobserv/network-observability-operator/pkg/conditions"
+)
+
+func setManagementStates(t *testing.T, cl client.Client, global, processor string) {
+	fc := flowsv1beta1.FlowCollector{}
+	require.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: "cluster"}, &fc))
+	fc.Spec.ManagementState = global
+	fc.Spec.Processor.ManagementState = processor
+	require.NoError(t, cl.Update(context.Background(), &fc))
+}
+
+func getCondition(t *testing.T, cl client.Client, condType string) *metav1.Condition {
+	fc := flowsv1beta1.FlowCollector{}
+	require.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: "cluster"}, &fc))
+	return meta.FindStatusCondition(fc.Status.Conditions, condType)
+}
+
+func TestCollectorManagementState(t *testing.T) {
+	require := require.New(t)
+	r, cl := newCleanupTestReconciler(t)
+	r.recorder = &record.FakeRecorder{}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster"}}
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+
+	dsKey := types.NamespacedName{Name: constants.GoflowKubeName, Namespace: "netobserv-test"}
+	crKey := types.NamespacedName{Name: constants.GoflowKubeName}
+	ds := appsv1.DaemonSet{}
+	require.NoError(cl.Get(ctx, dsKey, &ds))
+
+	// Unmanaged: manual changes are kept, while the state is still reported
+	setManagementStates(t, cl, "", constants.ManagementStateUnmanaged)
+	ds.Spec.Template.Spec.Containers[0].Image = "quay.io/netobserv/goflow2-kube:debug"
+	require.NoError(cl.Update(ctx, &ds))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	require.NoError(cl.Get(ctx, dsKey, &ds))
+	assert.Equal(t, "quay.io/netobserv/goflow2-kube:debug", ds.Spec.Template.Spec.Containers[0].Image)
+	cond := getCondition(t, cl, conditions.TypeCollectorReady)
+	require.NotNil(cond)
+	assert.Equal(t, conditions.ReasonUnmanaged, cond.Reason)
+
+	// Removed: the collector objects are deleted, the other components being kept
+	setManagementStates(t, cl, "", constants.ManagementStateRemoved)
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assertNotFound(t, cl, dsKey, &appsv1.DaemonSet{})
+	assertNotFound(t, cl, dsKey, &corev1.ServiceAccount{})
+	assertNotFound(t, cl, crKey, &rbacv1.ClusterRole{})
+	assert.Nil(t, getCondition(t, cl, conditions.TypeCollectorReady))
+	require.NoError(cl.Get(ctx, types.NamespacedName{Name: constants.PluginName}, &osv1alpha1.ConsolePlugin{}))
+
+	// Managed again: the collector is restored, including its permissions
+	setManagementStates(t, cl, "", constants.ManagementStateManaged)
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	restored := appsv1.DaemonSet{}
+	require.NoError(cl.Get(ctx, dsKey, &restored))
+	assert.NotEqual(t, "quay.io/netobserv/goflow2-kube:debug", restored.Spec.Template.Spec.Containers[0].Image)
+	require.NoError(cl.Get(ctx, dsKey, &corev1.ServiceAccount{}))
+	require.NoError(cl.Get(ctx, crKey, &rbacv1.ClusterRole{}))
+}
+
+func TestGlobalManagementState(t *testing.T) {
+	require := require.New(t)
+	r, cl := newCleanupTestReconciler(t)
+	r.recorder = &record.FakeRecorder{}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster"}}
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+
+	ovsKey := types.NamespacedName{Name: ovsFlowsConfigMapName, Namespace: cnoNamespace}
+	pluginKey := types.NamespacedName{Name: constants.PluginName, Namespace: "netobserv-test"}
+	require.NoError(cl.Get(ctx, ovsKey, &corev1.ConfigMap{}))
+
+	// The global state overrides the components one
+	setManagementStates(t, cl, constants.ManagementStateUnmanaged, constants.ManagementStateManaged)
+	require.NoError(cl.Delete(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: ovsKey.Name, Namespace: ovsKey.Namespace}}))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assertNotFound(t, cl, ovsKey, &corev1.ConfigMap{})
+	for _, condType := range []string{conditions.TypeCollectorReady, conditions.TypeConsolePluginReady, conditions.TypeOVSConfigured} {
+		cond := getCondition(t, cl, condType)
+		require.NotNil(cond, condType)
+		assert.Equal(t, conditions.ReasonUnmanaged, cond.Reason, condType)
+	}
+	assert.Contains(t, getCondition(t, cl, conditions.TypeOVSConfigured).Message, "is missing")
+
+	setManagementStates(t, cl, constants.ManagementStateRemoved, constants.ManagementStateManaged)
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assertNotFound(t, cl, types.NamespacedName{Name: constants.GoflowKubeName, Namespace: "netobserv-test"}, &appsv1.DaemonSet{})
+	assertNotFound(t, cl, pluginKey, &appsv1.Deployment{})
+	assertNotFound(t, cl, types.NamespacedName{Name: constants.PluginName}, &osv1alpha1.ConsolePlugin{})
+	assert.Nil(t, getCondition(t, cl, conditions.TypeOVSConfigured))
+	// The FlowCollector and its namespace are kept
+	require.NoError(cl.Get(ctx, types.NamespacedName{Name: "netobserv-test"}, &corev1.Namespace{}))
+
+	setManagementStates(t, cl, constants.ManagementStateManaged, constants.ManagementStateManaged)
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	require.NoError(cl.Get(ctx, ovsKey, &corev1.ConfigMap{}))
+	require.NoError(cl.Get(ctx, pluginKey, &appsv1.Deployment{}))
+}
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/goflowkube"
+	"github.com/netobserv/network-observability-operator/pkg/conditions"
+)
+
+func TestFlowMetricsWithoutLoki(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	r, cl := newCleanupTestReconciler(t)
+	lokiChecks := make(chan struct{}, 10)
+	r.checkLoki = func(context.Context, *flowsv1beta1.FlowCollectorLoki, string) error {
+		lokiChecks <- struct{}{}
+		return nil
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster"}}
+	metricsKey := types.NamespacedName{Name: "goflow-kube-flow-metrics", Namespace: "netobserv-test"}
+	newServiceMonitor := func() *unstructured.Unstructured {
+		sm := unstructured.Unstructured{}
+		sm.SetGroupVersionKind(schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"})
+		return &sm
+	}
+
+	fc := flowsv1beta1.FlowCollector{}
+	require.NoError(cl.Get(ctx, req.NamespacedName, &fc))
+	disabled := false
+	fc.Spec.Storage.Loki.Enable = &disabled
+	fc.Spec.Processor.Metrics = flowsv1beta1.FlowCollectorMetrics{
+		Enable:      true,
+		Port:        9102,
+		IncludeList: []string{"bytes_total"},
+		Labels:      []string{"SrcNamespace", "DstNamespace"},
+	}
+	require.NoError(cl.Update(ctx, &fc))
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+
+	// The collector computes the metrics instead of sending the flows to Loki
+	cm := corev1.ConfigMap{}
+	require.NoError(cl.Get(ctx, types.NamespacedName{Name: "goflow-kube-config", Namespace: "netobserv-test"}, &cm))
+	var config goflowkube.ConfigMap
+	require.NoError(json.Unmarshal([]byte(cm.Data["config.yaml"]), &config))
+	assert.Nil(config.Loki)
+	require.NotNil(config.Metrics)
+	assert.Equal("bytes_total", config.Metrics.Metrics[0].Name)
+	svc := corev1.Service{}
+	require.NoError(cl.Get(ctx, metricsKey, &svc))
+	assert.Equal(int32(9102), svc.Spec.Ports[0].Port)
+	require.NoError(cl.Get(ctx, metricsKey, newServiceMonitor()))
+
+	// Loki isn't checked
+	assert.Len(lokiChecks, 0)
+	require.NoError(cl.Get(ctx, req.NamespacedName, &fc))
+	assert.Nil(meta.FindStatusCondition(fc.Status.Conditions, conditions.TypeLokiReachable))
+
+	// Disabling the metrics removes the Service and the ServiceMonitor
+	enabled := true
+	fc.Spec.Storage.Loki.Enable = &enabled
+	fc.Spec.Processor.Metrics.Enable = false
+	require.NoError(cl.Update(ctx, &fc))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assertNotFound(t, cl, metricsKey, &corev1.Service{})
+	assertNotFound(t, cl, metricsKey, newServiceMonitor())
+	// The check runs in the background
+	assert.Eventually(func() bool { return len(lokiChecks) > 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestProcessorMonitoring(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	r, cl := newCleanupTestReconciler(t)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster"}}
+	monitoringKey := types.NamespacedName{Name: "goflow-kube-monitoring", Namespace: "netobserv-test"}
+	ruleKey := types.NamespacedName{Name: "goflow-kube", Namespace: "netobserv-test"}
+	newMonitoringObject := func(kind string) *unstructured.Unstructured {
+		obj := unstructured.Unstructured{}
+		obj.SetGroupVersionKind(schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: kind})
+		return &obj
+	}
+
+	fc := flowsv1beta1.FlowCollector{}
+	require.NoError(cl.Get(ctx, req.NamespacedName, &fc))
+	fc.Spec.Processor.Monitoring = flowsv1beta1.FlowCollectorMonitoring{
+		Enable: true,
+		Port:   9090,
+		Alerts: flowsv1beta1.FlowCollectorAlerts{
+			NoFlowsFor:        metav1.Duration{Duration: 10 * time.Minute},
+			LokiErrorsPercent: 5,
+			UDPDropsPerSecond: 100,
+			NotReadyFor:       metav1.Duration{Duration: 5 * time.Minute},
+		},
+	}
+	require.NoError(cl.Update(ctx, &fc))
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+
+	svc := corev1.Service{}
+	require.NoError(cl.Get(ctx, monitoringKey, &svc))
+	assert.Equal(int32(9090), svc.Spec.Ports[0].Port)
+	require.NoError(cl.Get(ctx, monitoringKey, newMonitoringObject("ServiceMonitor")))
+	rule := newMonitoringObject("PrometheusRule")
+	require.NoError(cl.Get(ctx, ruleKey, rule))
+
+	// The thresholds changes update the rule
+	require.NoError(cl.Get(ctx, req.NamespacedName, &fc))
+	fc.Spec.Processor.Monitoring.Alerts.LokiErrorsPercent = 20
+	require.NoError(cl.Update(ctx, &fc))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	updated := newMonitoringObject("PrometheusRule")
+	require.NoError(cl.Get(ctx, ruleKey, updated))
+	assert.NotEqual(rule.GetResourceVersion(), updated.GetResourceVersion())
+
+	// Disabling the monitoring removes the Service, the ServiceMonitor and the PrometheusRule
+	require.NoError(cl.Get(ctx, req.NamespacedName, &fc))
+	fc.Spec.Processor.Monitoring.Enable = false
+	require.NoError(cl.Update(ctx, &fc))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assertNotFound(t, cl, monitoringKey, &corev1.Service{})
+	assertNotFound(t, cl, monitoringKey, newMonitoringObject("ServiceMonitor"))
+	assertNotFound(t, cl, ruleKey, newMonitoringObject("PrometheusRule"))
+}
@@ -5,10 +5,16 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// createdByOperatorLabel marks the namespaces created by the operator, which are deleted along with the FlowCollector
+const createdByOperatorLabel = "flows.netobserv.io/created-by-operator"
+
 func buildNamespace(ns string) *corev1.Namespace {
 	return &corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: ns,
+			Labels: map[string]string{
+				createdByOperatorLabel: "true",
+			},
 		},
 	}
 }
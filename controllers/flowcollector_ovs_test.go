@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	osv1alpha1 "github.com/openshift/api/console/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/constants"
+	"github.com/netobserv/network-observability-operator/controllers/controllerstest"
+	"github.com/netobserv/network-observability-operator/pkg/conditions"
+)
+
+func TestOVSConfigFailureIsRequeuedAndNotified(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	scheme := runtime.NewScheme()
+	require.NoError(clientgoscheme.AddToScheme(scheme))
+	require.NoError(flowsv1beta1.AddToScheme(scheme))
+	require.NoError(osv1alpha1.AddToScheme(scheme))
+	fc := &flowsv1beta1.FlowCollector{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: flowsv1beta1.FlowCollectorSpec{
+			Agent: flowsv1beta1.FlowCollectorAgent{IPFIX: flowsv1beta1.FlowCollectorIPFIX{
+				ClusterNetworkOperator: flowsv1beta1.ClusterNetworkOperator{Namespace: "openshift-network-operator"},
+			}},
+			Processor: flowsv1beta1.FlowCollectorProcessor{Kind: "Deployment", Port: 2055},
+		},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(fc).Build()
+	recorder := record.NewFakeRecorder(100)
+	r := &FlowCollectorReconciler{
+		Client:         controllerstest.NewFakeApplyClient(cl),
+		Scheme:         scheme,
+		forceOwnership: true,
+		checkLoki:      func(context.Context, *flowsv1beta1.FlowCollectorLoki, string) error { return nil },
+		recorder:       recorder,
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster"}}
+
+	// The fake client doesn't allocate any cluster IP to the created Service, so the OVS target can't
+	// be computed: error must be returned to get requeued with backoff
+	_, err := r.Reconcile(context.Background(), req)
+	require.Error(err)
+	assert.Contains(err.Error(), "has no cluster IP")
+
+	updated := flowsv1beta1.FlowCollector{}
+	require.NoError(cl.Get(context.Background(), req.NamespacedName, &updated))
+	cond := meta.FindStatusCondition(updated.Status.Conditions, conditions.TypeOVSConfigured)
+	require.NotNil(cond)
+	assert.Equal(metav1.ConditionFalse, cond.Status)
+	assert.Equal(conditions.ReasonReconcileFailed, cond.Reason)
+	// The collector is still reconciled despite the failure
+	assert.NotNil(meta.FindStatusCondition(updated.Status.Conditions, conditions.TypeCollectorReady))
+	assert.Equal(corev1.EventTypeWarning, nextEvent(t, recorder, "OVSConfigFailed"))
+
+	// Once allocated, the ConfigMap is created and recovery is notified
+	svc := corev1.Service{}
+	svcKey := types.NamespacedName{Name: constants.GoflowKubeName, Namespace: operatorNamespace}
+	require.NoError(cl.Get(context.Background(), svcKey, &svc))
+	svc.Spec.ClusterIP = "11.22.33.44"
+	svc.Spec.ClusterIPs = []string{"11.22.33.44"}
+	require.NoError(cl.Update(context.Background(), &svc))
+	_, err = r.Reconcile(context.Background(), req)
+	require.NoError(err)
+
+	cm := corev1.ConfigMap{}
+	require.NoError(cl.Get(context.Background(),
+		types.NamespacedName{Name: ovsFlowsConfigMapName, Namespace: "openshift-network-operator"}, &cm))
+	assert.Equal("11.22.33.44:2055", cm.Data["sharedTarget"])
+	require.NoError(cl.Get(context.Background(), req.NamespacedName, &updated))
+	assert.True(meta.IsStatusConditionTrue(updated.Status.Conditions, conditions.TypeOVSConfigured))
+	assert.Equal(corev1.EventTypeNormal, nextEvent(t, recorder, "OVSConfigured"))
+}
+
+func TestOVSNodeAgentMode(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(clientgoscheme.AddToScheme(scheme))
+	require.NoError(flowsv1beta1.AddToScheme(scheme))
+	require.NoError(osv1alpha1.AddToScheme(scheme))
+	fc := &flowsv1beta1.FlowCollector{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: flowsv1beta1.FlowCollectorSpec{
+			Agent: flowsv1beta1.FlowCollectorAgent{IPFIX: flowsv1beta1.FlowCollectorIPFIX{
+				OVSConfigMode:          constants.OVSConfigModeNodeAgent,
+				ClusterNetworkOperator: flowsv1beta1.ClusterNetworkOperator{Namespace: "openshift-network-operator"},
+				NodeAgent: flowsv1beta1.OVSNodeAgent{
+					Image:     "ghcr.io/ovn-org/ovn-kubernetes/ovn-kube-f:master",
+					Bridge:    "br-int",
+					OVSRunDir: "/var/run/openvswitch",
+				},
+			}},
+			Processor: flowsv1beta1.FlowCollectorProcessor{Kind: constants.DaemonSetKind, Port: 2055},
+		},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(fc).Build()
+	r := &FlowCollectorReconciler{
+		Client:         controllerstest.NewFakeApplyClient(cl),
+		Scheme:         scheme,
+		forceOwnership: true,
+		checkLoki:      func(context.Context, *flowsv1beta1.FlowCollectorLoki, string) error { return nil },
+		recorder:       record.NewFakeRecorder(100),
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster"}}
+	agentKey := types.NamespacedName{Name: "ovs-ipfix-agent", Namespace: operatorNamespace}
+	cmKey := types.NamespacedName{Name: ovsFlowsConfigMapName, Namespace: "openshift-network-operator"}
+
+	// The node agent is deployed instead of the CNO ConfigMap
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+	ds := appsv1.DaemonSet{}
+	require.NoError(cl.Get(ctx, agentKey, &ds))
+	assertNotFound(t, cl, cmKey, &corev1.ConfigMap{})
+	updated := flowsv1beta1.FlowCollector{}
+	require.NoError(cl.Get(ctx, req.NamespacedName, &updated))
+	cond := meta.FindStatusCondition(updated.Status.Conditions, conditions.TypeOVSConfigured)
+	require.NotNil(cond)
+	assert.Equal(metav1.ConditionFalse, cond.Status)
+	assert.Equal(conditions.ReasonDeploying, cond.Reason)
+
+	// Per-node status is reported from the agent pods
+	ds.Status.DesiredNumberScheduled = 1
+	require.NoError(cl.Update(ctx, &ds))
+	require.NoError(cl.Create(ctx, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "ovs-ipfix-agent-abcde", Namespace: operatorNamespace, Labels: ds.Spec.Template.Labels},
+		Spec:       corev1.PodSpec{NodeName: "node-1"},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	}))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	require.NoError(cl.Get(ctx, req.NamespacedName, &updated))
+	assert.Equal([]flowsv1beta1.OVSNodeStatus{
+		{Node: "node-1", Configured: true, Message: "IPFIX export is configured"},
+	}, updated.Status.OVSNodes)
+	assert.True(meta.IsStatusConditionTrue(updated.Status.Conditions, conditions.TypeOVSConfigured))
+
+	// Switching back to CNO mode removes the node agent
+	updated.Spec.Agent.IPFIX.OVSConfigMode = constants.OVSConfigModeCNO
+	require.NoError(cl.Update(ctx, &updated))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assertNotFound(t, cl, agentKey, &appsv1.DaemonSet{})
+	require.NoError(cl.Get(ctx, cmKey, &corev1.ConfigMap{}))
+	switched := flowsv1beta1.FlowCollector{}
+	require.NoError(cl.Get(ctx, req.NamespacedName, &switched))
+	assert.Empty(switched.Status.OVSNodes)
+}
+
+func TestSwitchToEBPFAgent(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	r, cl := newCleanupTestReconciler(t)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster"}}
+	agentKey := types.NamespacedName{Name: "netobserv-ebpf-agent", Namespace: "netobserv-test"}
+	cmKey := types.NamespacedName{Name: ovsFlowsConfigMapName, Namespace: cnoNamespace}
+
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+	require.NoError(cl.Get(ctx, cmKey, &corev1.ConfigMap{}))
+	assertNotFound(t, cl, agentKey, &appsv1.DaemonSet{})
+
+	// Switching to the eBPF agent removes the OVS configuration
+	fc := flowsv1beta1.FlowCollector{}
+	require.NoError(cl.Get(ctx, req.NamespacedName, &fc))
+	fc.Spec.Agent.Type = constants.AgentEBPF
+	fc.Spec.Agent.EBPF.Image = "quay.io/netobserv/netobserv-ebpf-agent:main"
+	require.NoError(cl.Update(ctx, &fc))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assertNotFound(t, cl, cmKey, &corev1.ConfigMap{})
+	require.NoError(cl.Get(ctx, agentKey, &appsv1.DaemonSet{}))
+	require.NoError(cl.Get(ctx, types.NamespacedName{Name: "netobserv-ebpf-agent"}, &rbacv1.ClusterRoleBinding{}))
+	switched := flowsv1beta1.FlowCollector{}
+	require.NoError(cl.Get(ctx, req.NamespacedName, &switched))
+	assert.Nil(meta.FindStatusCondition(switched.Status.Conditions, conditions.TypeOVSConfigured))
+	cond := meta.FindStatusCondition(switched.Status.Conditions, conditions.TypeEBPFAgentReady)
+	require.NotNil(cond)
+	assert.Equal(conditions.ReasonDeploying, cond.Reason)
+
+	// Switching back removes the agent and its cluster-wide permissions
+	switched.Spec.Agent.Type = constants.AgentIPFIX
+	require.NoError(cl.Update(ctx, &switched))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assertNotFound(t, cl, agentKey, &appsv1.DaemonSet{})
+	assertNotFound(t, cl, types.NamespacedName{Name: "netobserv-ebpf-agent"}, &rbacv1.ClusterRoleBinding{})
+	require.NoError(cl.Get(ctx, cmKey, &corev1.ConfigMap{}))
+	back := flowsv1beta1.FlowCollector{}
+	require.NoError(cl.Get(ctx, req.NamespacedName, &back))
+	assert.Nil(meta.FindStatusCondition(back.Status.Conditions, conditions.TypeEBPFAgentReady))
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/constants"
+	"github.com/netobserv/network-observability-operator/controllers/goflowkube"
+)
+
+func TestCredentialsRotationTriggersRollout(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "loki-credentials", Namespace: "netobserv-test"},
+		Data:       map[string][]byte{"username": []byte("netobserv"), "password": []byte("initial")},
+	}
+	r, cl := newCleanupTestReconciler(t, secret)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster"}}
+	fc := flowsv1beta1.FlowCollector{}
+	require.NoError(cl.Get(ctx, req.NamespacedName, &fc))
+	fc.Spec.Storage.Loki.Auth = flowsv1beta1.LokiAuth{
+		Type:        "BasicAuth",
+		SecretName:  "loki-credentials",
+		UsernameKey: "username",
+		PasswordKey: "password",
+	}
+	require.NoError(cl.Update(ctx, &fc))
+
+	// The FlowCollector is reconciled when the secret changes
+	assert.Equal([]reconcile.Request{req}, r.referencingFlowCollectors(secret))
+	assert.Empty(r.referencingFlowCollectors(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "netobserv-test"},
+	}))
+	assert.Empty(r.referencingFlowCollectors(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "loki-credentials", Namespace: "netobserv-test"},
+	}))
+
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+	dsKey := types.NamespacedName{Name: constants.GoflowKubeName, Namespace: "netobserv-test"}
+	ds := appsv1.DaemonSet{}
+	require.NoError(cl.Get(ctx, dsKey, &ds))
+	initialDigest := ds.Spec.Template.Annotations[goflowkube.PodConfigurationDigest]
+	assert.Equal("loki-credentials", ds.Spec.Template.Spec.Volumes[1].Secret.SecretName)
+
+	// Rotating the password rolls out goflow-kube, although its configuration doesn't change
+	secret.Data["password"] = []byte("rotated")
+	require.NoError(cl.Update(ctx, secret))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	ds = appsv1.DaemonSet{}
+	require.NoError(cl.Get(ctx, dsKey, &ds))
+	assert.NotEqual(initialDigest, ds.Spec.Template.Annotations[goflowkube.PodConfigurationDigest])
+}
+
+func TestExporterCertificateIsReferenced(t *testing.T) {
+	fc := flowsv1beta1.FlowCollector{Spec: flowsv1beta1.FlowCollectorSpec{
+		Namespace: "netobserv-test",
+		Exporters: []flowsv1beta1.FlowCollectorExporter{{
+			Type: "OpenTelemetry",
+			OpenTelemetry: flowsv1beta1.FlowCollectorOpenTelemetry{
+				Endpoint: "otel-collector:4317",
+				TLS: flowsv1beta1.ClientTLS{
+					Enable: true,
+					CACert: flowsv1beta1.CertificateReference{Type: "configmap", Name: "otel-ca", CertFile: "ca.crt"},
+				},
+			},
+		}},
+	}}
+	assert.True(t, referencesObject(&fc, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "otel-ca", Namespace: "netobserv-test"},
+	}))
+	assert.False(t, referencesObject(&fc, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "otel-ca", Namespace: "netobserv-test"},
+	}))
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/constants"
+	"github.com/netobserv/network-observability-operator/pkg/conditions"
+)
+
+func TestStandalonePluginWithoutConsole(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	r, cl := newCleanupTestReconciler(t)
+	r.consoleEnabled = false
+	r.serviceCAAvailable = false
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster"}}
+	pluginKey := types.NamespacedName{Name: constants.PluginName, Namespace: "netobserv-test"}
+
+	// Without the OpenShift console, the plugin isn't deployed by default
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+	assertNotFound(t, cl, pluginKey, &appsv1.Deployment{})
+	fc := flowsv1beta1.FlowCollector{}
+	require.NoError(cl.Get(ctx, req.NamespacedName, &fc))
+	assert.Nil(meta.FindStatusCondition(fc.Status.Conditions, conditions.TypeConsolePluginReady))
+
+	// The standalone mode deploys it behind an Ingress, with a serving certificate generated by the operator
+	fc.Spec.Console.Standalone = flowsv1beta1.FlowCollectorStandalone{
+		Enable:  true,
+		Ingress: flowsv1beta1.FlowCollectorIngress{Host: "netobserv.example.com"},
+	}
+	require.NoError(cl.Update(ctx, &fc))
+	result, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+	require.NoError(cl.Get(ctx, pluginKey, &appsv1.Deployment{}))
+	ing := networkingv1.Ingress{}
+	require.NoError(cl.Get(ctx, pluginKey, &ing))
+	assert.Equal("netobserv.example.com", ing.Spec.Rules[0].Host)
+	require.NoError(cl.Get(ctx, types.NamespacedName{Name: constants.PluginServingCertSecret, Namespace: "netobserv-test"}, &corev1.Secret{}))
+	require.NoError(cl.Get(ctx, req.NamespacedName, &fc))
+	assert.NotNil(meta.FindStatusCondition(fc.Status.Conditions, conditions.TypeConsolePluginReady))
+	require.Len(fc.Status.Certificates, 1)
+	cert := fc.Status.Certificates[0]
+	assert.Equal(constants.PluginServingCertSecret, cert.SecretName)
+	assert.Equal(constants.CertIssuerOperator, cert.Issuer)
+	require.NotNil(cert.NotAfter)
+	require.NotNil(cert.RenewAfter)
+	// It is reconciled again to renew the certificate
+	assert.InDelta(time.Until(cert.RenewAfter.Time).Seconds(), result.RequeueAfter.Seconds(), 10)
+
+	// Disabling it removes the plugin
+	fc.Spec.Console.Standalone.Enable = false
+	require.NoError(cl.Update(ctx, &fc))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assertNotFound(t, cl, pluginKey, &appsv1.Deployment{})
+	assertNotFound(t, cl, pluginKey, &networkingv1.Ingress{})
+	fc = flowsv1beta1.FlowCollector{}
+	require.NoError(cl.Get(ctx, req.NamespacedName, &fc))
+	assert.Nil(meta.FindStatusCondition(fc.Status.Conditions, conditions.TypeConsolePluginReady))
+	assert.Empty(fc.Status.Certificates)
+}
+
+func TestRequeueForRenewal(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	issued := &flowsv1beta1.CertificateStatus{SecretName: "issued", Issuer: constants.CertIssuerServiceCA}
+	generated := &flowsv1beta1.CertificateStatus{SecretName: "generated", Issuer: constants.CertIssuerOperator,
+		RenewAfter: &metav1.Time{Time: now.Add(48 * time.Hour)}}
+	assert.Equal(ctrl.Result{}, requeueForRenewal(ctrl.Result{}, now, issued))
+	assert.Equal(ctrl.Result{}, requeueForRenewal(ctrl.Result{}, now, nil))
+	assert.Equal(ctrl.Result{RequeueAfter: 48*time.Hour + time.Second}, requeueForRenewal(ctrl.Result{}, now, issued, generated))
+	// The earliest requeue is kept
+	assert.Equal(ctrl.Result{RequeueAfter: time.Minute}, requeueForRenewal(ctrl.Result{RequeueAfter: time.Minute}, now, generated))
+
+	// A renewal time already past doesn't requeue immediately, nor overrides a longer requeue with a non-positive delay
+	past := &flowsv1beta1.CertificateStatus{SecretName: "generated", Issuer: constants.CertIssuerOperator,
+		RenewAfter: &metav1.Time{Time: now.Add(-time.Hour)}}
+	assert.Equal(ctrl.Result{RequeueAfter: minRenewalRequeue}, requeueForRenewal(ctrl.Result{}, now, past))
+	assert.Equal(ctrl.Result{RequeueAfter: minRenewalRequeue}, requeueForRenewal(ctrl.Result{RequeueAfter: time.Minute}, now, past))
+	due := &flowsv1beta1.CertificateStatus{SecretName: "generated", Issuer: constants.CertIssuerOperator,
+		RenewAfter: &metav1.Time{Time: now.Add(-time.Second)}}
+	assert.Equal(ctrl.Result{RequeueAfter: minRenewalRequeue}, requeueForRenewal(ctrl.Result{}, now, due))
+}
@@ -5,35 +5,129 @@ import (
 	"fmt"
 	"hash/fnv"
 	"strconv"
+	"strings"
 
 	appsv1 "k8s.io/api/apps/v1"
 	ascv1 "k8s.io/api/autoscaling/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
 
-	flowsv1alpha1 "github.com/netobserv/network-observability-operator/api/v1alpha1"
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
 	"github.com/netobserv/network-observability-operator/controllers/constants"
+	"github.com/netobserv/network-observability-operator/pkg/helper"
+	"github.com/netobserv/network-observability-operator/pkg/volumes"
 )
 
 const configMapName = "goflow-kube-config"
+const consumerName = constants.GoflowKubeName + "-consumer"
+const consumerConfigMapName = consumerName + "-config"
 const configVolume = "config-volume"
 const configPath = "/etc/goflow-kube"
 const configFile = "config.yaml"
+const metricsServiceName = constants.GoflowKubeName + "-flow-metrics"
+const metricsPortName = "metrics"
+const monitoringServiceName = constants.GoflowKubeName + "-monitoring"
+const monitoringPortName = "monitoring"
+
+// monitoringLabel is set on the pods exposing the processor metrics, collector and Kafka consumer alike, so that
+// a single Service selects them
+const monitoringLabel = "flows.netobserv.io/monitoring"
+
+var serviceMonitorGVK = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"}
+var prometheusRuleGVK = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "PrometheusRule"}
+
+// Metrics the alerts are based on: the received flows and the Loki pushes are counted by the processor, the UDP
+// drops by the node-exporter and the pods readiness by kube-state-metrics
+const (
+	flowsReceivedMetric     = "flow_traffic_packets"
+	lokiSentMetric          = "promtail_sent_entries_total"
+	lokiDroppedMetric       = "promtail_dropped_entries_total"
+	udpDropsMetric          = "node_netstat_Udp_RcvbufErrors"
+	podReadyMetric          = "kube_pod_status_ready"
+	alertsRateInterval      = "5m"
+	prometheusRuleGroupName = "netobserv-processor"
+)
 
 // PodConfigurationDigest is an annotation name to facilitate pod restart after
 // any external configuration change
 const PodConfigurationDigest = "flows.netobserv.io/goflow-kube-config"
 
 type ConfigMap struct {
-	Listen      string        `json:"listen,omitempty"`
-	Loki        LokiConfigMap `json:"loki,omitempty"`
-	PrintInput  bool          `json:"printInput"`
-	PrintOutput bool          `json:"printOutput"`
+	Listen      string               `json:"listen,omitempty"`
+	KafkaInput  *KafkaConfigMap      `json:"kafkaInput,omitempty"`
+	KafkaOutput *KafkaConfigMap      `json:"kafkaOutput,omitempty"`
+	Loki        *LokiConfigMap       `json:"loki,omitempty"`
+	Metrics     *MetricsConfigMap    `json:"metrics,omitempty"`
+	Monitoring  *MonitoringConfigMap `json:"monitoring,omitempty"`
+	Exporters   []ExporterConfigMap  `json:"exporters,omitempty"`
+	PrintInput  bool                 `json:"printInput"`
+	PrintOutput bool                 `json:"printOutput"`
+}
+
+type ExporterConfigMap struct {
+	Type          string                  `json:"type"`
+	OpenTelemetry *OpenTelemetryConfigMap `json:"openTelemetry,omitempty"`
+}
+
+// OpenTelemetryConfigMap defines the export of the flows as OTLP logs, and optionally of their bytes and
+// packets as OTLP metrics
+type OpenTelemetryConfigMap struct {
+	Endpoint string            `json:"endpoint"`
+	Protocol string            `json:"protocol"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	TLS      *TLSConfigMap     `json:"tls,omitempty"`
+	Logs     bool              `json:"logs"`
+	Metrics  bool              `json:"metrics"`
+}
+
+type MetricsConfigMap struct {
+	Port    int32             `json:"port"`
+	Prefix  string            `json:"prefix,omitempty"`
+	Metrics []MetricConfigMap `json:"metrics"`
+}
+
+// MonitoringConfigMap defines the endpoint exposing the processor metrics
+type MonitoringConfigMap struct {
+	Port int32 `json:"port"`
+}
+
+// MetricConfigMap defines a counter incremented for each flow, by the value of ValueField if set, or by one
+type MetricConfigMap struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	ValueField string   `json:"valueField,omitempty"`
+	Labels     []string `json:"labels"`
+}
+
+// metricValueFields are the flow fields summed by the metrics that can be included. Metrics without a field
+// count the flows.
+var metricValueFields = map[string]string{
+	"bytes_total":   "Bytes",
+	"packets_total": "Packets",
+	"flows_total":   "",
+}
+
+type KafkaConfigMap struct {
+	Brokers []string      `json:"brokers"`
+	Topic   string        `json:"topic"`
+	GroupID string        `json:"groupId,omitempty"`
+	TLS     *TLSConfigMap `json:"tls,omitempty"`
+}
+
+type TLSConfigMap struct {
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
+	CACertPath         string `json:"caCertPath,omitempty"`
+	UserCertPath       string `json:"userCertPath,omitempty"`
+	UserKeyPath        string `json:"userKeyPath,omitempty"`
 }
 
 type LokiConfigMap struct {
 	URL          string            `json:"url,omitempty"`
+	TenantID     string            `json:"tenantID,omitempty"`
 	BatchWait    metav1.Duration   `json:"batchWait,omitempty"`
 	BatchSize    int64             `json:"batchSize,omitempty"`
 	Timeout      metav1.Duration   `json:"timeout,omitempty"`
@@ -42,6 +136,51 @@ type LokiConfigMap struct {
 	MaxRetries   int32             `json:"maxRetries,omitempty"`
 	Labels       []string          `json:"labels,omitempty"`
 	StaticLabels map[string]string `json:"staticLabels,omitempty"`
+
+	TLS             *TLSConfigMap       `json:"tls,omitempty"`
+	BearerTokenPath string              `json:"bearerTokenPath,omitempty"`
+	BasicAuth       *BasicAuthConfigMap `json:"basicAuth,omitempty"`
+}
+
+type BasicAuthConfigMap struct {
+	UsernamePath string `json:"usernamePath"`
+	PasswordPath string `json:"passwordPath"`
+}
+
+// listener is a flow listener of the collector
+type listener struct {
+	name     string
+	protocol string
+	port     int32
+}
+
+// buildListeners returns the flow listeners of the collector: the main one, receiving the flows of the agent,
+// first, then the additional ones
+func buildListeners(desired *flowsv1beta1.FlowCollectorProcessor) []listener {
+	listeners := []listener{{name: constants.GoflowKubeName, protocol: "netflow", port: desired.Port}}
+	for _, l := range desired.Listeners {
+		listeners = append(listeners, listener{
+			name:     fmt.Sprintf("%s-%d", l.Protocol, l.Port),
+			protocol: l.Protocol,
+			port:     l.Port,
+		})
+	}
+	return listeners
+}
+
+// buildListen returns the listen addresses of the collector, e.g. "netflow://:2055,sflow://:6343"
+func buildListen(desired *flowsv1beta1.FlowCollectorProcessor) string {
+	var addresses []string
+	for _, l := range buildListeners(desired) {
+		addresses = append(addresses, fmt.Sprintf("%s://:%d", l.protocol, l.port))
+	}
+	return strings.Join(addresses, ",")
+}
+
+func buildMetricsServiceLabels() map[string]string {
+	return map[string]string{
+		"app": metricsServiceName,
+	}
 }
 
 func buildLabels() map[string]string {
@@ -50,23 +189,50 @@ func buildLabels() map[string]string {
 	}
 }
 
-func buildDeployment(desired *flowsv1alpha1.FlowCollectorGoflowKube, ns, configDigest string) *appsv1.Deployment {
-	return &appsv1.Deployment{
+func buildConsumerLabels() map[string]string {
+	return map[string]string{
+		"app": consumerName,
+	}
+}
+
+func buildMonitoringServiceLabels() map[string]string {
+	return map[string]string{
+		"app": monitoringServiceName,
+	}
+}
+
+// buildPodLabels returns the provided pod labels, with the label selected by the monitoring Service when the
+// processor metrics are exposed
+func buildPodLabels(desired *flowsv1beta1.FlowCollectorProcessor, labels map[string]string) map[string]string {
+	if desired.Monitoring.Enable {
+		labels[monitoringLabel] = constants.GoflowKubeName
+	}
+	return labels
+}
+
+func buildDeployment(desired *flowsv1beta1.FlowCollectorProcessor, ns, configDigest string, vols *volumes.Builder,
+	withMetrics bool) *appsv1.Deployment {
+	depl := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      constants.GoflowKubeName,
 			Namespace: ns,
 		},
 		Spec: appsv1.DeploymentSpec{
-			Replicas: &desired.Replicas,
 			Selector: &metav1.LabelSelector{
 				MatchLabels: buildLabels(),
 			},
-			Template: buildPodTemplate(desired, configDigest),
+			Template: buildPodTemplate(desired, configDigest, vols, withMetrics),
 		},
 	}
+	// The replicas are left to the autoscaler when there is one
+	if desired.HPA == nil {
+		depl.Spec.Replicas = &desired.Replicas
+	}
+	return depl
 }
 
-func buildDaemonSet(desired *flowsv1alpha1.FlowCollectorGoflowKube, ns, configDigest string) *appsv1.DaemonSet {
+func buildDaemonSet(desired *flowsv1beta1.FlowCollectorProcessor, ns, configDigest string, vols *volumes.Builder,
+	withMetrics bool) *appsv1.DaemonSet {
 	return &appsv1.DaemonSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      constants.GoflowKubeName,
@@ -76,91 +242,284 @@ func buildDaemonSet(desired *flowsv1alpha1.FlowCollectorGoflowKube, ns, configDi
 			Selector: &metav1.LabelSelector{
 				MatchLabels: buildLabels(),
 			},
-			Template: buildPodTemplate(desired, configDigest),
+			Template: buildPodTemplate(desired, configDigest, vols, withMetrics),
+		},
+	}
+}
+
+// buildConsumerDeployment returns the Deployment that reads the flows from Kafka, enriches them and sends them
+// to Loki. It runs the same image as the collector, with a different configuration.
+func buildConsumerDeployment(desired *flowsv1beta1.FlowCollectorProcessor, desiredKafka *flowsv1beta1.FlowCollectorKafka,
+	ns, configDigest string, vols *volumes.Builder) *appsv1.Deployment {
+	replicas := desiredKafka.ConsumerReplicas
+	template := buildPodTemplateWithConfig(desired, consumerConfigMapName, configDigest, vols, desired.Metrics.Enable)
+	template.Labels = buildPodLabels(desired, buildConsumerLabels())
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      consumerName,
+			Namespace: ns,
+			Labels:    buildConsumerLabels(),
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: buildConsumerLabels(),
+			},
+			Template: template,
 		},
 	}
 }
 
-func buildPodTemplate(desired *flowsv1alpha1.FlowCollectorGoflowKube, configDigest string) corev1.PodTemplateSpec {
+func buildPodTemplate(desired *flowsv1beta1.FlowCollectorProcessor, configDigest string, vols *volumes.Builder,
+	withMetrics bool) corev1.PodTemplateSpec {
+	template := buildPodTemplateWithConfig(desired, configMapName, configDigest, vols, withMetrics)
+	if desired.Kind == constants.DaemonSetKind {
+		var ports []corev1.ContainerPort
+		for _, l := range buildListeners(desired) {
+			ports = append(ports, corev1.ContainerPort{
+				Name:          l.name,
+				HostPort:      l.port,
+				ContainerPort: l.port,
+				Protocol:      corev1.ProtocolUDP,
+			})
+		}
+		template.Spec.Containers[0].Ports = append(ports, template.Spec.Containers[0].Ports...)
+		applyScheduling(&template.Spec, desired, true)
+	}
+	return template
+}
+
+// applyScheduling sets the configured scheduling constraints of the pods
+func applyScheduling(podSpec *corev1.PodSpec, desired *flowsv1beta1.FlowCollectorProcessor, daemonSet bool) {
+	helper.ApplyScheduling(podSpec, &desired.Scheduling, daemonSet)
+	if daemonSet && len(podSpec.Tolerations) == 0 {
+		// This allows deploying an instance in the master node, the same technique used in the
+		// companion ovnkube-node daemonset definition
+		podSpec.Tolerations = []corev1.Toleration{{Operator: corev1.TolerationOpExists}}
+	}
+}
+
+// buildPodTemplateWithConfig returns the goflow-kube pod template, reading the provided configmap. The metrics
+// port is exposed when the pods compute the flow metrics, and the monitoring port when the monitoring is enabled.
+func buildPodTemplateWithConfig(desired *flowsv1beta1.FlowCollectorProcessor, cmName, configDigest string,
+	vols *volumes.Builder, withMetrics bool) corev1.PodTemplateSpec {
 	cmd := buildMainCommand(desired)
+	podVolumes := []corev1.Volume{{
+		Name: configVolume,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: cmName,
+				},
+			},
+		},
+	}}
+	mounts := []corev1.VolumeMount{{
+		MountPath: configPath,
+		Name:      configVolume,
+	}}
+	podVolumes = append(podVolumes, vols.GetVolumes()...)
+	mounts = append(mounts, vols.GetMounts()...)
 	var ports []corev1.ContainerPort
-	var tolerations []corev1.Toleration
-	if desired.Kind == constants.DaemonSetKind {
+	if withMetrics {
 		ports = []corev1.ContainerPort{{
-			Name:          constants.GoflowKubeName,
-			HostPort:      desired.Port,
-			ContainerPort: desired.Port,
-			Protocol:      corev1.ProtocolUDP,
+			Name:          metricsPortName,
+			ContainerPort: desired.Metrics.Port,
+			Protocol:      corev1.ProtocolTCP,
 		}}
-		// This allows deploying an instance in the master node, the same technique used in the
-		// companion ovnkube-node daemonset definition
-		tolerations = []corev1.Toleration{{Operator: corev1.TolerationOpExists}}
+	}
+	if desired.Monitoring.Enable {
+		ports = append(ports, corev1.ContainerPort{
+			Name:          monitoringPortName,
+			ContainerPort: desired.Monitoring.Port,
+			Protocol:      corev1.ProtocolTCP,
+		})
 	}
 
-	return corev1.PodTemplateSpec{
+	template := corev1.PodTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
-			Labels: buildLabels(),
+			Labels: buildPodLabels(desired, buildLabels()),
 			Annotations: map[string]string{
 				PodConfigurationDigest: configDigest,
 			},
 		},
 		Spec: corev1.PodSpec{
-			Tolerations: tolerations,
-			Volumes: []corev1.Volume{{
-				Name: configVolume,
-				VolumeSource: corev1.VolumeSource{
-					ConfigMap: &corev1.ConfigMapVolumeSource{
-						LocalObjectReference: corev1.LocalObjectReference{
-							Name: configMapName,
-						},
-					},
-				},
-			}},
+			Volumes: podVolumes,
 			Containers: []corev1.Container{{
 				Name:            constants.GoflowKubeName,
 				Image:           desired.Image,
 				ImagePullPolicy: corev1.PullPolicy(desired.ImagePullPolicy),
 				Command:         []string{"/bin/sh", "-c", cmd},
 				Resources:       *desired.Resources.DeepCopy(),
-				VolumeMounts: []corev1.VolumeMount{{
-					MountPath: configPath,
-					Name:      configVolume,
-				}},
-				Ports: ports,
+				VolumeMounts:    mounts,
+				Ports:           ports,
 			}},
 			ServiceAccountName: constants.GoflowKubeName,
 		},
 	}
+	applyScheduling(&template.Spec, desired, false)
+	return template
 }
 
-func buildMainCommand(desired *flowsv1alpha1.FlowCollectorGoflowKube) string {
+func buildMainCommand(desired *flowsv1beta1.FlowCollectorProcessor) string {
 	return fmt.Sprintf(`/goflow-kube -loglevel "%s" -config %s/%s`, desired.LogLevel, configPath, configFile)
 }
 
 // returns a configmap with a digest of its configuration contents, which will be used to
-// detect any configuration change
-func buildConfigMap(desiredGoflowKube *flowsv1alpha1.FlowCollectorGoflowKube,
-	desiredLoki *flowsv1alpha1.FlowCollectorLoki, ns string) (*corev1.ConfigMap, string) {
+// detect any configuration change. When Kafka is enabled, the collector produces the flows to
+// Kafka instead of sending them to Loki and to the exporters, or computing the metrics. The volumes needed by the configuration
+// are added to vols.
+func buildConfigMap(desiredGoflowKube *flowsv1beta1.FlowCollectorProcessor, desiredKafka *flowsv1beta1.FlowCollectorKafka,
+	desiredLoki *flowsv1beta1.FlowCollectorLoki, desiredExporters []flowsv1beta1.FlowCollectorExporter, ns string,
+	vols *volumes.Builder) (*corev1.ConfigMap, string) {
 
-	configStr := `{}`
 	config := &ConfigMap{
-		Listen:      fmt.Sprintf("netflow://:%d", desiredGoflowKube.Port),
-		Loki:        LokiConfigMap{},
+		Listen:      buildListen(desiredGoflowKube),
 		PrintInput:  false,
 		PrintOutput: desiredGoflowKube.PrintOutput,
 	}
+	if desiredKafka != nil && desiredKafka.Enable {
+		config.KafkaOutput = buildKafkaConfig(desiredKafka, vols)
+	} else {
+		config.Loki = buildLokiConfig(desiredLoki, vols)
+		config.Metrics = buildMetricsConfig(&desiredGoflowKube.Metrics)
+		config.Exporters = buildExportersConfig(desiredExporters, vols)
+	}
+	config.Monitoring = buildMonitoringConfig(&desiredGoflowKube.Monitoring)
+	return buildConfigMapWithDigest(config, configMapName, buildLabels(), ns, vols)
+}
+
+// buildConsumerConfigMap returns the configmap of the Kafka consumer, with a digest of its configuration
+// contents. The volumes needed by the configuration are added to vols.
+func buildConsumerConfigMap(desiredGoflowKube *flowsv1beta1.FlowCollectorProcessor, desiredKafka *flowsv1beta1.FlowCollectorKafka,
+	desiredLoki *flowsv1beta1.FlowCollectorLoki, desiredExporters []flowsv1beta1.FlowCollectorExporter, ns string,
+	vols *volumes.Builder) (*corev1.ConfigMap, string) {
+
+	config := &ConfigMap{
+		KafkaInput:  buildKafkaConfig(desiredKafka, vols),
+		Loki:        buildLokiConfig(desiredLoki, vols),
+		Metrics:     buildMetricsConfig(&desiredGoflowKube.Metrics),
+		Exporters:   buildExportersConfig(desiredExporters, vols),
+		Monitoring:  buildMonitoringConfig(&desiredGoflowKube.Monitoring),
+		PrintInput:  false,
+		PrintOutput: desiredGoflowKube.PrintOutput,
+	}
+	config.KafkaInput.GroupID = consumerName
+	return buildConfigMapWithDigest(config, consumerConfigMapName, buildConsumerLabels(), ns, vols)
+}
+
+// buildLokiConfig returns the Loki client configuration, or nil when the flows aren't stored in Loki
+func buildLokiConfig(desiredLoki *flowsv1beta1.FlowCollectorLoki, vols *volumes.Builder) *LokiConfigMap {
+	if desiredLoki != nil && !helper.LokiEnabled(desiredLoki) {
+		return nil
+	}
+	config := &LokiConfigMap{}
 	if desiredLoki != nil {
-		config.Loki.BatchSize = desiredLoki.BatchSize
-		config.Loki.BatchWait = desiredLoki.BatchWait
-		config.Loki.MaxBackoff = desiredLoki.MaxBackoff
-		config.Loki.MaxRetries = desiredLoki.MaxRetries
-		config.Loki.MinBackoff = desiredLoki.MinBackoff
-		config.Loki.StaticLabels = desiredLoki.StaticLabels
-		config.Loki.Timeout = desiredLoki.Timeout
-		config.Loki.URL = desiredLoki.URL
+		files := vols.AddLokiClientFiles(desiredLoki)
+		if files.TLS {
+			config.TLS = &TLSConfigMap{
+				InsecureSkipVerify: files.InsecureSkipVerify,
+				CACertPath:         files.CACertPath,
+				UserCertPath:       files.UserCertPath,
+				UserKeyPath:        files.UserKeyPath,
+			}
+		}
+		config.BearerTokenPath = files.TokenPath
+		if files.UsernamePath != "" {
+			config.BasicAuth = &BasicAuthConfigMap{UsernamePath: files.UsernamePath, PasswordPath: files.PasswordPath}
+		}
+		config.BatchSize = desiredLoki.BatchSize
+		config.BatchWait = desiredLoki.BatchWait
+		config.MaxBackoff = desiredLoki.MaxBackoff
+		config.MaxRetries = desiredLoki.MaxRetries
+		config.MinBackoff = desiredLoki.MinBackoff
+		config.StaticLabels = desiredLoki.StaticLabels
+		config.Timeout = desiredLoki.Timeout
+		config.URL = desiredLoki.URL
+		config.TenantID = desiredLoki.TenantID
+		config.Labels = desiredLoki.Labels
+	}
+	return config
+}
+
+// buildMetricsConfig returns the definitions of the included metrics, or nil when the metrics are disabled
+func buildMetricsConfig(desired *flowsv1beta1.FlowCollectorMetrics) *MetricsConfigMap {
+	if !desired.Enable {
+		return nil
+	}
+	config := &MetricsConfigMap{
+		Port:   desired.Port,
+		Prefix: desired.Prefix,
+	}
+	for _, name := range desired.IncludeList {
+		config.Metrics = append(config.Metrics, MetricConfigMap{
+			Name:       name,
+			Type:       "counter",
+			ValueField: metricValueFields[name],
+			Labels:     desired.Labels,
+		})
 	}
-	config.Loki.Labels = []string{"SrcNamespace", "SrcWorkload", "DstNamespace", "DstWorkload"}
+	return config
+}
 
+// buildMonitoringConfig returns the endpoint of the processor metrics, or nil when the monitoring is disabled
+func buildMonitoringConfig(desired *flowsv1beta1.FlowCollectorMonitoring) *MonitoringConfigMap {
+	if !desired.Enable {
+		return nil
+	}
+	return &MonitoringConfigMap{Port: desired.Port}
+}
+
+func buildKafkaConfig(desiredKafka *flowsv1beta1.FlowCollectorKafka, vols *volumes.Builder) *KafkaConfigMap {
+	return &KafkaConfigMap{
+		Brokers: desiredKafka.Brokers,
+		Topic:   desiredKafka.Topic,
+		TLS:     buildTLSConfig(&desiredKafka.TLS, "kafka", vols),
+	}
+}
+
+// buildExportersConfig returns the configuration of the exporters. The certificates of each exporter are
+// mounted in their own volumes.
+func buildExportersConfig(desiredExporters []flowsv1beta1.FlowCollectorExporter, vols *volumes.Builder) []ExporterConfigMap {
+	var configs []ExporterConfigMap
+	for i := range desiredExporters {
+		exporter := &desiredExporters[i]
+		config := ExporterConfigMap{Type: exporter.Type}
+		if exporter.Type == constants.ExporterOpenTelemetry {
+			otlp := &exporter.OpenTelemetry
+			config.OpenTelemetry = &OpenTelemetryConfigMap{
+				Endpoint: otlp.Endpoint,
+				Protocol: otlp.Protocol,
+				Headers:  otlp.Headers,
+				TLS:      buildTLSConfig(&otlp.TLS, fmt.Sprintf("exporter-%d", i), vols),
+				Logs:     true,
+				Metrics:  otlp.Metrics,
+			}
+		}
+		configs = append(configs, config)
+	}
+	return configs
+}
+
+// buildTLSConfig returns the client TLS configuration, or nil when TLS is disabled. The certificates are
+// mounted in volumes named after the provided prefix.
+func buildTLSConfig(tls *flowsv1beta1.ClientTLS, volumePrefix string, vols *volumes.Builder) *TLSConfigMap {
+	if !tls.Enable {
+		return nil
+	}
+	config := &TLSConfigMap{InsecureSkipVerify: tls.InsecureSkipVerify}
+	if !tls.InsecureSkipVerify {
+		config.CACertPath = vols.AddCACertificate(&tls.CACert, volumePrefix+"-ca")
+	}
+	config.UserCertPath, config.UserKeyPath = vols.AddCertificate(&tls.UserCert, volumePrefix+"-user")
+	return config
+}
+
+// buildConfigMapWithDigest returns the configmap holding the provided configuration, and a digest of the
+// configuration and of the volumes it refers to
+func buildConfigMapWithDigest(config *ConfigMap, name string, labels map[string]string, ns string,
+	vols *volumes.Builder) (*corev1.ConfigMap, string) {
+	configStr := `{}`
 	b, err := json.Marshal(config)
 	if err == nil {
 		configStr = string(b)
@@ -168,9 +527,9 @@ func buildConfigMap(desiredGoflowKube *flowsv1alpha1.FlowCollectorGoflowKube,
 
 	configMap := corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      configMapName,
+			Name:      name,
 			Namespace: ns,
-			Labels:    buildLabels(),
+			Labels:    labels,
 		},
 		Data: map[string]string{
 			configFile: configStr,
@@ -178,37 +537,182 @@ func buildConfigMap(desiredGoflowKube *flowsv1alpha1.FlowCollectorGoflowKube,
 	}
 	hasher := fnv.New64a()
 	_, _ = hasher.Write([]byte(configStr))
+	if volumes := vols.GetVolumes(); len(volumes) > 0 {
+		// Certificates references are part of the configuration, but not of the configmap
+		b, _ := json.Marshal(volumes)
+		_, _ = hasher.Write(b)
+	}
 	digest := strconv.FormatUint(hasher.Sum64(), 36)
 	return &configMap, digest
 }
 
-func buildService(old *corev1.Service, desired *flowsv1alpha1.FlowCollectorGoflowKube, ns string) *corev1.Service {
-	if old == nil {
-		return &corev1.Service{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      constants.GoflowKubeName,
-				Namespace: ns,
-				Labels:    buildLabels(),
+func buildService(desired *flowsv1beta1.FlowCollectorProcessor, ns string) *corev1.Service {
+	var ports []corev1.ServicePort
+	listeners := buildListeners(desired)
+	for _, l := range listeners {
+		port := corev1.ServicePort{
+			Port:     l.port,
+			Protocol: corev1.ProtocolUDP,
+		}
+		// Names are only required when there are several ports
+		if len(listeners) > 1 {
+			port.Name = l.name
+		}
+		ports = append(ports, port)
+	}
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      constants.GoflowKubeName,
+			Namespace: ns,
+			Labels:    buildLabels(),
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: buildLabels(),
+			Ports:    ports,
+		},
+	}
+}
+
+// buildMetricsService returns the Service exposing the flow metrics of the pods that compute them: the Kafka
+// consumer when Kafka is enabled, the collector otherwise
+func buildMetricsService(desired *flowsv1beta1.FlowCollectorProcessor,
+	desiredKafka *flowsv1beta1.FlowCollectorKafka, ns string) *corev1.Service {
+	selector := buildLabels()
+	if desiredKafka != nil && desiredKafka.Enable {
+		selector = buildConsumerLabels()
+	}
+	ports := []corev1.ServicePort{{
+		Name:       metricsPortName,
+		Port:       desired.Metrics.Port,
+		Protocol:   corev1.ProtocolTCP,
+		TargetPort: intstr.FromString(metricsPortName),
+	}}
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      metricsServiceName,
+			Namespace: ns,
+			Labels:    buildMetricsServiceLabels(),
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: selector,
+			Ports:    ports,
+		},
+	}
+}
+
+// buildMonitoringService returns the Service exposing the processor metrics of the collector and Kafka consumer
+// pods
+func buildMonitoringService(desired *flowsv1beta1.FlowCollectorProcessor, ns string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      monitoringServiceName,
+			Namespace: ns,
+			Labels:    buildMonitoringServiceLabels(),
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{monitoringLabel: constants.GoflowKubeName},
+			Ports: []corev1.ServicePort{{
+				Name:       monitoringPortName,
+				Port:       desired.Monitoring.Port,
+				Protocol:   corev1.ProtocolTCP,
+				TargetPort: intstr.FromString(monitoringPortName),
+			}},
+		},
+	}
+}
+
+// buildServiceMonitor returns the ServiceMonitor scraping the port of the provided Service, whose app label is
+// its name. It is built as unstructured, so that the Prometheus operator API isn't a dependency.
+func buildServiceMonitor(svcName, portName, ns string) *unstructured.Unstructured {
+	sm := unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"endpoints": []interface{}{
+				map[string]interface{}{"port": portName, "scheme": "http"},
 			},
-			Spec: corev1.ServiceSpec{
-				Selector: buildLabels(),
-				Ports: []corev1.ServicePort{{
-					Port:     desired.Port,
-					Protocol: corev1.ProtocolUDP,
-				}},
+			"namespaceSelector": map[string]interface{}{
+				"matchNames": []interface{}{ns},
 			},
-		}
+			"selector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{"app": svcName},
+			},
+		},
+	}}
+	sm.SetGroupVersionKind(serviceMonitorGVK)
+	sm.SetName(svcName)
+	sm.SetNamespace(ns)
+	sm.SetLabels(map[string]string{"app": svcName})
+	return &sm
+}
+
+// buildPrometheusRule returns the alerts on the processor health, with the configured thresholds. The Loki alert
+// is only defined when the flows are stored in Loki. It is built as unstructured, so that the Prometheus operator
+// API isn't a dependency.
+func buildPrometheusRule(desired *flowsv1beta1.FlowCollectorProcessor, desiredLoki *flowsv1beta1.FlowCollectorLoki,
+	ns string) *unstructured.Unstructured {
+	alerts := &desired.Monitoring.Alerts
+	selector := fmt.Sprintf(`job="%s",namespace="%s"`, monitoringServiceName, ns)
+	rules := []interface{}{
+		alertRule("NetObservNoFlows", "critical", promDuration(alerts.NoFlowsFor),
+			fmt.Sprintf(`(sum(rate(%s{%s}[%s])) or vector(0)) == 0`, flowsReceivedMetric, selector, alertsRateInterval),
+			"NetObserv collector receives no flows",
+			fmt.Sprintf("The collector pods in the %s namespace haven't received any flow for %s.",
+				ns, alerts.NoFlowsFor.Duration)),
+	}
+	if desiredLoki == nil || helper.LokiEnabled(desiredLoki) {
+		dropped := fmt.Sprintf(`sum(rate(%s{%s}[%s]))`, lokiDroppedMetric, selector, alertsRateInterval)
+		sent := fmt.Sprintf(`sum(rate(%s{%s}[%s]))`, lokiSentMetric, selector, alertsRateInterval)
+		rules = append(rules, alertRule("NetObservLokiErrors", "warning", alertsRateInterval,
+			fmt.Sprintf(`%s / (%s + %s) * 100 > %d`, dropped, sent, dropped, alerts.LokiErrorsPercent),
+			"NetObserv flows fail to be pushed to Loki",
+			fmt.Sprintf("More than %d%% of the flows in the %s namespace fail to be pushed to Loki, and are lost.",
+				alerts.LokiErrorsPercent, ns)))
 	}
-	// In case we're updating an existing service, we need to build from the old one to keep immutable fields such as clusterIP
-	newService := old.DeepCopy()
-	newService.Spec.Ports = []corev1.ServicePort{{
-		Port:     desired.Port,
-		Protocol: corev1.ProtocolUDP,
+	rules = append(rules,
+		alertRule("NetObservUDPDrops", "warning", alertsRateInterval,
+			fmt.Sprintf(`sum by (instance) (rate(%s[%s])) > %d`, udpDropsMetric, alertsRateInterval, alerts.UDPDropsPerSecond),
+			"UDP datagrams are dropped on a node",
+			fmt.Sprintf("More than %d UDP datagrams per second are dropped on {{ $labels.instance }} because the "+
+				"receive buffers are full: the flows sent to the collector may be lost.", alerts.UDPDropsPerSecond)),
+		alertRule("NetObservCollectorNotReady", "warning", promDuration(alerts.NotReadyFor),
+			fmt.Sprintf(`sum by (pod) (%s{namespace="%s",pod=~"%s-.*",condition="false"}) > 0`,
+				podReadyMetric, ns, constants.GoflowKubeName),
+			"NetObserv processor pod isn't ready",
+			fmt.Sprintf("The pod {{ $labels.pod }} in the %s namespace hasn't been ready for %s.",
+				ns, alerts.NotReadyFor.Duration)),
+	)
+	rule := unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"groups": []interface{}{
+				map[string]interface{}{"name": prometheusRuleGroupName, "rules": rules},
+			},
+		},
 	}}
-	return newService
+	rule.SetGroupVersionKind(prometheusRuleGVK)
+	rule.SetName(constants.GoflowKubeName)
+	rule.SetNamespace(ns)
+	rule.SetLabels(buildLabels())
+	return &rule
+}
+
+func alertRule(name, severity, duration, expr, summary, description string) map[string]interface{} {
+	return map[string]interface{}{
+		"alert":  name,
+		"expr":   expr,
+		"for":    duration,
+		"labels": map[string]interface{}{"severity": severity},
+		"annotations": map[string]interface{}{
+			"summary":     summary,
+			"description": description,
+		},
+	}
+}
+
+// promDuration formats a duration for Prometheus, which doesn't parse fractional values such as 1.5s
+func promDuration(d metav1.Duration) string {
+	return strconv.FormatInt(int64(d.Seconds()), 10) + "s"
 }
 
-func buildAutoScaler(desired *flowsv1alpha1.FlowCollectorGoflowKube, ns string) *ascv1.HorizontalPodAutoscaler {
+func buildAutoScaler(desired *flowsv1beta1.FlowCollectorProcessor, ns string) *ascv1.HorizontalPodAutoscaler {
 	return &ascv1.HorizontalPodAutoscaler{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      constants.GoflowKubeName,
@@ -3,20 +3,32 @@ package goflowkube
 import (
 	"context"
 	"fmt"
-	"reflect"
 
 	appsv1 "k8s.io/api/apps/v1"
 	ascv1 "k8s.io/api/autoscaling/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 
-	flowsv1alpha1 "github.com/netobserv/network-observability-operator/api/v1alpha1"
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
 	"github.com/netobserv/network-observability-operator/controllers/constants"
 	"github.com/netobserv/network-observability-operator/controllers/reconcilers"
+	"github.com/netobserv/network-observability-operator/pkg/volumes"
 )
 
+//+kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors;prometheusrules,verbs=get;create;update;patch;delete
+
 // Type alias
-type goflowKubeSpec = flowsv1alpha1.FlowCollectorGoflowKube
-type lokiSpec = flowsv1alpha1.FlowCollectorLoki
+type goflowKubeSpec = flowsv1beta1.FlowCollectorProcessor
+type kafkaSpec = flowsv1beta1.FlowCollectorKafka
+type lokiSpec = flowsv1beta1.FlowCollectorLoki
+type exporterSpec = flowsv1beta1.FlowCollectorExporter
 
 // GFKReconciler reconciles the current goflow-kube state with the desired configuration
 type GFKReconciler struct {
@@ -32,6 +44,12 @@ type ownedObjects struct {
 	hpa            *ascv1.HorizontalPodAutoscaler
 	serviceAccount *corev1.ServiceAccount
 	configMap      *corev1.ConfigMap
+	metricsService *corev1.Service
+	// monitoringService exposes the processor metrics, of the Kafka consumer too
+	monitoringService *corev1.Service
+	// Kafka consumer
+	consumerDeployment *appsv1.Deployment
+	consumerConfigMap  *corev1.ConfigMap
 }
 
 func NewReconciler(cl reconcilers.ClientHelper, ns, prevNS string) GFKReconciler {
@@ -42,6 +60,12 @@ func NewReconciler(cl reconcilers.ClientHelper, ns, prevNS string) GFKReconciler
 		hpa:            &ascv1.HorizontalPodAutoscaler{},
 		serviceAccount: &corev1.ServiceAccount{},
 		configMap:      &corev1.ConfigMap{},
+		metricsService: &corev1.Service{},
+
+		monitoringService: &corev1.Service{},
+
+		consumerDeployment: &appsv1.Deployment{},
+		consumerConfigMap:  &corev1.ConfigMap{},
 	}
 	nobjMngr := reconcilers.NewNamespacedObjectManager(cl, ns, prevNS)
 	nobjMngr.AddManagedObject(constants.GoflowKubeName, owned.deployment)
@@ -50,6 +74,10 @@ func NewReconciler(cl reconcilers.ClientHelper, ns, prevNS string) GFKReconciler
 	nobjMngr.AddManagedObject(constants.GoflowKubeName, owned.hpa)
 	nobjMngr.AddManagedObject(constants.GoflowKubeName, owned.serviceAccount)
 	nobjMngr.AddManagedObject(configMapName, owned.configMap)
+	nobjMngr.AddManagedObject(metricsServiceName, owned.metricsService)
+	nobjMngr.AddManagedObject(monitoringServiceName, owned.monitoringService)
+	nobjMngr.AddManagedObject(consumerName, owned.consumerDeployment)
+	nobjMngr.AddManagedObject(consumerConfigMapName, owned.consumerConfigMap)
 
 	return GFKReconciler{ClientHelper: cl, nobjMngr: nobjMngr, owned: owned}
 }
@@ -63,60 +91,282 @@ func (r *GFKReconciler) InitStaticResources(ctx context.Context) error {
 func (r *GFKReconciler) PrepareNamespaceChange(ctx context.Context) error {
 	// Switching namespace => delete everything in the previous namespace
 	r.nobjMngr.CleanupNamespace(ctx)
+	if err := r.deletePrometheusObjects(ctx, r.nobjMngr.PreviousNamespace); err != nil {
+		return err
+	}
 	return r.createPermissions(ctx, false)
 }
 
+// CleanupClusterResources deletes the cluster-scoped resources, which are not reliably garbage collected
+// through their owner references
+func (r *GFKReconciler) CleanupClusterResources(ctx context.Context) error {
+	meta := metav1.ObjectMeta{Name: constants.GoflowKubeName}
+	if err := r.DeleteIfExists(ctx, &rbacv1.ClusterRoleBinding{ObjectMeta: meta}); err != nil {
+		return err
+	}
+	return r.DeleteIfExists(ctx, &rbacv1.ClusterRole{ObjectMeta: meta})
+}
+
+// Cleanup deletes goflow-kube, including its Kafka consumer, and its permissions
+func (r *GFKReconciler) Cleanup(ctx context.Context) error {
+	if err := r.nobjMngr.FetchAll(ctx); err != nil {
+		return err
+	}
+	r.nobjMngr.DeleteAll(ctx)
+	if err := r.deletePrometheusObjects(ctx, r.nobjMngr.Namespace); err != nil {
+		return err
+	}
+	return r.CleanupClusterResources(ctx)
+}
+
+// FetchAll retrieves the current owned objects, so that CheckReadiness reports their state when they are not
+// reconciled
+func (r *GFKReconciler) FetchAll(ctx context.Context) error {
+	return r.nobjMngr.FetchAll(ctx)
+}
+
 // Reconcile is the reconciler entry point to reconcile the current goflow-kube state with the desired configuration
-func (r *GFKReconciler) Reconcile(ctx context.Context, desiredGoflowKube *goflowKubeSpec, desiredLoki *lokiSpec) error {
+func (r *GFKReconciler) Reconcile(ctx context.Context, desiredGoflowKube *goflowKubeSpec, desiredKafka *kafkaSpec,
+	desiredLoki *lokiSpec, desiredExporters []exporterSpec) error {
 	// Retrieve current owned objects
 	err := r.nobjMngr.FetchAll(ctx)
 	if err != nil {
 		return err
 	}
-	newCM, configDigest := buildConfigMap(desiredGoflowKube, desiredLoki, r.nobjMngr.Namespace)
-	if !r.nobjMngr.Exists(r.owned.configMap) {
-		if err := r.CreateOwned(ctx, newCM); err != nil {
+	// Permissions are restored after a removal of the collector
+	if !r.nobjMngr.Exists(r.owned.serviceAccount) {
+		if err := r.createPermissions(ctx, true); err != nil {
 			return err
 		}
-	} else if !reflect.DeepEqual(newCM, r.owned.configMap.Data) {
-		if err := r.UpdateOwned(ctx, r.owned.configMap, newCM); err != nil {
+	}
+	vols := volumes.Builder{}
+	newCM, configDigest := buildConfigMap(desiredGoflowKube, desiredKafka, desiredLoki, desiredExporters, r.nobjMngr.Namespace, &vols)
+	if err := r.reconcileConfigMap(ctx, r.owned.configMap, newCM); err != nil {
+		return err
+	}
+	if configDigest, err = r.withVolumesContentDigest(ctx, configDigest, &vols); err != nil {
+		return err
+	}
+
+	// With Kafka, the metrics are computed by the consumer
+	withMetrics := desiredGoflowKube.Metrics.Enable && (desiredKafka == nil || !desiredKafka.Enable)
+	switch desiredGoflowKube.Kind {
+	case constants.DeploymentKind:
+		err = r.reconcileAsDeployment(ctx, desiredGoflowKube, configDigest, &vols, withMetrics)
+	case constants.DaemonSetKind:
+		err = r.reconcileAsDaemonSet(ctx, desiredGoflowKube, configDigest, &vols, withMetrics)
+	default:
+		err = fmt.Errorf("could not reconcile collector, invalid kind: %s", desiredGoflowKube.Kind)
+	}
+	if err != nil {
+		return err
+	}
+	if err := r.reconcileConsumer(ctx, desiredGoflowKube, desiredKafka, desiredLoki, desiredExporters); err != nil {
+		return err
+	}
+	if err := r.reconcileMetrics(ctx, desiredGoflowKube, desiredKafka); err != nil {
+		return err
+	}
+	return r.reconcileMonitoring(ctx, desiredGoflowKube, desiredLoki)
+}
+
+// withVolumesContentDigest appends to the config digest the digest of the certificates and credentials mounted
+// in the pods, so that their rotation triggers a rollout
+func (r *GFKReconciler) withVolumesContentDigest(ctx context.Context, configDigest string, vols *volumes.Builder) (string, error) {
+	contentDigest, err := r.VolumesContentDigest(ctx, r.nobjMngr.Namespace, vols.GetVolumes())
+	if err != nil || contentDigest == "" {
+		return configDigest, err
+	}
+	return configDigest + "-" + contentDigest, nil
+}
+
+func (r *GFKReconciler) reconcileConfigMap(ctx context.Context, current, desired *corev1.ConfigMap) error {
+	if !r.nobjMngr.Exists(current) {
+		return r.CreateOwned(ctx, desired)
+	} else if reconcilers.NeedsUpdate(current, desired) {
+		return r.UpdateOwned(ctx, desired)
+	}
+	return nil
+}
+
+func (r *GFKReconciler) reconcileService(ctx context.Context, current, desired *corev1.Service) error {
+	if !r.nobjMngr.Exists(current) {
+		return r.CreateOwned(ctx, desired)
+	} else if reconcilers.NeedsUpdate(current, desired) {
+		return r.UpdateOwned(ctx, desired)
+	}
+	return nil
+}
+
+// reconcileConsumer deploys the Kafka consumer when Kafka is enabled, and removes it otherwise
+func (r *GFKReconciler) reconcileConsumer(ctx context.Context, desiredGoflowKube *goflowKubeSpec, desiredKafka *kafkaSpec,
+	desiredLoki *lokiSpec, desiredExporters []exporterSpec) error {
+	if desiredKafka == nil || !desiredKafka.Enable {
+		r.nobjMngr.TryDelete(ctx, r.owned.consumerDeployment)
+		r.nobjMngr.TryDelete(ctx, r.owned.consumerConfigMap)
+		return nil
+	}
+	ns := r.nobjMngr.Namespace
+	vols := volumes.Builder{}
+	newCM, configDigest := buildConsumerConfigMap(desiredGoflowKube, desiredKafka, desiredLoki, desiredExporters, ns, &vols)
+	if err := r.reconcileConfigMap(ctx, r.owned.consumerConfigMap, newCM); err != nil {
+		return err
+	}
+	configDigest, err := r.withVolumesContentDigest(ctx, configDigest, &vols)
+	if err != nil {
+		return err
+	}
+	newDepl := buildConsumerDeployment(desiredGoflowKube, desiredKafka, ns, configDigest, &vols)
+	if !r.nobjMngr.Exists(r.owned.consumerDeployment) {
+		return r.CreateOwned(ctx, newDepl)
+	} else if reconcilers.NeedsUpdate(r.owned.consumerDeployment, newDepl) {
+		return r.UpdateOwned(ctx, newDepl)
+	}
+	return nil
+}
+
+// reconcileMetrics exposes the flow metrics through a Service, and a ServiceMonitor when the Prometheus operator
+// API is available. They are removed when the metrics are disabled.
+func (r *GFKReconciler) reconcileMetrics(ctx context.Context, desiredGoflowKube *goflowKubeSpec, desiredKafka *kafkaSpec) error {
+	ns := r.nobjMngr.Namespace
+	if !desiredGoflowKube.Metrics.Enable {
+		r.nobjMngr.TryDelete(ctx, r.owned.metricsService)
+		return r.deleteUnstructured(ctx, serviceMonitorGVK, metricsServiceName, ns)
+	}
+	newSVC := buildMetricsService(desiredGoflowKube, desiredKafka, ns)
+	if err := r.reconcileService(ctx, r.owned.metricsService, newSVC); err != nil {
+		return err
+	}
+	return r.reconcileUnstructured(ctx, buildServiceMonitor(metricsServiceName, metricsPortName, ns))
+}
+
+// reconcileMonitoring exposes the processor metrics through a Service, and scrapes them and alerts on them
+// through a ServiceMonitor and a PrometheusRule when the Prometheus operator API is available. They are removed
+// when the monitoring is disabled.
+func (r *GFKReconciler) reconcileMonitoring(ctx context.Context, desiredGoflowKube *goflowKubeSpec, desiredLoki *lokiSpec) error {
+	ns := r.nobjMngr.Namespace
+	if !desiredGoflowKube.Monitoring.Enable {
+		r.nobjMngr.TryDelete(ctx, r.owned.monitoringService)
+		if err := r.deleteUnstructured(ctx, serviceMonitorGVK, monitoringServiceName, ns); err != nil {
 			return err
 		}
+		return r.deleteUnstructured(ctx, prometheusRuleGVK, constants.GoflowKubeName, ns)
+	}
+	newSVC := buildMonitoringService(desiredGoflowKube, ns)
+	if err := r.reconcileService(ctx, r.owned.monitoringService, newSVC); err != nil {
+		return err
+	}
+	if err := r.reconcileUnstructured(ctx, buildServiceMonitor(monitoringServiceName, monitoringPortName, ns)); err != nil {
+		return err
+	}
+	return r.reconcileUnstructured(ctx, buildPrometheusRule(desiredGoflowKube, desiredLoki, ns))
+}
+
+// reconcileUnstructured creates or updates an object of the Prometheus operator API. Nothing is done when this
+// API isn't available: the metrics have to be scraped, and alerted on, by other means.
+func (r *GFKReconciler) reconcileUnstructured(ctx context.Context, desired *unstructured.Unstructured) error {
+	current, err := r.getUnstructured(ctx, desired.GroupVersionKind(), desired.GetName(), desired.GetNamespace())
+	switch {
+	case meta.IsNoMatchError(err):
+		return nil
+	case errors.IsNotFound(err):
+		return r.CreateOwned(ctx, desired)
+	case err != nil:
+		return err
+	}
+	if !equality.Semantic.DeepEqual(current.Object["spec"], desired.Object["spec"]) {
+		return r.UpdateOwned(ctx, desired)
 	}
+	return nil
+}
+
+// getUnstructured returns an object of the Prometheus operator API. A NoMatch error is returned when this API
+// isn't available.
+func (r *GFKReconciler) getUnstructured(ctx context.Context, gvk schema.GroupVersionKind, name, ns string) (*unstructured.Unstructured, error) {
+	obj := unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: ns}, &obj); err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
+
+func (r *GFKReconciler) deleteUnstructured(ctx context.Context, gvk schema.GroupVersionKind, name, ns string) error {
+	obj, err := r.getUnstructured(ctx, gvk, name, ns)
+	if err != nil {
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return nil
+		}
+		return err
+	}
+	return r.DeleteIfExists(ctx, obj)
+}
+
+// deletePrometheusObjects deletes the ServiceMonitors and the PrometheusRule from the provided namespace
+func (r *GFKReconciler) deletePrometheusObjects(ctx context.Context, ns string) error {
+	if err := r.deleteUnstructured(ctx, serviceMonitorGVK, metricsServiceName, ns); err != nil {
+		return err
+	}
+	if err := r.deleteUnstructured(ctx, serviceMonitorGVK, monitoringServiceName, ns); err != nil {
+		return err
+	}
+	return r.deleteUnstructured(ctx, prometheusRuleGVK, constants.GoflowKubeName, ns)
+}
 
+// CheckReadiness tells whether the goflow-kube workload, as fetched during the last reconciliation,
+// has completed its rollout. A message describing the rollout state is also returned.
+func (r *GFKReconciler) CheckReadiness(desiredGoflowKube *goflowKubeSpec, desiredKafka *kafkaSpec) (bool, string) {
+	ready, msg := r.checkCollectorReadiness(desiredGoflowKube)
+	if !ready || desiredKafka == nil || !desiredKafka.Enable {
+		return ready, msg
+	}
+	if !r.nobjMngr.Exists(r.owned.consumerDeployment) {
+		return false, "Deployment " + consumerName + " is being created"
+	}
+	consumerReady, consumerMsg := reconcilers.DeploymentProgress(r.owned.consumerDeployment)
+	return consumerReady, msg + "; " + consumerMsg
+}
+
+func (r *GFKReconciler) checkCollectorReadiness(desiredGoflowKube *goflowKubeSpec) (bool, string) {
 	switch desiredGoflowKube.Kind {
 	case constants.DeploymentKind:
-		return r.reconcileAsDeployment(ctx, desiredGoflowKube, configDigest)
+		if !r.nobjMngr.Exists(r.owned.deployment) {
+			return false, "Deployment " + constants.GoflowKubeName + " is being created"
+		}
+		return reconcilers.DeploymentProgress(r.owned.deployment)
 	case constants.DaemonSetKind:
-		return r.reconcileAsDaemonSet(ctx, desiredGoflowKube, configDigest)
+		if !r.nobjMngr.Exists(r.owned.daemonSet) {
+			return false, "DaemonSet " + constants.GoflowKubeName + " is being created"
+		}
+		return reconcilers.DaemonSetProgress(r.owned.daemonSet)
 	default:
-		return fmt.Errorf("could not reconcile collector, invalid kind: %s", desiredGoflowKube.Kind)
+		return false, "invalid kind: " + desiredGoflowKube.Kind
 	}
 }
 
-func (r *GFKReconciler) reconcileAsDeployment(ctx context.Context, desiredGoflowKube *goflowKubeSpec, configDigest string) error {
+func (r *GFKReconciler) reconcileAsDeployment(ctx context.Context, desiredGoflowKube *goflowKubeSpec, configDigest string,
+	vols *volumes.Builder, withMetrics bool) error {
 	// Kind changed: delete DaemonSet and create Deployment+Service
 	ns := r.nobjMngr.Namespace
 	r.nobjMngr.TryDelete(ctx, r.owned.daemonSet)
 
-	newDepl := buildDeployment(desiredGoflowKube, ns, configDigest)
+	newDepl := buildDeployment(desiredGoflowKube, ns, configDigest, vols, withMetrics)
 	if !r.nobjMngr.Exists(r.owned.deployment) {
 		if err := r.CreateOwned(ctx, newDepl); err != nil {
 			return err
 		}
-	} else if deploymentNeedsUpdate(r.owned.deployment, desiredGoflowKube, ns, configDigest) {
-		if err := r.UpdateOwned(ctx, r.owned.deployment, newDepl); err != nil {
+	} else if reconcilers.NeedsUpdate(r.owned.deployment, newDepl) {
+		if err := r.UpdateOwned(ctx, newDepl); err != nil {
 			return err
 		}
 	}
+	newSVC := buildService(desiredGoflowKube, ns)
 	if !r.nobjMngr.Exists(r.owned.service) {
-		newSVC := buildService(nil, desiredGoflowKube, ns)
 		if err := r.CreateOwned(ctx, newSVC); err != nil {
 			return err
 		}
-	} else if serviceNeedsUpdate(r.owned.service, desiredGoflowKube, ns) {
-		newSVC := buildService(r.owned.service, desiredGoflowKube, ns)
-		if err := r.UpdateOwned(ctx, r.owned.service, newSVC); err != nil {
+	} else if reconcilers.NeedsUpdate(r.owned.service, newSVC) {
+		if err := r.UpdateOwned(ctx, newSVC); err != nil {
 			return err
 		}
 	}
@@ -130,8 +380,8 @@ func (r *GFKReconciler) reconcileAsDeployment(ctx context.Context, desiredGoflow
 			if err := r.CreateOwned(ctx, newASC); err != nil {
 				return err
 			}
-		} else if autoScalerNeedsUpdate(r.owned.hpa, desiredGoflowKube, ns) {
-			if err := r.UpdateOwned(ctx, r.owned.hpa, newASC); err != nil {
+		} else if reconcilers.NeedsUpdate(r.owned.hpa, newASC) {
+			if err := r.UpdateOwned(ctx, newASC); err != nil {
 				return err
 			}
 		}
@@ -139,19 +389,20 @@ func (r *GFKReconciler) reconcileAsDeployment(ctx context.Context, desiredGoflow
 	return nil
 }
 
-func (r *GFKReconciler) reconcileAsDaemonSet(ctx context.Context, desiredGoflowKube *goflowKubeSpec, configDigest string) error {
+func (r *GFKReconciler) reconcileAsDaemonSet(ctx context.Context, desiredGoflowKube *goflowKubeSpec, configDigest string,
+	vols *volumes.Builder, withMetrics bool) error {
 	// Kind changed: delete Deployment / Service / HPA and create DaemonSet
 	ns := r.nobjMngr.Namespace
 	r.nobjMngr.TryDelete(ctx, r.owned.deployment)
 	r.nobjMngr.TryDelete(ctx, r.owned.service)
 	r.nobjMngr.TryDelete(ctx, r.owned.hpa)
-	newDS := buildDaemonSet(desiredGoflowKube, ns, configDigest)
+	newDS := buildDaemonSet(desiredGoflowKube, ns, configDigest, vols, withMetrics)
 	if !r.nobjMngr.Exists(r.owned.daemonSet) {
 		if err := r.CreateOwned(ctx, newDS); err != nil {
 			return err
 		}
-	} else if daemonSetNeedsUpdate(r.owned.daemonSet, desiredGoflowKube, ns, configDigest) {
-		if err := r.UpdateOwned(ctx, r.owned.daemonSet, newDS); err != nil {
+	} else if reconcilers.NeedsUpdate(r.owned.daemonSet, newDS) {
+		if err := r.UpdateOwned(ctx, newDS); err != nil {
 			return err
 		}
 	}
@@ -175,74 +426,9 @@ func (r *GFKReconciler) createPermissions(ctx context.Context, firstInstall bool
 			return err
 		}
 	} else {
-		if err := r.UpdateOwned(ctx, nil, buildClusterRoleBinding(r.nobjMngr.Namespace)); err != nil {
+		if err := r.UpdateOwned(ctx, buildClusterRoleBinding(r.nobjMngr.Namespace)); err != nil {
 			return err
 		}
 	}
 	return nil
 }
-
-func daemonSetNeedsUpdate(ds *appsv1.DaemonSet, desired *goflowKubeSpec, ns, configDigest string) bool {
-	if ds.Namespace != ns {
-		return true
-	}
-	return containerNeedsUpdate(&ds.Spec.Template.Spec, desired) ||
-		configChanged(&ds.Spec.Template, configDigest)
-}
-
-func deploymentNeedsUpdate(depl *appsv1.Deployment, desired *goflowKubeSpec, ns, configDigest string) bool {
-	if depl.Namespace != ns {
-		return true
-	}
-	return containerNeedsUpdate(&depl.Spec.Template.Spec, desired) ||
-		configChanged(&depl.Spec.Template, configDigest) ||
-		*depl.Spec.Replicas != desired.Replicas
-}
-
-func configChanged(tmpl *corev1.PodTemplateSpec, configDigest string) bool {
-	return tmpl.Annotations == nil || tmpl.Annotations[PodConfigurationDigest] != configDigest
-}
-
-func serviceNeedsUpdate(svc *corev1.Service, desired *goflowKubeSpec, ns string) bool {
-	if svc.Namespace != ns {
-		return true
-	}
-	for _, port := range svc.Spec.Ports {
-		if port.Port == desired.Port && port.Protocol == corev1.ProtocolUDP {
-			return false
-		}
-	}
-	return true
-}
-
-func containerNeedsUpdate(podSpec *corev1.PodSpec, desired *goflowKubeSpec) bool {
-	container := reconcilers.FindContainer(podSpec, constants.GoflowKubeName)
-	if container == nil {
-		return true
-	}
-	if desired.Image != container.Image || desired.ImagePullPolicy != string(container.ImagePullPolicy) {
-		return true
-	}
-	if !reflect.DeepEqual(desired.Resources, container.Resources) {
-		return true
-	}
-	if len(container.Command) != 3 || container.Command[2] != buildMainCommand(desired) {
-		return true
-	}
-	return false
-}
-
-func autoScalerNeedsUpdate(asc *ascv1.HorizontalPodAutoscaler, desired *goflowKubeSpec, ns string) bool {
-	if asc.Namespace != ns {
-		return true
-	}
-	differentPointerValues := func(a, b *int32) bool {
-		return (a == nil && b != nil) || (a != nil && b == nil) || (a != nil && *a != *b)
-	}
-	if asc.Spec.MaxReplicas != desired.HPA.MaxReplicas ||
-		differentPointerValues(asc.Spec.MinReplicas, desired.HPA.MinReplicas) ||
-		differentPointerValues(asc.Spec.TargetCPUUtilizationPercentage, desired.HPA.TargetCPUUtilizationPercentage) {
-		return true
-	}
-	return false
-}
@@ -17,18 +17,28 @@ limitations under the License.
 package goflowkube
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v2"
+	appsv1 "k8s.io/api/apps/v1"
 	ascv1 "k8s.io/api/autoscaling/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	flowsv1alpha1 "github.com/netobserv/network-observability-operator/api/v1alpha1"
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
 	"github.com/netobserv/network-observability-operator/controllers/constants"
+	"github.com/netobserv/network-observability-operator/controllers/controllerstest"
+	"github.com/netobserv/network-observability-operator/controllers/reconcilers"
+	"github.com/netobserv/network-observability-operator/pkg/helper"
+	"github.com/netobserv/network-observability-operator/pkg/volumes"
 )
 
 var resources = corev1.ResourceRequirements{
@@ -50,14 +60,14 @@ var targetCPU = int32(75)
 
 const testNamespace = "goflowkube"
 
-func getGoflowKubeConfig() flowsv1alpha1.FlowCollectorGoflowKube {
-	return flowsv1alpha1.FlowCollectorGoflowKube{
+func getGoflowKubeConfig() flowsv1beta1.FlowCollectorProcessor {
+	return flowsv1beta1.FlowCollectorProcessor{
 		Port:            2055,
 		Image:           image,
 		ImagePullPolicy: string(pullPolicy),
 		LogLevel:        "trace",
 		Resources:       resources,
-		HPA: &flowsv1alpha1.FlowCollectorHPA{
+		HPA: &flowsv1beta1.FlowCollectorHPA{
 			MinReplicas:                    &minReplicas,
 			MaxReplicas:                    maxReplicas,
 			TargetCPUUtilizationPercentage: &targetCPU,
@@ -66,8 +76,8 @@ func getGoflowKubeConfig() flowsv1alpha1.FlowCollectorGoflowKube {
 	}
 }
 
-func getLokiConfig() flowsv1alpha1.FlowCollectorLoki {
-	return flowsv1alpha1.FlowCollectorLoki{
+func getLokiConfig() flowsv1beta1.FlowCollectorLoki {
+	return flowsv1beta1.FlowCollectorLoki{
 		URL: "http://loki:3100/",
 		BatchWait: metav1.Duration{
 			Duration: 1,
@@ -81,112 +91,220 @@ func getLokiConfig() flowsv1alpha1.FlowCollectorLoki {
 		},
 		MaxRetries:   10,
 		StaticLabels: map[string]string{"app": "netobserv-flowcollector"},
+		Labels:       []string{"SrcNamespace", "SrcWorkload", "DstNamespace", "DstWorkload"},
 	}
 }
 
-func getContainerSpecs() (corev1.PodSpec, flowsv1alpha1.FlowCollectorGoflowKube) {
-	var podSpec = corev1.PodSpec{
-		Containers: []corev1.Container{
-			{
-				Name:            constants.GoflowKubeName,
-				Image:           image,
-				Command:         commands,
-				Resources:       resources,
-				ImagePullPolicy: pullPolicy,
-			},
-		},
-	}
-
-	return podSpec, getGoflowKubeConfig()
-}
-
-func getServiceSpecs() (corev1.Service, flowsv1alpha1.FlowCollectorGoflowKube) {
-	var service = corev1.Service{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace: testNamespace,
-		},
-		Spec: corev1.ServiceSpec{
-			Ports: []corev1.ServicePort{
-				{
-					Port:     2055,
-					Protocol: "UDP",
-				},
-			},
-		},
-	}
-
-	return service, getGoflowKubeConfig()
-}
-
 func TestBuildMainCommand(t *testing.T) {
 	assert := assert.New(t)
 
-	_, goflowKube := getContainerSpecs()
+	goflowKube := getGoflowKubeConfig()
 	cmd := buildMainCommand(&goflowKube)
 	assert.Equal(commands[2], cmd)
 }
 
-func getAutoScalerSpecs() (ascv1.HorizontalPodAutoscaler, flowsv1alpha1.FlowCollectorGoflowKube) {
-	var autoScaler = ascv1.HorizontalPodAutoscaler{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace: testNamespace,
-		},
-		Spec: ascv1.HorizontalPodAutoscalerSpec{
-			ScaleTargetRef: ascv1.CrossVersionObjectReference{
-				Kind: constants.DeploymentKind,
-				Name: constants.GoflowKubeName,
-			},
-			MinReplicas:                    &minReplicas,
-			MaxReplicas:                    maxReplicas,
-			TargetCPUUtilizationPercentage: &targetCPU,
-		},
-	}
-
-	return autoScaler, getGoflowKubeConfig()
-}
-
-func TestContainerUpdateCheck(t *testing.T) {
+func TestDeploymentUpdateCheck(t *testing.T) {
 	assert := assert.New(t)
 
+	goflowKube := getGoflowKubeConfig()
+	desired := func() client.Object {
+		return buildDeployment(&goflowKube, testNamespace, "digest", &volumes.Builder{}, false)
+	}
+	current := controllerstest.AsWritten(desired())
+
 	//equals specs
-	podSpec, goflowKube := getContainerSpecs()
-	assert.Equal(containerNeedsUpdate(&podSpec, &goflowKube), false)
+	assert.False(reconcilers.NeedsUpdate(current, desired()))
+
+	//server defaults and metadata set by other controllers are ignored
+	depl := current.DeepCopyObject().(*appsv1.Deployment)
+	depl.Annotations["deployment.kubernetes.io/revision"] = "2"
+	depl.Spec.RevisionHistoryLimit = helper.Int32Ptr(10)
+	depl.Spec.Template.Spec.Containers[0].TerminationMessagePath = corev1.TerminationMessagePathDefault
+	depl.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyAlways
+	assert.False(reconcilers.NeedsUpdate(depl, desired()))
 
 	//wrong command
-	podSpec, goflowKube = getContainerSpecs()
-	podSpec.Containers[0].Command = []string{"/bin/sh"}
-	assert.Equal(containerNeedsUpdate(&podSpec, &goflowKube), true)
+	depl = current.DeepCopyObject().(*appsv1.Deployment)
+	depl.Spec.Template.Spec.Containers[0].Command = []string{"/bin/sh"}
+	assert.True(reconcilers.NeedsUpdate(depl, desired()))
 
 	//wrong log level
-	podSpec, goflowKube = getContainerSpecs()
 	goflowKube.LogLevel = "info"
-	assert.Equal(containerNeedsUpdate(&podSpec, &goflowKube), true)
+	assert.True(reconcilers.NeedsUpdate(current, desired()))
 
 	//wrong resources
-	podSpec, goflowKube = getContainerSpecs()
+	goflowKube = getGoflowKubeConfig()
 	goflowKube.Resources.Limits = map[corev1.ResourceName]resource.Quantity{
 		corev1.ResourceCPU:    resource.MustParse("500m"),
 		corev1.ResourceMemory: resource.MustParse("500Gi"),
 	}
-	assert.Equal(containerNeedsUpdate(&podSpec, &goflowKube), true)
+	assert.True(reconcilers.NeedsUpdate(current, desired()))
+
+	//removed resources
+	goflowKube.Resources = corev1.ResourceRequirements{}
+	assert.True(reconcilers.NeedsUpdate(current, desired()))
+
+	//any modified field is detected
+	goflowKube = getGoflowKubeConfig()
+	assert.Empty(controllerstest.UndetectedDrifts(desired()))
+}
+
+func TestDaemonSetUpdateCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	goflowKube := getGoflowKubeConfig()
+	goflowKube.Kind = constants.DaemonSetKind
+	goflowKube.Metrics = getMetricsConfig()
+	goflowKube.Listeners = []flowsv1beta1.FlowCollectorListener{{Protocol: "sflow", Port: 6343}}
+	desired := buildDaemonSet(&goflowKube, testNamespace, "digest", &volumes.Builder{}, true)
+	current := controllerstest.AsWritten(desired)
+	assert.False(reconcilers.NeedsUpdate(current, desired))
+
+	//wrong namespace
+	assert.True(reconcilers.NeedsUpdate(current, buildDaemonSet(&goflowKube, "NewNamespace", "digest", &volumes.Builder{}, true)))
+
+	//configuration change
+	assert.True(reconcilers.NeedsUpdate(current, buildDaemonSet(&goflowKube, testNamespace, "other", &volumes.Builder{}, true)))
+
+	//any modified field is detected
+	assert.Empty(controllerstest.UndetectedDrifts(desired))
 }
 
 func TestServiceUpdateCheck(t *testing.T) {
 	assert := assert.New(t)
 
 	//equals specs
-	serviceSpec, goflowKube := getServiceSpecs()
-	assert.Equal(serviceNeedsUpdate(&serviceSpec, &goflowKube, testNamespace), false)
+	goflowKube := getGoflowKubeConfig()
+	current := controllerstest.AsWritten(buildService(&goflowKube, testNamespace))
+	assert.False(reconcilers.NeedsUpdate(current, buildService(&goflowKube, testNamespace)))
+
+	//allocated and defaulted fields are ignored
+	svc := current.DeepCopyObject().(*corev1.Service)
+	svc.Spec.ClusterIP = "172.30.0.10"
+	svc.Spec.Ports[0].TargetPort = intstr.FromInt(2055)
+	svc.Spec.Type = corev1.ServiceTypeClusterIP
+	assert.False(reconcilers.NeedsUpdate(svc, buildService(&goflowKube, testNamespace)))
 
 	//wrong port protocol
-	serviceSpec, goflowKube = getServiceSpecs()
-	serviceSpec.Spec.Ports[0].Protocol = "TCP"
-	assert.Equal(serviceNeedsUpdate(&serviceSpec, &goflowKube, testNamespace), true)
+	svc = current.DeepCopyObject().(*corev1.Service)
+	svc.Spec.Ports[0].Protocol = corev1.ProtocolTCP
+	assert.True(reconcilers.NeedsUpdate(svc, buildService(&goflowKube, testNamespace)))
 
 	//wrong namespace
-	serviceSpec, goflowKube = getServiceSpecs()
-	serviceSpec.Namespace = "NewNamespace"
-	assert.Equal(serviceNeedsUpdate(&serviceSpec, &goflowKube, testNamespace), true)
+	assert.True(reconcilers.NeedsUpdate(current, buildService(&goflowKube, "NewNamespace")))
+
+	//any modified field is detected
+	assert.Empty(controllerstest.UndetectedDrifts(buildService(&goflowKube, testNamespace)))
+}
+
+func TestListeners(t *testing.T) {
+	assert := assert.New(t)
+
+	goflowKube := getGoflowKubeConfig()
+	goflowKube.Kind = constants.DaemonSetKind
+	goflowKube.Listeners = []flowsv1beta1.FlowCollectorListener{{Protocol: "sflow", Port: 6343}}
+	loki := getLokiConfig()
+
+	cm, _ := buildConfigMap(&goflowKube, nil, &loki, nil, "namespace", &volumes.Builder{})
+	var decoded map[string]interface{}
+	assert.NoError(yaml.Unmarshal([]byte(cm.Data[configFile]), &decoded))
+	assert.Equal("netflow://:2055,sflow://:6343", decoded["listen"])
+
+	podSpec := buildPodTemplate(&goflowKube, "digest", &volumes.Builder{}, false).Spec
+	assert.Equal([]corev1.ContainerPort{
+		{Name: constants.GoflowKubeName, HostPort: 2055, ContainerPort: 2055, Protocol: corev1.ProtocolUDP},
+		{Name: "sflow-6343", HostPort: 6343, ContainerPort: 6343, Protocol: corev1.ProtocolUDP},
+	}, podSpec.Containers[0].Ports)
+
+	svc := buildService(&goflowKube, testNamespace)
+	assert.Equal([]corev1.ServicePort{
+		{Name: constants.GoflowKubeName, Port: 2055, Protocol: corev1.ProtocolUDP},
+		{Name: "sflow-6343", Port: 6343, Protocol: corev1.ProtocolUDP},
+	}, svc.Spec.Ports)
+
+	// Adding or changing a listener requires an update
+	currentDS := controllerstest.AsWritten(buildDaemonSet(&goflowKube, testNamespace, "digest", &volumes.Builder{}, false))
+	currentSvc := controllerstest.AsWritten(svc)
+	updated := goflowKube
+	updated.Listeners = []flowsv1beta1.FlowCollectorListener{{Protocol: "sflow", Port: 6344}}
+	assert.True(reconcilers.NeedsUpdate(currentDS, buildDaemonSet(&updated, testNamespace, "digest", &volumes.Builder{}, false)))
+	assert.True(reconcilers.NeedsUpdate(currentSvc, buildService(&updated, testNamespace)))
+	updated.Listeners = append(goflowKube.Listeners, flowsv1beta1.FlowCollectorListener{Protocol: "netflow", Port: 2056})
+	assert.True(reconcilers.NeedsUpdate(currentDS, buildDaemonSet(&updated, testNamespace, "digest", &volumes.Builder{}, false)))
+	assert.True(reconcilers.NeedsUpdate(currentSvc, buildService(&updated, testNamespace)))
+}
+
+func TestSchedulingUpdateCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	goflowKube := getGoflowKubeConfig()
+	goflowKube.Kind = constants.DaemonSetKind
+	desired := func() client.Object {
+		return buildDaemonSet(&goflowKube, testNamespace, "digest", &volumes.Builder{}, false)
+	}
+	current := controllerstest.AsWritten(desired())
+	podSpec := current.(*appsv1.DaemonSet).Spec.Template.Spec
+	// The DaemonSet tolerates all taints by default
+	assert.Equal([]corev1.Toleration{{Operator: corev1.TolerationOpExists}}, podSpec.Tolerations)
+
+	//configured constraints
+	goflowKube.Scheduling = flowsv1beta1.FlowCollectorScheduling{
+		NodeSelector:      map[string]string{"kubernetes.io/os": "linux"},
+		Tolerations:       []corev1.Toleration{{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists}},
+		PriorityClassName: "system-node-critical",
+	}
+	assert.True(reconcilers.NeedsUpdate(current, desired()))
+	current = controllerstest.AsWritten(desired())
+	podSpec = current.(*appsv1.DaemonSet).Spec.Template.Spec
+	assert.Equal(goflowKube.Scheduling.Tolerations, podSpec.Tolerations)
+	assert.Equal("system-node-critical", podSpec.PriorityClassName)
+	assert.False(reconcilers.NeedsUpdate(current, desired()))
+
+	//removed constraints
+	goflowKube.Scheduling.NodeSelector = nil
+	assert.True(reconcilers.NeedsUpdate(current, desired()))
+
+	//spread deployment replicas across zones, off the GPU nodes
+	goflowKube.Kind = constants.DeploymentKind
+	goflowKube.Scheduling = flowsv1beta1.FlowCollectorScheduling{
+		Affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{{
+					Key:      "nvidia.com/gpu",
+					Operator: corev1.NodeSelectorOpDoesNotExist,
+				}}}},
+			},
+		}},
+		TopologySpreadConstraints: []corev1.TopologySpreadConstraint{{
+			MaxSkew:           1,
+			TopologyKey:       "topology.kubernetes.io/zone",
+			WhenUnsatisfiable: corev1.DoNotSchedule,
+			LabelSelector:     &metav1.LabelSelector{MatchLabels: buildLabels()},
+		}},
+	}
+	depl := buildDeployment(&goflowKube, testNamespace, "digest", &volumes.Builder{}, false)
+	podSpec = depl.Spec.Template.Spec
+	assert.Empty(podSpec.Tolerations)
+	assert.Equal(goflowKube.Scheduling.Affinity, podSpec.Affinity)
+	assert.Equal(goflowKube.Scheduling.TopologySpreadConstraints, podSpec.TopologySpreadConstraints)
+	currentDepl := controllerstest.AsWritten(depl)
+	assert.False(reconcilers.NeedsUpdate(currentDepl, buildDeployment(&goflowKube, testNamespace, "digest", &volumes.Builder{}, false)))
+	assert.Empty(controllerstest.UndetectedDrifts(depl))
+
+	//changed constraints
+	goflowKube.Scheduling.TopologySpreadConstraints[0].MaxSkew = 2
+	assert.True(reconcilers.NeedsUpdate(currentDepl, buildDeployment(&goflowKube, testNamespace, "digest", &volumes.Builder{}, false)))
+	goflowKube.Scheduling.Affinity = nil
+	assert.True(reconcilers.NeedsUpdate(currentDepl, buildDeployment(&goflowKube, testNamespace, "digest", &volumes.Builder{}, false)))
+
+	//the DaemonSet pods aren't spread, but still follow the affinity
+	goflowKube.Kind = constants.DaemonSetKind
+	goflowKube.Scheduling.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{}}
+	ds := desired()
+	podSpec = ds.(*appsv1.DaemonSet).Spec.Template.Spec
+	assert.Equal(goflowKube.Scheduling.Affinity, podSpec.Affinity)
+	assert.Nil(podSpec.TopologySpreadConstraints)
+	assert.Empty(controllerstest.UndetectedDrifts(ds))
 }
 
 func TestConfigMapShouldDeserializeAsYAML(t *testing.T) {
@@ -194,7 +312,7 @@ func TestConfigMapShouldDeserializeAsYAML(t *testing.T) {
 
 	goflowKube := getGoflowKubeConfig()
 	loki := getLokiConfig()
-	cm, digest := buildConfigMap(&goflowKube, &loki, "namespace")
+	cm, digest := buildConfigMap(&goflowKube, nil, &loki, nil, "namespace", &volumes.Builder{})
 	assert.NotEmpty(t, digest)
 
 	data, ok := cm.Data[configFile]
@@ -216,32 +334,404 @@ func TestConfigMapShouldDeserializeAsYAML(t *testing.T) {
 	assert.EqualValues(loki.BatchSize, lokiCfg["batchSize"])
 	assert.EqualValues([]interface{}{"SrcNamespace", "SrcWorkload", "DstNamespace", "DstWorkload"}, lokiCfg["labels"])
 	assert.Equal(fmt.Sprintf("%v", loki.StaticLabels), fmt.Sprintf("%v", lokiCfg["staticLabels"]))
+
+	// Labels are configurable
+	loki.Labels = []string{"SrcNamespace", "DstNamespace", "FlowDirection"}
+	cm, _ = buildConfigMap(&goflowKube, nil, &loki, nil, "namespace", &volumes.Builder{})
+	decoded = nil
+	assert.NoError(yaml.Unmarshal([]byte(cm.Data[configFile]), &decoded))
+	lokiCfg = decoded["loki"].(map[interface{}]interface{})
+	assert.EqualValues([]interface{}{"SrcNamespace", "DstNamespace", "FlowDirection"}, lokiCfg["labels"])
+}
+
+func getKafkaConfig() flowsv1beta1.FlowCollectorKafka {
+	return flowsv1beta1.FlowCollectorKafka{
+		Enable:  true,
+		Brokers: []string{"kafka-bootstrap:9093"},
+		Topic:   "network-flows",
+		TLS: flowsv1beta1.ClientTLS{
+			Enable:   true,
+			CACert:   flowsv1beta1.CertificateReference{Type: "configmap", Name: "kafka-ca", CertFile: "ca.crt"},
+			UserCert: flowsv1beta1.CertificateReference{Type: "secret", Name: "kafka-user", CertFile: "user.crt", CertKey: "user.key"},
+		},
+		ConsumerReplicas: 3,
+	}
+}
+
+func TestConfigMapWithKafka(t *testing.T) {
+	assert := assert.New(t)
+
+	goflowKube := getGoflowKubeConfig()
+	kafka := getKafkaConfig()
+	loki := getLokiConfig()
+
+	// The collector produces to Kafka and doesn't send to Loki
+	vols := volumes.Builder{}
+	cm, digest := buildConfigMap(&goflowKube, &kafka, &loki, nil, "namespace", &vols)
+	var decoded ConfigMap
+	assert.NoError(json.Unmarshal([]byte(cm.Data[configFile]), &decoded))
+	assert.Nil(decoded.Loki)
+	assert.Nil(decoded.KafkaInput)
+	assert.Equal(&KafkaConfigMap{
+		Brokers: []string{"kafka-bootstrap:9093"},
+		Topic:   "network-flows",
+		TLS: &TLSConfigMap{
+			CACertPath:   "/var/kafka-ca/ca.crt",
+			UserCertPath: "/var/kafka-user/user.crt",
+			UserKeyPath:  "/var/kafka-user/user.key",
+		},
+	}, decoded.KafkaOutput)
+	assert.Len(vols.GetVolumes(), 2)
+
+	// Changing the certificate reference changes the digest, even though the configmap remains the same
+	kafka.TLS.CACert.Name = "other-ca"
+	otherCM, otherDigest := buildConfigMap(&goflowKube, &kafka, &loki, nil, "namespace", &volumes.Builder{})
+	assert.Equal(cm.Data, otherCM.Data)
+	assert.NotEqual(digest, otherDigest)
+
+	// The consumer reads from Kafka and sends to Loki
+	cm, _ = buildConsumerConfigMap(&goflowKube, &kafka, &loki, nil, "namespace", &volumes.Builder{})
+	decoded = ConfigMap{}
+	assert.NoError(json.Unmarshal([]byte(cm.Data[configFile]), &decoded))
+	assert.Empty(decoded.Listen)
+	assert.Nil(decoded.KafkaOutput)
+	assert.Equal(consumerName, decoded.KafkaInput.GroupID)
+	assert.Equal(loki.URL, decoded.Loki.URL)
+}
+
+func TestConfigMapWithLokiTLSAndAuth(t *testing.T) {
+	assert := assert.New(t)
+
+	goflowKube := getGoflowKubeConfig()
+	loki := getLokiConfig()
+	loki.TLS = flowsv1beta1.ClientTLS{
+		Enable:   true,
+		CACert:   flowsv1beta1.CertificateReference{Type: "configmap", Name: "loki-ca", CertFile: "ca.crt"},
+		UserCert: flowsv1beta1.CertificateReference{Type: "secret", Name: "loki-user", CertFile: "tls.crt", CertKey: "tls.key"},
+	}
+	loki.Auth = flowsv1beta1.LokiAuth{Type: "ServiceAccountToken"}
+	loki.TenantID = "netobserv"
+	vols := volumes.Builder{}
+	cm, _ := buildConfigMap(&goflowKube, nil, &loki, nil, "namespace", &vols)
+	var decoded ConfigMap
+	assert.NoError(json.Unmarshal([]byte(cm.Data[configFile]), &decoded))
+	assert.Equal(&TLSConfigMap{
+		CACertPath:   "/var/loki-ca/ca.crt",
+		UserCertPath: "/var/loki-user/tls.crt",
+		UserKeyPath:  "/var/loki-user/tls.key",
+	}, decoded.Loki.TLS)
+	assert.Equal(volumes.ServiceAccountTokenPath, decoded.Loki.BearerTokenPath)
+	assert.Nil(decoded.Loki.BasicAuth)
+	assert.Equal("netobserv", decoded.Loki.TenantID)
+	assert.Len(vols.GetVolumes(), 2)
+
+	ds := buildDaemonSet(&goflowKube, "namespace", "digest", &vols, false)
+	assert.Len(ds.Spec.Template.Spec.Volumes, 3)
+	assert.Len(ds.Spec.Template.Spec.Containers[0].VolumeMounts, 3)
+}
+
+func TestConsumerDeployment(t *testing.T) {
+	assert := assert.New(t)
+
+	goflowKube := getGoflowKubeConfig()
+	goflowKube.Kind = constants.DaemonSetKind
+	kafka := getKafkaConfig()
+	vols := volumes.Builder{}
+	_, digest := buildConsumerConfigMap(&goflowKube, &kafka, nil, nil, testNamespace, &vols)
+	depl := buildConsumerDeployment(&goflowKube, &kafka, testNamespace, digest, &vols)
+
+	assert.Equal(int32(3), *depl.Spec.Replicas)
+	assert.Equal(buildConsumerLabels(), depl.Spec.Template.Labels)
+	podSpec := depl.Spec.Template.Spec
+	// Unlike the collector, the consumer doesn't listen on the host port
+	assert.Empty(podSpec.Containers[0].Ports)
+	assert.Empty(podSpec.Tolerations)
+	assert.Equal(consumerConfigMapName, podSpec.Volumes[0].ConfigMap.Name)
+	assert.Len(podSpec.Volumes, 3)
+	assert.Len(podSpec.Containers[0].VolumeMounts, 3)
+
+	current := controllerstest.AsWritten(depl)
+	assert.False(reconcilers.NeedsUpdate(current, buildConsumerDeployment(&goflowKube, &kafka, testNamespace, digest, &vols)))
+	assert.True(reconcilers.NeedsUpdate(current, buildConsumerDeployment(&goflowKube, &kafka, testNamespace, "other", &vols)))
+	kafka.ConsumerReplicas = 5
+	assert.True(reconcilers.NeedsUpdate(current, buildConsumerDeployment(&goflowKube, &kafka, testNamespace, digest, &vols)))
+	assert.Empty(controllerstest.UndetectedDrifts(depl))
+}
+
+func getMetricsConfig() flowsv1beta1.FlowCollectorMetrics {
+	return flowsv1beta1.FlowCollectorMetrics{
+		Enable:      true,
+		Port:        9102,
+		Prefix:      "netobserv_",
+		IncludeList: []string{"bytes_total", "flows_total"},
+		Labels:      []string{"SrcNamespace", "DstNamespace"},
+	}
+}
+
+func TestConfigMapWithMetrics(t *testing.T) {
+	assert := assert.New(t)
+
+	goflowKube := getGoflowKubeConfig()
+	goflowKube.Metrics = getMetricsConfig()
+	loki := getLokiConfig()
+	disabled := false
+	loki.Enable = &disabled
+
+	// Without Kafka, the collector computes the metrics
+	cm, _ := buildConfigMap(&goflowKube, nil, &loki, nil, "namespace", &volumes.Builder{})
+	var decoded ConfigMap
+	assert.NoError(json.Unmarshal([]byte(cm.Data[configFile]), &decoded))
+	assert.Nil(decoded.Loki)
+	assert.Equal(&MetricsConfigMap{
+		Port:   9102,
+		Prefix: "netobserv_",
+		Metrics: []MetricConfigMap{{
+			Name:       "bytes_total",
+			Type:       "counter",
+			ValueField: "Bytes",
+			Labels:     []string{"SrcNamespace", "DstNamespace"},
+		}, {
+			Name:   "flows_total",
+			Type:   "counter",
+			Labels: []string{"SrcNamespace", "DstNamespace"},
+		}},
+	}, decoded.Metrics)
+
+	// With Kafka, the consumer computes them
+	kafka := getKafkaConfig()
+	cm, _ = buildConfigMap(&goflowKube, &kafka, &loki, nil, "namespace", &volumes.Builder{})
+	decoded = ConfigMap{}
+	assert.NoError(json.Unmarshal([]byte(cm.Data[configFile]), &decoded))
+	assert.Nil(decoded.Metrics)
+	cm, _ = buildConsumerConfigMap(&goflowKube, &kafka, &loki, nil, "namespace", &volumes.Builder{})
+	decoded = ConfigMap{}
+	assert.NoError(json.Unmarshal([]byte(cm.Data[configFile]), &decoded))
+	assert.Nil(decoded.Loki)
+	assert.Len(decoded.Metrics.Metrics, 2)
+
+	goflowKube.Metrics.Enable = false
+	cm, _ = buildConfigMap(&goflowKube, nil, &loki, nil, "namespace", &volumes.Builder{})
+	decoded = ConfigMap{}
+	assert.NoError(json.Unmarshal([]byte(cm.Data[configFile]), &decoded))
+	assert.Nil(decoded.Metrics)
+}
+
+func TestMetricsService(t *testing.T) {
+	assert := assert.New(t)
+
+	goflowKube := getGoflowKubeConfig()
+	goflowKube.Kind = constants.DaemonSetKind
+	goflowKube.Metrics = getMetricsConfig()
+	ds := buildDaemonSet(&goflowKube, testNamespace, "digest", &volumes.Builder{}, true)
+	ports := ds.Spec.Template.Spec.Containers[0].Ports
+	assert.Len(ports, 2)
+	assert.Equal(corev1.ContainerPort{Name: metricsPortName, ContainerPort: 9102, Protocol: corev1.ProtocolTCP}, ports[1])
+
+	svc := buildMetricsService(&goflowKube, nil, testNamespace)
+	assert.Equal(buildLabels(), svc.Spec.Selector)
+	assert.Equal(int32(9102), svc.Spec.Ports[0].Port)
+	assert.Empty(controllerstest.UndetectedDrifts(svc))
+
+	// With Kafka, the metrics are exposed by the consumer
+	kafka := getKafkaConfig()
+	goflowKube.Metrics.Port = 9999
+	updated := buildMetricsService(&goflowKube, &kafka, testNamespace)
+	assert.Equal(buildConsumerLabels(), updated.Spec.Selector)
+	assert.Equal(int32(9999), updated.Spec.Ports[0].Port)
+	assert.True(reconcilers.NeedsUpdate(controllerstest.AsWritten(svc), updated))
+	depl := buildConsumerDeployment(&goflowKube, &kafka, testNamespace, "digest", &volumes.Builder{})
+	assert.Equal(int32(9999), depl.Spec.Template.Spec.Containers[0].Ports[0].ContainerPort)
+
+	sm := buildServiceMonitor(metricsServiceName, metricsPortName, testNamespace)
+	assert.Equal("ServiceMonitor", sm.GetKind())
+	assert.Equal(metricsServiceName, sm.GetName())
+	selector, _, _ := unstructured.NestedStringMap(sm.Object, "spec", "selector", "matchLabels")
+	assert.Equal(updated.Labels, selector)
+}
+
+func getMonitoringConfig() flowsv1beta1.FlowCollectorMonitoring {
+	return flowsv1beta1.FlowCollectorMonitoring{
+		Enable: true,
+		Port:   9090,
+		Alerts: flowsv1beta1.FlowCollectorAlerts{
+			NoFlowsFor:        metav1.Duration{Duration: 10 * time.Minute},
+			LokiErrorsPercent: 5,
+			UDPDropsPerSecond: 100,
+			NotReadyFor:       metav1.Duration{Duration: 5 * time.Minute},
+		},
+	}
+}
+
+func TestMonitoring(t *testing.T) {
+	assert := assert.New(t)
+
+	goflowKube := getGoflowKubeConfig()
+	goflowKube.Kind = constants.DaemonSetKind
+	goflowKube.Monitoring = getMonitoringConfig()
+	loki := getLokiConfig()
+	kafka := getKafkaConfig()
+
+	// The collector and the Kafka consumer expose the monitoring port, and are selected by the Service
+	svc := buildMonitoringService(&goflowKube, testNamespace)
+	assert.Equal(int32(9090), svc.Spec.Ports[0].Port)
+	assert.Empty(controllerstest.UndetectedDrifts(svc))
+	ds := buildDaemonSet(&goflowKube, testNamespace, "digest", &volumes.Builder{}, false)
+	depl := buildConsumerDeployment(&goflowKube, &kafka, testNamespace, "digest", &volumes.Builder{})
+	for _, template := range []corev1.PodTemplateSpec{ds.Spec.Template, depl.Spec.Template} {
+		ports := template.Spec.Containers[0].Ports
+		assert.Equal(corev1.ContainerPort{Name: monitoringPortName, ContainerPort: 9090, Protocol: corev1.ProtocolTCP},
+			ports[len(ports)-1])
+		for k, v := range svc.Spec.Selector {
+			assert.Equal(v, template.Labels[k])
+		}
+	}
+	assert.Equal(buildLabels(), ds.Spec.Selector.MatchLabels)
+	cm, digest := buildConfigMap(&goflowKube, nil, &loki, nil, testNamespace, &volumes.Builder{})
+	var decoded ConfigMap
+	assert.NoError(json.Unmarshal([]byte(cm.Data[configFile]), &decoded))
+	assert.Equal(&MonitoringConfigMap{Port: 9090}, decoded.Monitoring)
+
+	sm := buildServiceMonitor(monitoringServiceName, monitoringPortName, testNamespace)
+	selector, _, _ := unstructured.NestedStringMap(sm.Object, "spec", "selector", "matchLabels")
+	assert.Equal(svc.Labels, selector)
+
+	rule := buildPrometheusRule(&goflowKube, &loki, testNamespace)
+	assert.Equal("PrometheusRule", rule.GetKind())
+	groups, _, _ := unstructured.NestedSlice(rule.Object, "spec", "groups")
+	rules := groups[0].(map[string]interface{})["rules"].([]interface{})
+	alerts := map[string]map[string]interface{}{}
+	for _, r := range rules {
+		alerts[r.(map[string]interface{})["alert"].(string)] = r.(map[string]interface{})
+	}
+	assert.Len(alerts, 4)
+	assert.Equal("600s", alerts["NetObservNoFlows"]["for"])
+	assert.Contains(alerts["NetObservNoFlows"]["expr"], `job="goflow-kube-monitoring",namespace="`+testNamespace+`"`)
+	assert.Contains(alerts["NetObservLokiErrors"]["expr"], "* 100 > 5")
+	assert.Contains(alerts["NetObservUDPDrops"]["expr"], "> 100")
+	assert.Equal("300s", alerts["NetObservCollectorNotReady"]["for"])
+
+	// Thresholds are configurable, and the Loki alert is only defined when the flows are stored in Loki
+	goflowKube.Monitoring.Alerts.UDPDropsPerSecond = 10
+	disabled := false
+	loki.Enable = &disabled
+	updated := buildPrometheusRule(&goflowKube, &loki, testNamespace)
+	groups, _, _ = unstructured.NestedSlice(updated.Object, "spec", "groups")
+	rules = groups[0].(map[string]interface{})["rules"].([]interface{})
+	assert.Len(rules, 3)
+	assert.Contains(rules[1].(map[string]interface{})["expr"], "> 10")
+
+	// Disabling the monitoring removes the port and rolls the pods out
+	goflowKube.Monitoring.Enable = false
+	ds = buildDaemonSet(&goflowKube, testNamespace, "digest", &volumes.Builder{}, false)
+	for _, port := range ds.Spec.Template.Spec.Containers[0].Ports {
+		assert.NotEqual(monitoringPortName, port.Name)
+	}
+	assert.NotContains(ds.Spec.Template.Labels, monitoringLabel)
+	_, updatedDigest := buildConfigMap(&goflowKube, nil, &loki, nil, testNamespace, &volumes.Builder{})
+	assert.NotEqual(digest, updatedDigest)
+}
+
+func TestConfigMapWithExporters(t *testing.T) {
+	assert := assert.New(t)
+
+	goflowKube := getGoflowKubeConfig()
+	loki := getLokiConfig()
+	exporters := []flowsv1beta1.FlowCollectorExporter{{
+		Type: "OpenTelemetry",
+		OpenTelemetry: flowsv1beta1.FlowCollectorOpenTelemetry{
+			Endpoint: "otel-collector:4317",
+			Protocol: "grpc",
+			Headers:  map[string]string{"X-Team": "network"},
+			TLS: flowsv1beta1.ClientTLS{
+				Enable: true,
+				CACert: flowsv1beta1.CertificateReference{Type: "configmap", Name: "otel-ca", CertFile: "ca.crt"},
+			},
+			Metrics: true,
+		},
+	}, {
+		Type: "OpenTelemetry",
+		OpenTelemetry: flowsv1beta1.FlowCollectorOpenTelemetry{
+			Endpoint: "http://otel-collector:4318/",
+			Protocol: "http",
+		},
+	}}
+
+	// Without Kafka, the collector sends the flows to Loki and to every exporter
+	vols := volumes.Builder{}
+	cm, _ := buildConfigMap(&goflowKube, nil, &loki, exporters, "namespace", &vols)
+	var decoded ConfigMap
+	assert.NoError(json.Unmarshal([]byte(cm.Data[configFile]), &decoded))
+	assert.NotNil(decoded.Loki)
+	assert.Equal([]ExporterConfigMap{{
+		Type: "OpenTelemetry",
+		OpenTelemetry: &OpenTelemetryConfigMap{
+			Endpoint: "otel-collector:4317",
+			Protocol: "grpc",
+			Headers:  map[string]string{"X-Team": "network"},
+			TLS:      &TLSConfigMap{CACertPath: "/var/exporter-0-ca/ca.crt"},
+			Logs:     true,
+			Metrics:  true,
+		},
+	}, {
+		Type: "OpenTelemetry",
+		OpenTelemetry: &OpenTelemetryConfigMap{
+			Endpoint: "http://otel-collector:4318/",
+			Protocol: "http",
+			Logs:     true,
+		},
+	}}, decoded.Exporters)
+	assert.Len(vols.GetVolumes(), 1)
+
+	// With Kafka, the consumer sends them
+	kafka := getKafkaConfig()
+	cm, _ = buildConfigMap(&goflowKube, &kafka, &loki, exporters, "namespace", &volumes.Builder{})
+	decoded = ConfigMap{}
+	assert.NoError(json.Unmarshal([]byte(cm.Data[configFile]), &decoded))
+	assert.Empty(decoded.Exporters)
+	cm, _ = buildConsumerConfigMap(&goflowKube, &kafka, &loki, exporters, "namespace", &volumes.Builder{})
+	decoded = ConfigMap{}
+	assert.NoError(json.Unmarshal([]byte(cm.Data[configFile]), &decoded))
+	assert.Len(decoded.Exporters, 2)
 }
 
 func TestAutoScalerUpdateCheck(t *testing.T) {
 	assert := assert.New(t)
 
 	//equals specs
-	autoScalerSpec, goflowKube := getAutoScalerSpecs()
-	assert.Equal(autoScalerNeedsUpdate(&autoScalerSpec, &goflowKube, testNamespace), false)
+	goflowKube := getGoflowKubeConfig()
+	current := controllerstest.AsWritten(buildAutoScaler(&goflowKube, testNamespace))
+	assert.False(reconcilers.NeedsUpdate(current, buildAutoScaler(&goflowKube, testNamespace)))
 
 	//wrong max replicas
-	autoScalerSpec, goflowKube = getAutoScalerSpecs()
-	autoScalerSpec.Spec.MaxReplicas = 10
-	assert.Equal(autoScalerNeedsUpdate(&autoScalerSpec, &goflowKube, testNamespace), true)
+	asc := current.DeepCopyObject().(*ascv1.HorizontalPodAutoscaler)
+	asc.Spec.MaxReplicas = 10
+	assert.True(reconcilers.NeedsUpdate(asc, buildAutoScaler(&goflowKube, testNamespace)))
 
 	//missing min replicas
-	autoScalerSpec, goflowKube = getAutoScalerSpecs()
-	autoScalerSpec.Spec.MinReplicas = nil
-	assert.Equal(autoScalerNeedsUpdate(&autoScalerSpec, &goflowKube, testNamespace), true)
+	asc = current.DeepCopyObject().(*ascv1.HorizontalPodAutoscaler)
+	asc.Spec.MinReplicas = nil
+	assert.True(reconcilers.NeedsUpdate(asc, buildAutoScaler(&goflowKube, testNamespace)))
 
 	//missing min target CPU
-	autoScalerSpec, goflowKube = getAutoScalerSpecs()
-	autoScalerSpec.Spec.TargetCPUUtilizationPercentage = nil
-	assert.Equal(autoScalerNeedsUpdate(&autoScalerSpec, &goflowKube, testNamespace), true)
+	asc = current.DeepCopyObject().(*ascv1.HorizontalPodAutoscaler)
+	asc.Spec.TargetCPUUtilizationPercentage = nil
+	assert.True(reconcilers.NeedsUpdate(asc, buildAutoScaler(&goflowKube, testNamespace)))
 
 	//wrong namespace
-	autoScalerSpec, goflowKube = getAutoScalerSpecs()
-	autoScalerSpec.Namespace = "NewNamespace"
-	assert.Equal(autoScalerNeedsUpdate(&autoScalerSpec, &goflowKube, testNamespace), true)
+	assert.True(reconcilers.NeedsUpdate(current, buildAutoScaler(&goflowKube, "NewNamespace")))
+
+	//any modified field is detected
+	assert.Empty(controllerstest.UndetectedDrifts(buildAutoScaler(&goflowKube, testNamespace)))
+
+	//replicas are left to the autoscaler
+	depl := buildDeployment(&goflowKube, testNamespace, "digest", &volumes.Builder{}, false)
+	assert.Nil(depl.Spec.Replicas)
+	scaled := controllerstest.AsWritten(depl).(*appsv1.Deployment)
+	scaled.Spec.Replicas = helper.Int32Ptr(4)
+	assert.False(reconcilers.NeedsUpdate(scaled, depl))
+
+	goflowKube.HPA = nil
+	goflowKube.Replicas = 2
+	depl = buildDeployment(&goflowKube, testNamespace, "digest", &volumes.Builder{}, false)
+	assert.Equal(helper.Int32Ptr(2), depl.Spec.Replicas)
 }
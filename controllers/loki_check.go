@@ -0,0 +1,242 @@
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/constants"
+	"github.com/netobserv/network-observability-operator/pkg/volumes"
+)
+
+// newLokiChecker returns a function that queries the readiness endpoint of the configured Loki instance, with
+// its TLS and authentication settings. The certificates and credentials are read from the namespace where
+// the components are deployed. In LokiStack mode, the LokiStack status is checked instead, as its gateway
+// doesn't expose the readiness endpoint.
+func newLokiChecker(cl client.Client) func(context.Context, *flowsv1beta1.FlowCollectorLoki, string) error {
+	return func(ctx context.Context, loki *flowsv1beta1.FlowCollectorLoki, ns string) error {
+		if loki.Mode == constants.LokiModeLokiStack {
+			return checkLokiStack(ctx, cl, &loki.LokiStack)
+		}
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(loki.URL, "/")+"/ready", nil)
+		if err != nil {
+			return err
+		}
+		refs := referenceReader{Client: cl, namespace: ns}
+		httpClient, err := refs.lokiHTTPClient(ctx, &loki.TLS)
+		if err != nil {
+			return err
+		}
+		if err := refs.setLokiAuth(ctx, req, &loki.Auth); err != nil {
+			return err
+		}
+		if loki.TenantID != "" {
+			req.Header.Set("X-Scope-OrgID", loki.TenantID)
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("unexpected status from %s: %s", req.URL, resp.Status)
+		}
+		return nil
+	}
+}
+
+// lokiProbe runs the Loki reachability checks in the background, so that a slow or unreachable Loki doesn't hold
+// the reconciliation. The result of the last check is kept for the LokiReachable condition: the settings are
+// checked again when they change, or once the result is older than lokiCheckInterval.
+type lokiProbe struct {
+	mutex    sync.Mutex
+	settings string
+	result   error
+	checked  time.Time
+	running  bool
+	// notify, when set, is called once a check completes, to reconcile the FlowCollector with its result
+	notify func(name string)
+}
+
+// reachability returns the result of the last check of the provided Loki settings, and whether there is one. A
+// new check is started in the background when there is no result yet, or when it is outdated.
+func (p *lokiProbe) reachability(check func(context.Context, *flowsv1beta1.FlowCollectorLoki, string) error,
+	fcName string, loki *flowsv1beta1.FlowCollectorLoki, ns string) (bool, error) {
+	settings, err := json.Marshal(struct {
+		Loki      *flowsv1beta1.FlowCollectorLoki
+		Namespace string
+	}{loki, ns})
+	if err != nil {
+		return true, err
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.settings != string(settings) {
+		p.settings = string(settings)
+		p.result = nil
+		p.checked = time.Time{}
+	}
+	if !p.running && time.Since(p.checked) >= lokiCheckInterval {
+		p.running = true
+		go p.run(check, fcName, loki.DeepCopy(), ns, string(settings))
+	}
+	return !p.checked.IsZero(), p.result
+}
+
+func (p *lokiProbe) run(check func(context.Context, *flowsv1beta1.FlowCollectorLoki, string) error,
+	fcName string, loki *flowsv1beta1.FlowCollectorLoki, ns, settings string) {
+	err := check(context.Background(), loki, ns)
+	p.mutex.Lock()
+	// The result of outdated settings is dropped: the next reconciliation checks the new ones
+	if p.settings == settings {
+		p.result = err
+		p.checked = time.Now()
+	}
+	p.running = false
+	p.mutex.Unlock()
+	if p.notify != nil {
+		p.notify(fcName)
+	}
+}
+
+// checkLokiStack returns an error unless the referenced LokiStack reports a Ready condition. The LokiStack is
+// read as unstructured, so that the Loki operator API isn't a dependency.
+func checkLokiStack(ctx context.Context, cl client.Client, ref *flowsv1beta1.LokiStackRef) error {
+	stack := unstructured.Unstructured{}
+	stack.SetGroupVersionKind(schema.GroupVersionKind{Group: "loki.grafana.com", Version: "v1", Kind: "LokiStack"})
+	if err := cl.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}, &stack); err != nil {
+		return err
+	}
+	conds, _, err := unstructured.NestedSlice(stack.Object, "status", "conditions")
+	if err != nil {
+		return err
+	}
+	for _, c := range conds {
+		cond, ok := c.(map[string]interface{})
+		if !ok || cond["type"] != "Ready" {
+			continue
+		}
+		if cond["status"] == string(metav1.ConditionTrue) {
+			return nil
+		}
+		return fmt.Errorf("LokiStack %s/%s is not ready: %v", ref.Namespace, ref.Name, cond["message"])
+	}
+	return fmt.Errorf("LokiStack %s/%s is not ready", ref.Namespace, ref.Name)
+}
+
+// referenceReader reads the certificates and credentials referenced in the FlowCollector
+type referenceReader struct {
+	client.Client
+	namespace string
+}
+
+func (r *referenceReader) lokiHTTPClient(ctx context.Context, clientTLS *flowsv1beta1.ClientTLS) (*http.Client, error) {
+	if !clientTLS.Enable {
+		return http.DefaultClient, nil
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: clientTLS.InsecureSkipVerify,
+	}
+	if !clientTLS.InsecureSkipVerify && clientTLS.CACert.Name != "" {
+		caCert, err := r.readCertificateFile(ctx, &clientTLS.CACert, clientTLS.CACert.CertFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no valid certificate found in %s %s", clientTLS.CACert.Type, clientTLS.CACert.Name)
+		}
+	}
+	if clientTLS.UserCert.Name != "" {
+		cert, err := r.readCertificateFile(ctx, &clientTLS.UserCert, clientTLS.UserCert.CertFile)
+		if err != nil {
+			return nil, err
+		}
+		key, err := r.readCertificateFile(ctx, &clientTLS.UserCert, clientTLS.UserCert.CertKey)
+		if err != nil {
+			return nil, err
+		}
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{pair}
+	}
+	// The transport is built for a single check, as the certificates may change in between: the connection isn't
+	// kept alive, as it would be left open for the life of the operator
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig, DisableKeepAlives: true}}, nil
+}
+
+func (r *referenceReader) setLokiAuth(ctx context.Context, req *http.Request, auth *flowsv1beta1.LokiAuth) error {
+	switch auth.Type {
+	case "BearerToken":
+		token, err := r.readSecretKey(ctx, auth.SecretName, auth.TokenKey)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	case "ServiceAccountToken":
+		// The operator uses its own token, when running in a pod
+		if token, err := os.ReadFile(volumes.ServiceAccountTokenPath); err == nil {
+			req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+		}
+	case "BasicAuth":
+		username, err := r.readSecretKey(ctx, auth.SecretName, auth.UsernameKey)
+		if err != nil {
+			return err
+		}
+		password, err := r.readSecretKey(ctx, auth.SecretName, auth.PasswordKey)
+		if err != nil {
+			return err
+		}
+		req.SetBasicAuth(string(username), string(password))
+	}
+	return nil
+}
+
+func (r *referenceReader) readCertificateFile(ctx context.Context, ref *flowsv1beta1.CertificateReference, file string) ([]byte, error) {
+	if ref.Type == "configmap" {
+		cm := corev1.ConfigMap{}
+		if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: r.namespace}, &cm); err != nil {
+			return nil, err
+		}
+		if data, ok := cm.Data[file]; ok {
+			return []byte(data), nil
+		}
+		if data, ok := cm.BinaryData[file]; ok {
+			return data, nil
+		}
+		return nil, fmt.Errorf("key %s not found in configmap %s", file, ref.Name)
+	}
+	return r.readSecretKey(ctx, ref.Name, file)
+}
+
+func (r *referenceReader) readSecretKey(ctx context.Context, name, key string) ([]byte, error) {
+	secret := corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: r.namespace}, &secret); err != nil {
+		return nil, err
+	}
+	data, ok := secret.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("key %s not found in secret %s", key, name)
+	}
+	return data, nil
+}
@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+)
+
+func TestCheckLokiWithTLSAndAuth(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ready" || r.Header.Get("Authorization") != "Bearer s3cr3t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	scheme := runtime.NewScheme()
+	require.NoError(clientgoscheme.AddToScheme(scheme))
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "loki-ca", Namespace: "netobserv"},
+			Data:       map[string]string{"ca.crt": string(caPEM)},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "loki-token", Namespace: "netobserv"},
+			Data:       map[string][]byte{"token": []byte("s3cr3t\n")},
+		},
+	).Build()
+	checkLoki := newLokiChecker(cl)
+	loki := flowsv1beta1.FlowCollectorLoki{
+		URL: server.URL + "/",
+		TLS: flowsv1beta1.ClientTLS{
+			Enable: true,
+			CACert: flowsv1beta1.CertificateReference{Type: "configmap", Name: "loki-ca", CertFile: "ca.crt"},
+		},
+		Auth: flowsv1beta1.LokiAuth{Type: "BearerToken", SecretName: "loki-token", TokenKey: "token"},
+	}
+	assert.NoError(checkLoki(context.Background(), &loki, "netobserv"))
+
+	// Without the token
+	noAuth := loki
+	noAuth.Auth = flowsv1beta1.LokiAuth{Type: "None"}
+	err := checkLoki(context.Background(), &noAuth, "netobserv")
+	require.Error(err)
+	assert.Contains(err.Error(), "401")
+
+	// Without the CA
+	noCA := loki
+	noCA.TLS = flowsv1beta1.ClientTLS{Enable: true}
+	assert.Error(checkLoki(context.Background(), &noCA, "netobserv"))
+	noCA.TLS.InsecureSkipVerify = true
+	assert.NoError(checkLoki(context.Background(), &noCA, "netobserv"))
+
+	// The certificates must be in the collector namespace
+	err = checkLoki(context.Background(), &loki, "other")
+	require.Error(err)
+	assert.Contains(err.Error(), "not found")
+
+	// The connections of a check aren't kept open
+	refs := referenceReader{Client: cl, namespace: "netobserv"}
+	httpClient, err := refs.lokiHTTPClient(context.Background(), &loki.TLS)
+	require.NoError(err)
+	transport, ok := httpClient.Transport.(*http.Transport)
+	require.True(ok)
+	assert.True(transport.DisableKeepAlives)
+}
+
+func TestCheckLokiStack(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	stack := &unstructured.Unstructured{}
+	stack.SetGroupVersionKind(schema.GroupVersionKind{Group: "loki.grafana.com", Version: "v1", Kind: "LokiStack"})
+	stack.SetName("lokistack")
+	stack.SetNamespace("openshift-logging")
+	require.NoError(unstructured.SetNestedSlice(stack.Object, []interface{}{
+		map[string]interface{}{"type": "Pending", "status": "False"},
+		map[string]interface{}{"type": "Ready", "status": "False", "message": "Some LokiStack components pending"},
+	}, "status", "conditions"))
+	cl := fake.NewClientBuilder().WithObjects(stack).Build()
+	checkLoki := newLokiChecker(cl)
+	loki := flowsv1beta1.FlowCollectorLoki{
+		Mode:      "LokiStack",
+		LokiStack: flowsv1beta1.LokiStackRef{Name: "lokistack", Namespace: "openshift-logging"},
+	}
+	err := checkLoki(context.Background(), &loki, "netobserv")
+	require.Error(err)
+	assert.Contains(err.Error(), "Some LokiStack components pending")
+
+	require.NoError(unstructured.SetNestedSlice(stack.Object, []interface{}{
+		map[string]interface{}{"type": "Ready", "status": "True"},
+	}, "status", "conditions"))
+	require.NoError(cl.Update(context.Background(), stack))
+	assert.NoError(checkLoki(context.Background(), &loki, "netobserv"))
+
+	loki.LokiStack.Name = "other"
+	assert.Error(checkLoki(context.Background(), &loki, "netobserv"))
+}
+
+func TestLokiProbeRunsInBackground(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	release := make(chan error)
+	check := func(context.Context, *flowsv1beta1.FlowCollectorLoki, string) error {
+		return <-release
+	}
+	notified := make(chan string, 1)
+	probe := lokiProbe{notify: func(name string) { notified <- name }}
+	loki := flowsv1beta1.FlowCollectorLoki{URL: "http://loki:3100/"}
+
+	// The check doesn't block the caller: there is no result until it completes
+	checked, _ := probe.reachability(check, "cluster", &loki, "netobserv")
+	assert.False(checked)
+	release <- errors.New("connection refused")
+	assert.Equal("cluster", <-notified)
+	checked, err := probe.reachability(check, "cluster", &loki, "netobserv")
+	assert.True(checked)
+	require.Error(err)
+	assert.Contains(err.Error(), "connection refused")
+
+	// The result of other settings isn't reused
+	loki.URL = "http://other-loki:3100/"
+	checked, _ = probe.reachability(check, "cluster", &loki, "netobserv")
+	assert.False(checked)
+	release <- nil
+	<-notified
+	checked, err = probe.reachability(check, "cluster", &loki, "netobserv")
+	assert.True(checked)
+	assert.NoError(err)
+}
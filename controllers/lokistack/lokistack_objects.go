@@ -0,0 +1,119 @@
+package lokistack
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/constants"
+)
+
+// TenantName is the LokiStack tenant where the flows are stored, as expected by the gateway in openshift-network mode
+const TenantName = "network"
+
+const (
+	// caBundleName is the copy of the gateway CA bundle in the namespace where the components are deployed.
+	// It is named differently from the LokiStack one, as both can be in the same namespace.
+	caBundleName = "netobserv-loki-gateway-ca-bundle"
+	caBundleFile = "service-ca.crt"
+	writerName   = "netobserv-loki-writer"
+	readerName   = "netobserv-loki-reader"
+)
+
+func buildLabels() map[string]string {
+	return map[string]string{
+		"app": "netobserv-lokistack",
+	}
+}
+
+// Namespace returns the namespace of the referenced LokiStack
+func Namespace(loki *flowsv1beta1.FlowCollectorLoki, ns string) string {
+	if loki.LokiStack.Namespace != "" {
+		return loki.LokiStack.Namespace
+	}
+	return ns
+}
+
+// GatewayCABundleName returns the name of the ConfigMap holding the CA of the gateway of the named LokiStack,
+// as created by the Loki operator
+func GatewayCABundleName(name string) string {
+	return name + "-gateway-ca-bundle"
+}
+
+// Resolve returns the Loki configuration to use by the components deployed in the provided namespace. In
+// LokiStack mode, the connection settings are derived from the LokiStack: URLs of its gateway, tenant, TLS
+// with the gateway CA and service account token authentication. In Manual mode, the configuration is
+// returned unchanged.
+func Resolve(loki *flowsv1beta1.FlowCollectorLoki, ns string) *flowsv1beta1.FlowCollectorLoki {
+	if loki.Mode != constants.LokiModeLokiStack {
+		return loki
+	}
+	resolved := loki.DeepCopy()
+	resolved.LokiStack.Namespace = Namespace(loki, ns)
+	resolved.URL = fmt.Sprintf("https://%s-gateway-http.%s.svc:8080/api/logs/v1/%s/",
+		loki.LokiStack.Name, resolved.LokiStack.Namespace, TenantName)
+	resolved.QuerierURL = ""
+	resolved.TenantID = TenantName
+	resolved.TLS = flowsv1beta1.ClientTLS{
+		Enable: true,
+		CACert: flowsv1beta1.CertificateReference{
+			Type:     "configmap",
+			Name:     caBundleName,
+			CertFile: caBundleFile,
+		},
+	}
+	resolved.Auth = flowsv1beta1.LokiAuth{Type: "ServiceAccountToken"}
+	return resolved
+}
+
+func buildCABundle(source *corev1.ConfigMap, ns string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      caBundleName,
+			Namespace: ns,
+			Labels:    buildLabels(),
+		},
+		Data: map[string]string{
+			caBundleFile: source.Data[caBundleFile],
+		},
+	}
+}
+
+// buildClusterRole returns the role required by the gateway to write (create verb) or read (get verb) the
+// flows of the network tenant
+func buildClusterRole(name, verb string) *rbacv1.ClusterRole {
+	return &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: buildLabels(),
+		},
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups:     []string{"loki.grafana.com"},
+			Resources:     []string{TenantName},
+			ResourceNames: []string{"logs"},
+			Verbs:         []string{verb},
+		}},
+	}
+}
+
+func buildClusterRoleBinding(name, serviceAccount, ns string) *rbacv1.ClusterRoleBinding {
+	return &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: buildLabels(),
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "ClusterRole",
+			Name:     name,
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      "ServiceAccount",
+			Name:      serviceAccount,
+			Namespace: ns,
+		}},
+	}
+}
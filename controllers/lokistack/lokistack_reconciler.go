@@ -0,0 +1,138 @@
+// Package lokistack manages the resources required to use a LokiStack as the flows storage: the copy of the
+// gateway CA bundle, and the permissions of the components on the network tenant
+package lokistack
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/constants"
+	"github.com/netobserv/network-observability-operator/controllers/reconcilers"
+	"github.com/netobserv/network-observability-operator/pkg/helper"
+)
+
+// The operator can only grant the permissions it holds
+//+kubebuilder:rbac:groups=loki.grafana.com,resources=network,resourceNames=logs,verbs=get;create
+//+kubebuilder:rbac:groups=loki.grafana.com,resources=lokistacks,verbs=get
+
+// Reconciler reconciles the LokiStack related resources with the desired configuration
+type Reconciler struct {
+	reconcilers.ClientHelper
+	nobjMngr *reconcilers.NamespacedObjectManager
+	owned    ownedObjects
+}
+
+type ownedObjects struct {
+	caBundle *corev1.ConfigMap
+}
+
+func NewReconciler(cl reconcilers.ClientHelper, ns, prevNS string) Reconciler {
+	owned := ownedObjects{
+		caBundle: &corev1.ConfigMap{},
+	}
+	nobjMngr := reconcilers.NewNamespacedObjectManager(cl, ns, prevNS)
+	nobjMngr.AddManagedObject(caBundleName, owned.caBundle)
+
+	return Reconciler{ClientHelper: cl, nobjMngr: nobjMngr, owned: owned}
+}
+
+// PrepareNamespaceChange cleans up old namespace
+func (r *Reconciler) PrepareNamespaceChange(ctx context.Context) {
+	r.nobjMngr.CleanupNamespace(ctx)
+}
+
+// Reconcile copies the CA bundle of the LokiStack gateway and grants the components access to the network
+// tenant when Loki is enabled in LokiStack mode. Otherwise, these resources are removed.
+func (r *Reconciler) Reconcile(ctx context.Context, loki *flowsv1beta1.FlowCollectorLoki) error {
+	if err := r.nobjMngr.FetchAll(ctx); err != nil {
+		return err
+	}
+	if !helper.LokiEnabled(loki) || loki.Mode != constants.LokiModeLokiStack {
+		r.nobjMngr.TryDelete(ctx, r.owned.caBundle)
+		return r.CleanupClusterResources(ctx)
+	}
+	if err := r.reconcileCABundle(ctx, loki); err != nil {
+		return err
+	}
+	return r.reconcilePermissions(ctx)
+}
+
+// CleanupClusterResources deletes the cluster-scoped resources, which are not reliably garbage collected
+// through their owner references
+func (r *Reconciler) CleanupClusterResources(ctx context.Context) error {
+	for _, name := range []string{writerName, readerName} {
+		meta := metav1.ObjectMeta{Name: name}
+		if err := r.DeleteIfExists(ctx, &rbacv1.ClusterRoleBinding{ObjectMeta: meta}); err != nil {
+			return err
+		}
+		if err := r.DeleteIfExists(ctx, &rbacv1.ClusterRole{ObjectMeta: meta}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcileCABundle copies the gateway CA bundle, which can live in another namespace, next to the components
+// that mount it
+func (r *Reconciler) reconcileCABundle(ctx context.Context, loki *flowsv1beta1.FlowCollectorLoki) error {
+	ns := r.nobjMngr.Namespace
+	source := corev1.ConfigMap{}
+	sourceKey := types.NamespacedName{Name: GatewayCABundleName(loki.LokiStack.Name), Namespace: Namespace(loki, ns)}
+	if err := r.Get(ctx, sourceKey, &source); err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("gateway CA bundle %s not found: is LokiStack %s deployed in namespace %s?",
+				sourceKey.Name, loki.LokiStack.Name, sourceKey.Namespace)
+		}
+		return err
+	}
+	newCABundle := buildCABundle(&source, ns)
+	if !r.nobjMngr.Exists(r.owned.caBundle) {
+		return r.CreateOwned(ctx, newCABundle)
+	}
+	if r.owned.caBundle.Data[caBundleFile] != newCABundle.Data[caBundleFile] {
+		return r.UpdateOwned(ctx, newCABundle)
+	}
+	return nil
+}
+
+// reconcilePermissions allows goflow-kube (including the Kafka consumer, which runs with the same service
+// account) to push the flows, and the console plugin to query them
+func (r *Reconciler) reconcilePermissions(ctx context.Context) error {
+	ns := r.nobjMngr.Namespace
+	for _, perm := range []struct{ name, verb, serviceAccount string }{
+		{name: writerName, verb: "create", serviceAccount: constants.GoflowKubeName},
+		{name: readerName, verb: "get", serviceAccount: constants.PluginName},
+	} {
+		if err := r.Get(ctx, types.NamespacedName{Name: perm.name}, &rbacv1.ClusterRole{}); err != nil {
+			if !errors.IsNotFound(err) {
+				return err
+			}
+			if err := r.CreateOwned(ctx, buildClusterRole(perm.name, perm.verb)); err != nil {
+				return err
+			}
+		}
+		// Cluster role bindings have to be updated when namespace changes (they are not namespace-scoped)
+		newCRB := buildClusterRoleBinding(perm.name, perm.serviceAccount, ns)
+		crb := rbacv1.ClusterRoleBinding{}
+		if err := r.Get(ctx, types.NamespacedName{Name: perm.name}, &crb); err != nil {
+			if !errors.IsNotFound(err) {
+				return err
+			}
+			if err := r.CreateOwned(ctx, newCRB); err != nil {
+				return err
+			}
+		} else if len(crb.Subjects) != 1 || crb.Subjects[0].Namespace != ns {
+			if err := r.UpdateOwned(ctx, newCRB); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package lokistack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+)
+
+func TestResolveManual(t *testing.T) {
+	loki := flowsv1beta1.FlowCollectorLoki{Mode: "Manual", URL: "http://loki:3100/", TenantID: "netobserv"}
+	assert.Same(t, &loki, Resolve(&loki, "netobserv"))
+}
+
+func TestResolveLokiStack(t *testing.T) {
+	assert := assert.New(t)
+
+	loki := flowsv1beta1.FlowCollectorLoki{
+		Mode:       "LokiStack",
+		LokiStack:  flowsv1beta1.LokiStackRef{Name: "lokistack"},
+		URL:        "http://loki:3100/",
+		QuerierURL: "http://loki-querier:3100/",
+		TenantID:   "netobserv",
+		Auth:       flowsv1beta1.LokiAuth{Type: "BasicAuth", SecretName: "loki-credentials"},
+		BatchSize:  1000,
+	}
+	resolved := Resolve(&loki, "netobserv")
+	assert.Equal("https://lokistack-gateway-http.netobserv.svc:8080/api/logs/v1/network/", resolved.URL)
+	assert.Empty(resolved.QuerierURL)
+	assert.Equal("network", resolved.TenantID)
+	assert.Equal(flowsv1beta1.ClientTLS{
+		Enable: true,
+		CACert: flowsv1beta1.CertificateReference{Type: "configmap", Name: caBundleName, CertFile: "service-ca.crt"},
+	}, resolved.TLS)
+	assert.Equal(flowsv1beta1.LokiAuth{Type: "ServiceAccountToken"}, resolved.Auth)
+	// Other settings are kept, and the original configuration is unchanged
+	assert.Equal(int64(1000), resolved.BatchSize)
+	assert.Equal("http://loki:3100/", loki.URL)
+
+	loki.LokiStack.Namespace = "openshift-logging"
+	resolved = Resolve(&loki, "netobserv")
+	assert.Equal("https://lokistack-gateway-http.openshift-logging.svc:8080/api/logs/v1/network/", resolved.URL)
+	assert.Equal("openshift-logging", resolved.LokiStack.Namespace)
+}
@@ -0,0 +1,219 @@
+package managedloki
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/constants"
+	"github.com/netobserv/network-observability-operator/controllers/reconcilers"
+)
+
+// PodConfigurationDigest is an annotation name to facilitate pod restart after
+// any external configuration change
+const PodConfigurationDigest = "flows.netobserv.io/loki-config"
+
+const (
+	name        = "netobserv-loki"
+	configName  = "netobserv-loki-config"
+	configFile  = "local-config.yaml"
+	configDir   = "/etc/loki/"
+	storageName = "loki-store"
+	storageDir  = "/loki-store"
+	port        = 3100
+)
+
+// configTemplate is the configuration of a single-binary Loki storing the flows on the local filesystem. The
+// compactor applies the retention period.
+const configTemplate = `auth_enabled: false
+server:
+  http_listen_port: %[1]d
+  grpc_listen_port: 9096
+common:
+  path_prefix: %[2]s
+  storage:
+    filesystem:
+      chunks_directory: %[2]s/chunks
+      rules_directory: %[2]s/rules
+  replication_factor: 1
+  ring:
+    kvstore:
+      store: inmemory
+schema_config:
+  configs:
+    - from: 2020-10-24
+      store: boltdb-shipper
+      object_store: filesystem
+      schema: v11
+      index:
+        prefix: index_
+        period: 24h
+compactor:
+  working_directory: %[2]s/compactor
+  shared_store: filesystem
+  retention_enabled: true
+limits_config:
+  retention_period: %[3]dh
+  reject_old_samples: true
+  reject_old_samples_max_age: 168h
+`
+
+func buildLabels() map[string]string {
+	return map[string]string{
+		"app": name,
+	}
+}
+
+// Resolve returns the Loki configuration to use by the components deployed in the provided namespace. In
+// Deploy mode, they are connected to the Loki deployed by the operator. In other modes, the configuration is
+// returned unchanged.
+func Resolve(loki *flowsv1beta1.FlowCollectorLoki, ns string) *flowsv1beta1.FlowCollectorLoki {
+	if loki.Mode != constants.LokiModeDeploy {
+		return loki
+	}
+	resolved := loki.DeepCopy()
+	resolved.URL = fmt.Sprintf("http://%s.%s.svc:%d/", name, ns, port)
+	resolved.QuerierURL = ""
+	resolved.TenantID = ""
+	resolved.TLS = flowsv1beta1.ClientTLS{}
+	resolved.Auth = flowsv1beta1.LokiAuth{Type: "None"}
+	return resolved
+}
+
+func buildConfigMap(desired *flowsv1beta1.FlowCollectorLokiDeploy, ns string) (*corev1.ConfigMap, string) {
+	config := fmt.Sprintf(configTemplate, port, storageDir, desired.RetentionPeriod.Duration/time.Hour)
+	hasher := fnv.New64a()
+	_, _ = hasher.Write([]byte(config))
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configName,
+			Namespace: ns,
+			Labels:    buildLabels(),
+		},
+		Data: map[string]string{
+			configFile: config,
+		},
+	}, strconv.FormatUint(hasher.Sum64(), 36)
+}
+
+// buildStatefulSet returns the Loki StatefulSet. Its storage is a PersistentVolumeClaim managed by the operator
+// rather than a volume claim template, so that it is garbage collected with the FlowCollector.
+func buildStatefulSet(desired *flowsv1beta1.FlowCollectorLokiDeploy, ns, configDigest string) *appsv1.StatefulSet {
+	replicas := int32(1)
+	spec := corev1.PodSpec{
+		Containers: []corev1.Container{{
+			Name:            name,
+			Image:           desired.Image,
+			ImagePullPolicy: corev1.PullPolicy(desired.ImagePullPolicy),
+			Args:            []string{"-config.file=" + configDir + configFile},
+			Ports: []corev1.ContainerPort{{
+				Name:          "http",
+				ContainerPort: port,
+				Protocol:      corev1.ProtocolTCP,
+			}},
+			ReadinessProbe: &corev1.Probe{
+				Handler: corev1.Handler{
+					HTTPGet: &corev1.HTTPGetAction{Path: "/ready", Port: intstr.FromInt(port)},
+				},
+				InitialDelaySeconds: 15,
+			},
+			Resources: *desired.Resources.DeepCopy(),
+			VolumeMounts: []corev1.VolumeMount{{
+				Name:      configName,
+				MountPath: configDir,
+			}, {
+				Name:      storageName,
+				MountPath: storageDir,
+			}},
+		}},
+		Volumes: []corev1.Volume{{
+			Name: configName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: configName},
+				},
+			},
+		}, {
+			Name: storageName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: name},
+			},
+		}},
+	}
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels:    buildLabels(),
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:    &replicas,
+			ServiceName: name,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: buildLabels(),
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: buildLabels(),
+					Annotations: map[string]string{
+						PodConfigurationDigest: configDigest + "-" + reconcilers.PodSpecDigest(&spec),
+					},
+				},
+				Spec: spec,
+			},
+		},
+	}
+}
+
+func buildPersistentVolumeClaim(desired *flowsv1beta1.FlowCollectorLokiDeploy, ns string) *corev1.PersistentVolumeClaim {
+	pvc := corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels:    buildLabels(),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: desired.StorageSize},
+			},
+		},
+	}
+	if desired.StorageClassName != "" {
+		pvc.Spec.StorageClassName = &desired.StorageClassName
+	}
+	return &pvc
+}
+
+func buildService(ns string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels:    buildLabels(),
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: buildLabels(),
+			Ports: []corev1.ServicePort{{
+				Name:       "http",
+				Port:       port,
+				Protocol:   corev1.ProtocolTCP,
+				TargetPort: intstr.FromInt(port),
+			}},
+		},
+	}
+}
+
+// pvcNeedsUpdate tells whether the storage size has been increased: volumes can't be shrunk
+func pvcNeedsUpdate(pvc *corev1.PersistentVolumeClaim, desired *corev1.PersistentVolumeClaim) bool {
+	current := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	requested := desired.Spec.Resources.Requests[corev1.ResourceStorage]
+	return requested.Cmp(current) > 0
+}
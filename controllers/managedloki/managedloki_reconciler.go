@@ -0,0 +1,114 @@
+// Package managedloki deploys a single-binary Loki for development and small clusters
+package managedloki
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/constants"
+	"github.com/netobserv/network-observability-operator/controllers/reconcilers"
+	"github.com/netobserv/network-observability-operator/pkg/helper"
+)
+
+//+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+
+// Reconciler reconciles the current Loki state with the desired configuration
+type Reconciler struct {
+	reconcilers.ClientHelper
+	nobjMngr *reconcilers.NamespacedObjectManager
+	owned    ownedObjects
+}
+
+type ownedObjects struct {
+	statefulSet *appsv1.StatefulSet
+	configMap   *corev1.ConfigMap
+	pvc         *corev1.PersistentVolumeClaim
+	service     *corev1.Service
+}
+
+func NewReconciler(cl reconcilers.ClientHelper, ns, prevNS string) Reconciler {
+	owned := ownedObjects{
+		statefulSet: &appsv1.StatefulSet{},
+		configMap:   &corev1.ConfigMap{},
+		pvc:         &corev1.PersistentVolumeClaim{},
+		service:     &corev1.Service{},
+	}
+	nobjMngr := reconcilers.NewNamespacedObjectManager(cl, ns, prevNS)
+	nobjMngr.AddManagedObject(name, owned.statefulSet)
+	nobjMngr.AddManagedObject(configName, owned.configMap)
+	nobjMngr.AddManagedObject(name, owned.pvc)
+	nobjMngr.AddManagedObject(name, owned.service)
+
+	return Reconciler{ClientHelper: cl, nobjMngr: nobjMngr, owned: owned}
+}
+
+// PrepareNamespaceChange cleans up old namespace. The stored flows are lost.
+func (r *Reconciler) PrepareNamespaceChange(ctx context.Context) {
+	r.nobjMngr.CleanupNamespace(ctx)
+}
+
+// Reconcile deploys Loki when it is enabled in Deploy mode. Otherwise, the deployed Loki and its storage are
+// removed.
+func (r *Reconciler) Reconcile(ctx context.Context, loki *flowsv1beta1.FlowCollectorLoki) error {
+	if err := r.nobjMngr.FetchAll(ctx); err != nil {
+		return err
+	}
+	if !helper.LokiEnabled(loki) || loki.Mode != constants.LokiModeDeploy {
+		r.nobjMngr.TryDelete(ctx, r.owned.statefulSet)
+		r.nobjMngr.TryDelete(ctx, r.owned.configMap)
+		r.nobjMngr.TryDelete(ctx, r.owned.pvc)
+		r.nobjMngr.TryDelete(ctx, r.owned.service)
+		return nil
+	}
+	ns := r.nobjMngr.Namespace
+	desired := &loki.Deploy
+
+	newCM, configDigest := buildConfigMap(desired, ns)
+	if !r.nobjMngr.Exists(r.owned.configMap) {
+		if err := r.CreateOwned(ctx, newCM); err != nil {
+			return err
+		}
+	} else if reconcilers.NeedsUpdate(r.owned.configMap, newCM) {
+		if err := r.UpdateOwned(ctx, newCM); err != nil {
+			return err
+		}
+	}
+
+	newPVC := buildPersistentVolumeClaim(desired, ns)
+	if !r.nobjMngr.Exists(r.owned.pvc) {
+		if err := r.CreateOwned(ctx, newPVC); err != nil {
+			return err
+		}
+	} else if pvcNeedsUpdate(r.owned.pvc, newPVC) {
+		// Other fields of a bound claim are immutable, including those set by the server
+		expanded := newPVC.DeepCopy()
+		expanded.Spec = *r.owned.pvc.Spec.DeepCopy()
+		expanded.Spec.Resources.Requests = newPVC.Spec.Resources.Requests
+		if err := r.UpdateOwned(ctx, expanded); err != nil {
+			return err
+		}
+	}
+
+	newSVC := buildService(ns)
+	if !r.nobjMngr.Exists(r.owned.service) {
+		if err := r.CreateOwned(ctx, newSVC); err != nil {
+			return err
+		}
+	} else if reconcilers.NeedsUpdate(r.owned.service, newSVC) {
+		if err := r.UpdateOwned(ctx, newSVC); err != nil {
+			return err
+		}
+	}
+
+	newSS := buildStatefulSet(desired, ns, configDigest)
+	if !r.nobjMngr.Exists(r.owned.statefulSet) {
+		return r.CreateOwned(ctx, newSS)
+	} else if reconcilers.NeedsUpdate(r.owned.statefulSet, newSS) {
+		return r.UpdateOwned(ctx, newSS)
+	}
+	return nil
+}
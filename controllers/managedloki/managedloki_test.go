@@ -0,0 +1,99 @@
+package managedloki
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/controllerstest"
+	"github.com/netobserv/network-observability-operator/controllers/reconcilers"
+)
+
+func getDeployConfig() flowsv1beta1.FlowCollectorLokiDeploy {
+	return flowsv1beta1.FlowCollectorLokiDeploy{
+		Image:           "grafana/loki:2.4.1",
+		ImagePullPolicy: "IfNotPresent",
+		StorageSize:     resource.MustParse("10Gi"),
+		RetentionPeriod: metav1.Duration{Duration: 72 * time.Hour},
+	}
+}
+
+func TestResolve(t *testing.T) {
+	assert := assert.New(t)
+
+	loki := flowsv1beta1.FlowCollectorLoki{
+		Mode:     "Manual",
+		URL:      "https://loki:3100/",
+		TenantID: "netobserv",
+		Auth:     flowsv1beta1.LokiAuth{Type: "BearerToken", SecretName: "loki-token"},
+	}
+	assert.Same(&loki, Resolve(&loki, "netobserv"))
+
+	loki.Mode = "Deploy"
+	resolved := Resolve(&loki, "netobserv")
+	assert.Equal("http://netobserv-loki.netobserv.svc:3100/", resolved.URL)
+	assert.Empty(resolved.TenantID)
+	assert.False(resolved.TLS.Enable)
+	assert.Equal("None", resolved.Auth.Type)
+	assert.Equal("https://loki:3100/", loki.URL)
+}
+
+func TestConfigMapRetention(t *testing.T) {
+	assert := assert.New(t)
+
+	deploy := getDeployConfig()
+	cm, digest := buildConfigMap(&deploy, "netobserv")
+	assert.Contains(cm.Data[configFile], "retention_period: 72h\n")
+
+	deploy.RetentionPeriod.Duration = 24 * time.Hour
+	cm, newDigest := buildConfigMap(&deploy, "netobserv")
+	assert.Contains(cm.Data[configFile], "retention_period: 24h\n")
+	assert.NotEqual(digest, newDigest)
+}
+
+func TestStatefulSet(t *testing.T) {
+	assert := assert.New(t)
+
+	deploy := getDeployConfig()
+	ss := buildStatefulSet(&deploy, "netobserv", "digest")
+	assert.Equal(int32(1), *ss.Spec.Replicas)
+	container := ss.Spec.Template.Spec.Containers[0]
+	assert.Equal([]string{"-config.file=/etc/loki/local-config.yaml"}, container.Args)
+	assert.Equal("netobserv-loki", ss.Spec.Template.Spec.Volumes[1].PersistentVolumeClaim.ClaimName)
+	assert.True(strings.HasPrefix(ss.Spec.Template.Annotations[PodConfigurationDigest], "digest-"))
+
+	// Unchanged configuration
+	current := controllerstest.AsWritten(ss)
+	assert.False(reconcilers.NeedsUpdate(current, buildStatefulSet(&deploy, "netobserv", "digest")))
+	// Changed configuration file
+	assert.True(reconcilers.NeedsUpdate(current, buildStatefulSet(&deploy, "netobserv", "other")))
+	// Modified fields
+	assert.Empty(controllerstest.UndetectedDrifts(ss))
+	assert.Empty(controllerstest.UndetectedDrifts(buildService("netobserv")))
+	// Changed pod spec
+	deploy.Image = "grafana/loki:2.5.0"
+	assert.True(reconcilers.NeedsUpdate(current, buildStatefulSet(&deploy, "netobserv", "digest")))
+}
+
+func TestPersistentVolumeClaimNeedsUpdate(t *testing.T) {
+	assert := assert.New(t)
+
+	deploy := getDeployConfig()
+	pvc := buildPersistentVolumeClaim(&deploy, "netobserv")
+	assert.Nil(pvc.Spec.StorageClassName)
+	deploy.StorageClassName = "gp2"
+	assert.Equal("gp2", *buildPersistentVolumeClaim(&deploy, "netobserv").Spec.StorageClassName)
+
+	deploy.StorageSize = resource.MustParse("20Gi")
+	assert.True(pvcNeedsUpdate(pvc, buildPersistentVolumeClaim(&deploy, "netobserv")))
+	// Volumes can't be shrunk
+	deploy.StorageSize = resource.MustParse("5Gi")
+	assert.False(pvcNeedsUpdate(pvc, buildPersistentVolumeClaim(&deploy, "netobserv")))
+	assert.Equal(resource.MustParse("10Gi"), pvc.Spec.Resources.Requests[corev1.ResourceStorage])
+}
@@ -10,11 +10,14 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
-	flowsv1alpha1 "github.com/netobserv/network-observability-operator/api/v1alpha1"
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
 	"github.com/netobserv/network-observability-operator/controllers/constants"
 	"github.com/netobserv/network-observability-operator/controllers/reconcilers"
+	"github.com/netobserv/network-observability-operator/pkg/helper"
+	"github.com/netobserv/network-observability-operator/pkg/metrics"
 )
 
 type FlowsConfigController struct {
@@ -22,25 +25,22 @@ type FlowsConfigController struct {
 	goflowkubeNamespace string
 	cnoNamespace        string
 	client              reconcilers.ClientHelper
-	lookupIP            func(string) ([]net.IP, error)
 }
 
 func NewFlowsConfigController(client reconcilers.ClientHelper,
-	goflowkubeNamespace, cnoNamespace, ovsConfigMapName string,
-	lookupIP func(string) ([]net.IP, error)) *FlowsConfigController {
+	goflowkubeNamespace, cnoNamespace, ovsConfigMapName string) *FlowsConfigController {
 	return &FlowsConfigController{
 		client:              client,
 		goflowkubeNamespace: goflowkubeNamespace,
 		cnoNamespace:        cnoNamespace,
 		ovsConfigMapName:    ovsConfigMapName,
-		lookupIP:            lookupIP,
 	}
 }
 
 // Reconcile reconciles the status of the ovs-flows-config configmap with
 // the target FlowCollector ipfix section map
 func (c *FlowsConfigController) Reconcile(
-	ctx context.Context, target *flowsv1alpha1.FlowCollector) error {
+	ctx context.Context, target *flowsv1beta1.FlowCollector) error {
 	rlog := log.FromContext(ctx, "component", "FlowsConfigController")
 
 	current, err := c.current(ctx)
@@ -55,26 +55,53 @@ func (c *FlowsConfigController) Reconcile(
 
 	if current == nil {
 		rlog.Info("Provided IPFIX configuration. Creating " + c.ovsConfigMapName + " ConfigMap")
-		cm, err := c.flowsConfigMap(desired)
-		if err != nil {
-			return err
-		}
-		return c.client.Create(ctx, cm)
+		return c.write(ctx, desired, c.client.CreateOwned)
 	}
 
 	if desired != nil && *desired != *current {
 		rlog.Info("Provided IPFIX configuration differs current configuration. Updating")
-		cm, err := c.flowsConfigMap(desired)
-		if err != nil {
-			return err
-		}
-		return c.client.Update(ctx, cm)
+		return c.write(ctx, desired, c.client.UpdateOwned)
 	}
 
 	rlog.Info("No changes needed")
 	return nil
 }
 
+// write creates or updates the ovs-flows-config configmap from the desired configuration, through the provided
+// ClientHelper function, so that the change is reported like those of the other owned objects
+func (c *FlowsConfigController) write(ctx context.Context, desired *flowsConfig,
+	writeOwned func(context.Context, client.Object) error) error {
+	if err := writeOwned(ctx, c.flowsConfigMap(desired)); err != nil {
+		return err
+	}
+	metrics.ObserveOVSConfigChange()
+	return nil
+}
+
+// IsConfigured tells whether the ovs-flows-config configmap exists
+func (c *FlowsConfigController) IsConfigured(ctx context.Context) (bool, error) {
+	current, err := c.current(ctx)
+	return current != nil, err
+}
+
+// Cleanup deletes the ovs-flows-config configmap, so that OVS stops exporting flows to the collector
+func (c *FlowsConfigController) Cleanup(ctx context.Context) error {
+	current, err := c.current(ctx)
+	if err != nil || current == nil {
+		return err
+	}
+	err = c.client.DeleteIfExists(ctx, &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      c.ovsConfigMapName,
+			Namespace: c.cnoNamespace,
+		},
+	})
+	if err == nil {
+		metrics.ObserveOVSConfigChange()
+	}
+	return err
+}
+
 func (c *FlowsConfigController) current(ctx context.Context) (*flowsConfig, error) {
 	curr := &corev1.ConfigMap{}
 	if err := c.client.Get(ctx, types.NamespacedName{
@@ -95,50 +122,36 @@ func (c *FlowsConfigController) current(ctx context.Context) (*flowsConfig, erro
 }
 
 func (c *FlowsConfigController) desired(
-	ctx context.Context, coll *flowsv1alpha1.FlowCollector) (*flowsConfig, error) {
-
-	conf := flowsConfig{FlowCollectorIPFIX: coll.Spec.IPFIX}
+	ctx context.Context, coll *flowsv1beta1.FlowCollector) (*flowsConfig, error) {
+
+	ipfix := &coll.Spec.Agent.IPFIX
+	conf := flowsConfig{
+		// CNO doesn't support durations combining several units, such as 1m30s
+		CacheActiveTimeout: helper.UnitDuration(ipfix.CacheActiveTimeout.Duration),
+		CacheMaxFlows:      ipfix.CacheMaxFlows,
+		Sampling:           ipfix.Sampling,
+	}
 
 	// According to the "OVS flow export configuration" RFE:
 	// nodePort be set by the NOO when the collector is deployed as a DaemonSet
 	// sharedTarget set when deployed as Deployment + Service
-	switch coll.Spec.GoflowKube.Kind {
+	switch coll.Spec.Processor.Kind {
 	case constants.DaemonSetKind:
-		conf.NodePort = coll.Spec.GoflowKube.Port
+		conf.NodePort = coll.Spec.Processor.Port
 		return &conf, nil
 	case constants.DeploymentKind:
-		svc := corev1.Service{}
-		if err := c.client.Get(ctx, types.NamespacedName{
-			Namespace: c.goflowkubeNamespace,
-			Name:      constants.GoflowKubeName,
-		}, &svc); err != nil {
-			return nil, fmt.Errorf("can't get service %s in %s: %w", constants.GoflowKubeName, c.goflowkubeNamespace, err)
-		}
-		// service IP resolution
-		svcHost := svc.Name + "." + svc.Namespace
-		addrs, err := c.lookupIP(svcHost)
+		target, err := collectorServiceTarget(ctx, c.client, c.goflowkubeNamespace, &coll.Spec.Processor)
 		if err != nil {
-			return nil, fmt.Errorf("can't resolve IP address for service %v: %w", svcHost, err)
-		}
-		var ip string
-		for _, addr := range addrs {
-			if len(addr) > 0 {
-				ip = addr.String()
-				break
-			}
-		}
-		if ip == "" {
-			return nil, fmt.Errorf("can't find any suitable IP for host %s", svcHost)
+			return nil, err
 		}
-		// TODO: if spec/goflowkube is empty or port is empty, fetch first UDP port in the service spec
-		conf.SharedTarget = net.JoinHostPort(ip, strconv.Itoa(int(coll.Spec.GoflowKube.Port)))
+		conf.SharedTarget = target
 		return &conf, nil
 	}
-	return nil, fmt.Errorf("unexpected GoflowKube kind: %s", coll.Spec.GoflowKube.Kind)
+	return nil, fmt.Errorf("unexpected processor kind: %s", coll.Spec.Processor.Kind)
 }
 
-func (c *FlowsConfigController) flowsConfigMap(fc *flowsConfig) (*corev1.ConfigMap, error) {
-	cm := &corev1.ConfigMap{
+func (c *FlowsConfigController) flowsConfigMap(fc *flowsConfig) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
 		TypeMeta: v1.TypeMeta{
 			Kind:       "ConfigMap",
 			APIVersion: "v1",
@@ -149,8 +162,61 @@ func (c *FlowsConfigController) flowsConfigMap(fc *flowsConfig) (*corev1.ConfigM
 		},
 		Data: fc.asStringMap(),
 	}
-	if err := c.client.SetControllerReference(cm); err != nil {
-		return nil, err
+}
+
+// collectorServiceTarget returns the "host:port" address of the goflow-kube Service deployed in the provided namespace
+func collectorServiceTarget(ctx context.Context, cl client.Client, ns string, processor *flowsv1beta1.FlowCollectorProcessor) (string, error) {
+	svc := corev1.Service{}
+	if err := cl.Get(ctx, types.NamespacedName{
+		Namespace: ns,
+		Name:      constants.GoflowKubeName,
+	}, &svc); err != nil {
+		return "", fmt.Errorf("can't get service %s in %s: %w", constants.GoflowKubeName, ns, err)
+	}
+	return serviceTarget(&svc, processor.Port, processor.PreferredIPFamily)
+}
+
+// serviceTarget returns the "host:port" address of the provided Service, to which OVS must send the flows.
+// The address is taken from the Service ClusterIPs, rather than resolved through DNS, so that it doesn't depend
+// on the operator network view. In dual-stack clusters, the address of the preferred IP family is picked
+// if any. If port is 0, the first UDP port of the Service is used.
+func serviceTarget(svc *corev1.Service, port int32, preferredFamily string) (string, error) {
+	ips := svc.Spec.ClusterIPs
+	if len(ips) == 0 && svc.Spec.ClusterIP != "" {
+		ips = []string{svc.Spec.ClusterIP}
+	}
+	if len(ips) == 0 || ips[0] == "" || ips[0] == corev1.ClusterIPNone {
+		return "", fmt.Errorf("service %s/%s has no cluster IP", svc.Namespace, svc.Name)
+	}
+	ip := ips[0]
+	for _, candidate := range ips {
+		if ipFamily(candidate) == preferredFamily {
+			ip = candidate
+			break
+		}
+	}
+	if port == 0 {
+		for i := range svc.Spec.Ports {
+			if svc.Spec.Ports[i].Protocol == corev1.ProtocolUDP {
+				port = svc.Spec.Ports[i].Port
+				break
+			}
+		}
+		if port == 0 {
+			return "", fmt.Errorf("service %s/%s has no UDP port", svc.Namespace, svc.Name)
+		}
+	}
+	return net.JoinHostPort(ip, strconv.Itoa(int(port))), nil
+}
+
+func ipFamily(ip string) string {
+	parsed := net.ParseIP(ip)
+	switch {
+	case parsed == nil:
+		return ""
+	case parsed.To4() != nil:
+		return string(corev1.IPv4Protocol)
+	default:
+		return string(corev1.IPv6Protocol)
 	}
-	return cm, nil
 }
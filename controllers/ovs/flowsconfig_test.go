@@ -0,0 +1,68 @@
+package ovs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func getService(clusterIPs ...string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "goflow-kube", Namespace: "network-observability"},
+		Spec: corev1.ServiceSpec{
+			ClusterIP:  clusterIPs[0],
+			ClusterIPs: clusterIPs,
+			Ports: []corev1.ServicePort{
+				{Port: 9090, Protocol: corev1.ProtocolTCP},
+				{Port: 2055, Protocol: corev1.ProtocolUDP},
+			},
+		},
+	}
+}
+
+func TestServiceTargetSingleStack(t *testing.T) {
+	assert := assert.New(t)
+
+	target, err := serviceTarget(getService("10.0.0.12"), 2055, "")
+	assert.NoError(err)
+	assert.Equal("10.0.0.12:2055", target)
+
+	// Preferred family not available: falling back to the primary address
+	target, err = serviceTarget(getService("10.0.0.12"), 2055, "IPv6")
+	assert.NoError(err)
+	assert.Equal("10.0.0.12:2055", target)
+}
+
+func TestServiceTargetDualStack(t *testing.T) {
+	assert := assert.New(t)
+
+	svc := getService("10.0.0.12", "fd00:10:96::c")
+	target, err := serviceTarget(svc, 2055, "")
+	assert.NoError(err)
+	assert.Equal("10.0.0.12:2055", target)
+
+	target, err = serviceTarget(svc, 2055, "IPv6")
+	assert.NoError(err)
+	assert.Equal("[fd00:10:96::c]:2055", target)
+
+	target, err = serviceTarget(svc, 2055, "IPv4")
+	assert.NoError(err)
+	assert.Equal("10.0.0.12:2055", target)
+}
+
+func TestServiceTargetDefaultsToFirstUDPPort(t *testing.T) {
+	target, err := serviceTarget(getService("10.0.0.12"), 0, "")
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.12:2055", target)
+}
+
+func TestServiceTargetWithoutClusterIP(t *testing.T) {
+	_, err := serviceTarget(getService(""), 2055, "")
+	assert.Error(t, err)
+
+	_, err = serviceTarget(getService(corev1.ClusterIPNone), 2055, "")
+	assert.Error(t, err)
+}
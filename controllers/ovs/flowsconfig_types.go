@@ -5,14 +5,14 @@ import (
 	"reflect"
 
 	"github.com/mitchellh/mapstructure"
-
-	"github.com/netobserv/network-observability-operator/api/v1alpha1"
 )
 
 type flowsConfig struct {
-	v1alpha1.FlowCollectorIPFIX `json:",inline" mapstructure:",squash"`
-	SharedTarget                string `json:"sharedTarget,omitempty" mapstructure:"sharedTarget,omitempty"`
-	NodePort                    int32  `json:"nodePort,omitempty" mapstructure:"nodePort,omitempty"`
+	CacheActiveTimeout string `json:"cacheActiveTimeout,omitempty" mapstructure:"cacheActiveTimeout,omitempty"`
+	CacheMaxFlows      int32  `json:"cacheMaxFlows,omitempty" mapstructure:"cacheMaxFlows,omitempty"`
+	Sampling           int32  `json:"sampling,omitempty" mapstructure:"sampling,omitempty"`
+	SharedTarget       string `json:"sharedTarget,omitempty" mapstructure:"sharedTarget,omitempty"`
+	NodePort           int32  `json:"nodePort,omitempty" mapstructure:"nodePort,omitempty"`
 }
 
 func configFromMap(data map[string]string) (*flowsConfig, error) {
@@ -0,0 +1,176 @@
+package ovs
+
+import (
+	"math"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/reconcilers"
+)
+
+const nodeAgentName = "ovs-ipfix-agent"
+const ovsRunVolume = "ovs-run"
+const ovsRunPath = "/var/run/openvswitch"
+
+// nodeAgentConfigDigest is an annotation name to facilitate pod restart after any configuration change
+const nodeAgentConfigDigest = "flows.netobserv.io/ovs-ipfix-agent-config"
+
+// nodeAgentScript configures IPFIX export on the OVS bridge, then checks periodically that the configuration is
+// still there (e.g. after an OVS database reset). The configuration is removed when the pod terminates, so that
+// OVS stops exporting flows when the agent is undeployed. Any failure makes the container exit and report its
+// logs as termination message.
+const nodeAgentScript = `set -eu
+if [ -n "${IPFIX_SHARED_TARGET:-}" ]; then
+  target="$IPFIX_SHARED_TARGET"
+else
+  case "$HOST_IP" in
+    *:*) target="[$HOST_IP]:$IPFIX_NODE_PORT" ;;
+    *) target="$HOST_IP:$IPFIX_NODE_PORT" ;;
+  esac
+fi
+configure() {
+  echo "configuring IPFIX export to $target on bridge $OVS_BRIDGE"
+  ovs-vsctl --timeout=10 -- --id=@ipfix create IPFIX targets="\"$target\"" sampling="$IPFIX_SAMPLING" \
+    cache_active_timeout="$IPFIX_CACHE_ACTIVE_TIMEOUT" cache_max_flows="$IPFIX_CACHE_MAX_FLOWS" \
+    -- set Bridge "$OVS_BRIDGE" ipfix=@ipfix
+}
+unconfigure() {
+  echo "removing IPFIX export from bridge $OVS_BRIDGE"
+  ovs-vsctl --timeout=10 clear Bridge "$OVS_BRIDGE" ipfix
+  exit 0
+}
+trap unconfigure TERM INT
+configure
+while true; do
+  sleep 60 & wait $!
+  if [ "$(ovs-vsctl --timeout=10 get Bridge "$OVS_BRIDGE" ipfix)" = "[]" ]; then
+    configure
+  fi
+done
+`
+
+// nodeAgentReadinessCheck tells whether IPFIX export is configured on the OVS bridge
+const nodeAgentReadinessCheck = `[ "$(ovs-vsctl --timeout=5 get Bridge "$OVS_BRIDGE" ipfix)" != "[]" ]`
+
+func buildNodeAgentLabels() map[string]string {
+	return map[string]string{
+		"app": nodeAgentName,
+	}
+}
+
+// buildNodeAgentDaemonSet returns the node agent DaemonSet. sharedTarget is the address to which OVS must send
+// the flows when the collector is deployed as a Deployment; when it is empty, flows are sent to the collector
+// port of the node on which OVS runs.
+func buildNodeAgentDaemonSet(desired *flowsv1beta1.FlowCollectorSpec, ns, sharedTarget string) *appsv1.DaemonSet {
+	template := buildNodeAgentPodTemplate(desired, sharedTarget)
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      nodeAgentName,
+			Namespace: ns,
+			Labels:    buildNodeAgentLabels(),
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: buildNodeAgentLabels(),
+			},
+			Template: template,
+		},
+	}
+}
+
+func buildNodeAgentPodTemplate(desired *flowsv1beta1.FlowCollectorSpec, sharedTarget string) corev1.PodTemplateSpec {
+	ipfix := &desired.Agent.IPFIX
+	env := []corev1.EnvVar{
+		{Name: "OVS_BRIDGE", Value: ipfix.NodeAgent.Bridge},
+		{Name: "IPFIX_SAMPLING", Value: strconv.Itoa(int(ovsSampling(ipfix.Sampling)))},
+		{Name: "IPFIX_CACHE_ACTIVE_TIMEOUT", Value: strconv.Itoa(ovsCacheActiveTimeout(ipfix.CacheActiveTimeout.Duration.Seconds()))},
+		{Name: "IPFIX_CACHE_MAX_FLOWS", Value: strconv.Itoa(int(ipfix.CacheMaxFlows))},
+	}
+	if sharedTarget != "" {
+		env = append(env, corev1.EnvVar{Name: "IPFIX_SHARED_TARGET", Value: sharedTarget})
+	} else {
+		env = append(env,
+			corev1.EnvVar{Name: "IPFIX_NODE_PORT", Value: strconv.Itoa(int(desired.Processor.Port))},
+			corev1.EnvVar{Name: "HOST_IP", ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: "status.hostIP"},
+			}},
+		)
+	}
+	privileged := true
+	hostPathType := corev1.HostPathDirectory
+	spec := corev1.PodSpec{
+		// OVS runs on every node, including the tainted ones
+		Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+		Volumes: []corev1.Volume{{
+			Name: ovsRunVolume,
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: ipfix.NodeAgent.OVSRunDir,
+					Type: &hostPathType,
+				},
+			},
+		}},
+		Containers: []corev1.Container{{
+			Name:            nodeAgentName,
+			Image:           ipfix.NodeAgent.Image,
+			ImagePullPolicy: corev1.PullPolicy(ipfix.NodeAgent.ImagePullPolicy),
+			Command:         []string{"/bin/sh", "-c", nodeAgentScript},
+			Env:             env,
+			VolumeMounts: []corev1.VolumeMount{{
+				Name:      ovsRunVolume,
+				MountPath: ovsRunPath,
+			}},
+			ReadinessProbe: &corev1.Probe{
+				Handler: corev1.Handler{
+					Exec: &corev1.ExecAction{Command: []string{"/bin/sh", "-c", nodeAgentReadinessCheck}},
+				},
+				PeriodSeconds:  30,
+				TimeoutSeconds: 10,
+			},
+			SecurityContext: &corev1.SecurityContext{
+				// Required to access the OVS database socket
+				Privileged: &privileged,
+			},
+			TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+		}},
+		ServiceAccountName: nodeAgentName,
+	}
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: buildNodeAgentLabels(),
+			Annotations: map[string]string{
+				nodeAgentConfigDigest: reconcilers.PodSpecDigest(&spec),
+			},
+		},
+		Spec: spec,
+	}
+}
+
+func buildNodeAgentServiceAccount(ns string) *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      nodeAgentName,
+			Namespace: ns,
+			Labels:    buildNodeAgentLabels(),
+		},
+	}
+}
+
+// ovsSampling converts the FlowCollector sampling to the OVS one: 0 disables the sampling, which means that
+// every flow is sent, as with a sampling of 1 in OVS
+func ovsSampling(sampling int32) int32 {
+	if sampling <= 0 {
+		return 1
+	}
+	return sampling
+}
+
+// ovsCacheActiveTimeout converts the cache active timeout to the whole number of seconds that OVS expects,
+// rounded up so that sub-second timeouts aren't disabled
+func ovsCacheActiveTimeout(seconds float64) int {
+	return int(math.Ceil(seconds))
+}
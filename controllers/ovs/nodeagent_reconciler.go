@@ -0,0 +1,179 @@
+package ovs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/constants"
+	"github.com/netobserv/network-observability-operator/controllers/reconcilers"
+)
+
+// NodeAgentReconciler reconciles the agent that configures IPFIX export directly in OVS on each node,
+// for clusters where the cluster network operator isn't available
+type NodeAgentReconciler struct {
+	reconcilers.ClientHelper
+	nobjMngr *reconcilers.NamespacedObjectManager
+	owned    nodeAgentObjects
+}
+
+type nodeAgentObjects struct {
+	daemonSet      *appsv1.DaemonSet
+	serviceAccount *corev1.ServiceAccount
+}
+
+func NewNodeAgentReconciler(cl reconcilers.ClientHelper, ns, prevNS string) NodeAgentReconciler {
+	owned := nodeAgentObjects{
+		daemonSet:      &appsv1.DaemonSet{},
+		serviceAccount: &corev1.ServiceAccount{},
+	}
+	nobjMngr := reconcilers.NewNamespacedObjectManager(cl, ns, prevNS)
+	nobjMngr.AddManagedObject(nodeAgentName, owned.daemonSet)
+	nobjMngr.AddManagedObject(nodeAgentName, owned.serviceAccount)
+
+	return NodeAgentReconciler{ClientHelper: cl, nobjMngr: nobjMngr, owned: owned}
+}
+
+// PrepareNamespaceChange cleans up old namespace
+func (r *NodeAgentReconciler) PrepareNamespaceChange(ctx context.Context) {
+	// Switching namespace => delete everything in the previous namespace
+	r.nobjMngr.CleanupNamespace(ctx)
+}
+
+// Reconcile is the reconciler entry point to reconcile the current node agent state with the desired configuration
+func (r *NodeAgentReconciler) Reconcile(ctx context.Context, desired *flowsv1beta1.FlowCollector) error {
+	ns := r.nobjMngr.Namespace
+	// Retrieve current owned objects
+	err := r.nobjMngr.FetchAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	sharedTarget := ""
+	switch desired.Spec.Processor.Kind {
+	case constants.DaemonSetKind:
+		// The target is the collector port on the node where the agent runs
+	case constants.DeploymentKind:
+		if sharedTarget, err = collectorServiceTarget(ctx, r.Client, ns, &desired.Spec.Processor); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unexpected processor kind: %s", desired.Spec.Processor.Kind)
+	}
+
+	if !r.nobjMngr.Exists(r.owned.serviceAccount) {
+		if err := r.CreateOwned(ctx, buildNodeAgentServiceAccount(ns)); err != nil {
+			return err
+		}
+	}
+	newDS := buildNodeAgentDaemonSet(&desired.Spec, ns, sharedTarget)
+	if !r.nobjMngr.Exists(r.owned.daemonSet) {
+		return r.CreateOwned(ctx, newDS)
+	} else if reconcilers.NeedsUpdate(r.owned.daemonSet, newDS) {
+		return r.UpdateOwned(ctx, newDS)
+	}
+	return nil
+}
+
+// FetchAll retrieves the current owned objects, so that CheckReadiness reports their state when they are not
+// reconciled
+func (r *NodeAgentReconciler) FetchAll(ctx context.Context) error {
+	return r.nobjMngr.FetchAll(ctx)
+}
+
+// Cleanup deletes the node agent, which removes the IPFIX configuration from OVS when terminating
+func (r *NodeAgentReconciler) Cleanup(ctx context.Context) error {
+	if err := r.nobjMngr.FetchAll(ctx); err != nil {
+		return err
+	}
+	r.nobjMngr.TryDelete(ctx, r.owned.daemonSet)
+	r.nobjMngr.TryDelete(ctx, r.owned.serviceAccount)
+	return nil
+}
+
+// NodeStatuses returns the IPFIX configuration state of each node on which the agent runs
+func (r *NodeAgentReconciler) NodeStatuses(ctx context.Context) ([]flowsv1beta1.OVSNodeStatus, error) {
+	pods := corev1.PodList{}
+	if err := r.List(ctx, &pods,
+		client.InNamespace(r.nobjMngr.Namespace),
+		client.MatchingLabels(buildNodeAgentLabels())); err != nil {
+		return nil, err
+	}
+	return nodeStatuses(pods.Items), nil
+}
+
+// CheckReadiness tells whether OVS is configured on all the nodes where the node agent, as fetched during the
+// last reconciliation, is scheduled. A message describing the configuration progress is also returned.
+func (r *NodeAgentReconciler) CheckReadiness(nodes []flowsv1beta1.OVSNodeStatus) (bool, string) {
+	if !r.nobjMngr.Exists(r.owned.daemonSet) {
+		return false, "DaemonSet " + nodeAgentName + " is being created"
+	}
+	return nodesProgress(r.owned.daemonSet.Status.DesiredNumberScheduled, nodes)
+}
+
+func nodesProgress(desired int32, nodes []flowsv1beta1.OVSNodeStatus) (bool, string) {
+	configured := int32(0)
+	for i := range nodes {
+		if nodes[i].Configured {
+			configured++
+		}
+	}
+	if desired == 0 {
+		return false, "DaemonSet " + nodeAgentName + ": no node scheduled"
+	}
+	if configured < desired {
+		return false, fmt.Sprintf("IPFIX export configured on %d out of %d nodes", configured, desired)
+	}
+	return true, fmt.Sprintf("IPFIX export configured on %d nodes", configured)
+}
+
+// nodeStatuses builds the per-node status from the agent pods. When a node hosts several pods, e.g. during a
+// rollout, the most recent one is reported.
+func nodeStatuses(pods []corev1.Pod) []flowsv1beta1.OVSNodeStatus {
+	latest := map[string]*corev1.Pod{}
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Spec.NodeName == "" || pod.DeletionTimestamp != nil {
+			continue
+		}
+		if prev, ok := latest[pod.Spec.NodeName]; !ok || prev.CreationTimestamp.Before(&pod.CreationTimestamp) {
+			latest[pod.Spec.NodeName] = pod
+		}
+	}
+	var statuses []flowsv1beta1.OVSNodeStatus
+	for node, pod := range latest {
+		configured, msg := podConfigurationState(pod)
+		statuses = append(statuses, flowsv1beta1.OVSNodeStatus{Node: node, Configured: configured, Message: msg})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Node < statuses[j].Node })
+	return statuses
+}
+
+func podConfigurationState(pod *corev1.Pod) (bool, string) {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady && cond.Status == corev1.ConditionTrue {
+			return true, "IPFIX export is configured"
+		}
+	}
+	for i := range pod.Status.ContainerStatuses {
+		status := &pod.Status.ContainerStatuses[i]
+		if status.Name != nodeAgentName {
+			continue
+		}
+		if last := status.LastTerminationState.Terminated; last != nil && last.ExitCode != 0 {
+			return false, fmt.Sprintf("configuration failed (exit code %d): %s", last.ExitCode, last.Message)
+		}
+		if status.State.Waiting != nil {
+			return false, "container is waiting: " + status.State.Waiting.Reason
+		}
+		if status.State.Running != nil {
+			return false, "IPFIX export is being configured"
+		}
+	}
+	return false, "pod is " + string(pod.Status.Phase)
+}
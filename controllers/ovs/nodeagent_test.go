@@ -0,0 +1,149 @@
+package ovs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+	"github.com/netobserv/network-observability-operator/controllers/controllerstest"
+	"github.com/netobserv/network-observability-operator/controllers/reconcilers"
+)
+
+func getNodeAgentSpec() flowsv1beta1.FlowCollectorSpec {
+	return flowsv1beta1.FlowCollectorSpec{
+		Agent: flowsv1beta1.FlowCollectorAgent{IPFIX: flowsv1beta1.FlowCollectorIPFIX{
+			CacheActiveTimeout: metav1.Duration{Duration: 1500 * time.Millisecond},
+			CacheMaxFlows:      100,
+			Sampling:           0,
+			OVSConfigMode:      "NodeAgent",
+			NodeAgent: flowsv1beta1.OVSNodeAgent{
+				Image:           "ghcr.io/ovn-org/ovn-kubernetes/ovn-kube-f:master",
+				ImagePullPolicy: "IfNotPresent",
+				Bridge:          "br-int",
+				OVSRunDir:       "/var/run/openvswitch",
+			},
+		}},
+		Processor: flowsv1beta1.FlowCollectorProcessor{Kind: "DaemonSet", Port: 2055},
+	}
+}
+
+func envMap(container *corev1.Container) map[string]string {
+	env := map[string]string{}
+	for _, e := range container.Env {
+		env[e.Name] = e.Value
+		if e.ValueFrom != nil {
+			env[e.Name] = e.ValueFrom.FieldRef.FieldPath
+		}
+	}
+	return env
+}
+
+func TestNodeAgentDaemonSetWithNodeTarget(t *testing.T) {
+	assert := assert.New(t)
+
+	spec := getNodeAgentSpec()
+	ds := buildNodeAgentDaemonSet(&spec, "netobserv", "")
+	require.Len(t, ds.Spec.Template.Spec.Containers, 1)
+	container := ds.Spec.Template.Spec.Containers[0]
+	assert.Equal("ghcr.io/ovn-org/ovn-kubernetes/ovn-kube-f:master", container.Image)
+	assert.Equal(map[string]string{
+		"OVS_BRIDGE":                 "br-int",
+		"IPFIX_SAMPLING":             "1",
+		"IPFIX_CACHE_ACTIVE_TIMEOUT": "2",
+		"IPFIX_CACHE_MAX_FLOWS":      "100",
+		"IPFIX_NODE_PORT":            "2055",
+		"HOST_IP":                    "status.hostIP",
+	}, envMap(&container))
+	assert.Equal("/var/run/openvswitch", ds.Spec.Template.Spec.Volumes[0].HostPath.Path)
+	assert.True(*container.SecurityContext.Privileged)
+}
+
+func TestNodeAgentDaemonSetWithSharedTarget(t *testing.T) {
+	spec := getNodeAgentSpec()
+	spec.Processor.Kind = "Deployment"
+	spec.Agent.IPFIX.Sampling = 400
+	ds := buildNodeAgentDaemonSet(&spec, "netobserv", "10.0.0.12:2055")
+	env := envMap(&ds.Spec.Template.Spec.Containers[0])
+	assert.Equal(t, "10.0.0.12:2055", env["IPFIX_SHARED_TARGET"])
+	assert.Equal(t, "400", env["IPFIX_SAMPLING"])
+	assert.NotContains(t, env, "HOST_IP")
+}
+
+func TestNodeAgentNeedsUpdate(t *testing.T) {
+	assert := assert.New(t)
+
+	spec := getNodeAgentSpec()
+	current := controllerstest.AsWritten(buildNodeAgentDaemonSet(&spec, "netobserv", ""))
+	assert.False(reconcilers.NeedsUpdate(current, buildNodeAgentDaemonSet(&spec, "netobserv", "")))
+
+	spec.Agent.IPFIX.CacheMaxFlows = 200
+	assert.True(reconcilers.NeedsUpdate(current, buildNodeAgentDaemonSet(&spec, "netobserv", "")))
+
+	spec = getNodeAgentSpec()
+	assert.True(reconcilers.NeedsUpdate(current, buildNodeAgentDaemonSet(&spec, "other", "")))
+	assert.Empty(controllerstest.UndetectedDrifts(buildNodeAgentDaemonSet(&spec, "netobserv", "")))
+}
+
+func agentPod(node string, created int64, ready bool) corev1.Pod {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.Unix(created, 0)},
+		Spec:       corev1.PodSpec{NodeName: node},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}
+	return pod
+}
+
+func TestNodeStatuses(t *testing.T) {
+	failing := agentPod("node-b", 10, false)
+	failing.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  nodeAgentName,
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+		LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+			ExitCode: 1,
+			Message:  "ovs-vsctl: no bridge named br-int",
+		}},
+	}}
+	unscheduled := agentPod("", 10, false)
+	unscheduled.Status.Phase = corev1.PodPending
+
+	statuses := nodeStatuses([]corev1.Pod{
+		agentPod("node-c", 10, true),
+		failing,
+		// Rollout: the most recent pod of node-a is reported
+		agentPod("node-a", 20, false),
+		agentPod("node-a", 10, true),
+		unscheduled,
+	})
+	assert.Equal(t, []flowsv1beta1.OVSNodeStatus{
+		{Node: "node-a", Configured: false, Message: "pod is Running"},
+		{Node: "node-b", Configured: false, Message: "configuration failed (exit code 1): ovs-vsctl: no bridge named br-int"},
+		{Node: "node-c", Configured: true, Message: "IPFIX export is configured"},
+	}, statuses)
+}
+
+func TestNodesProgress(t *testing.T) {
+	assert := assert.New(t)
+
+	nodes := []flowsv1beta1.OVSNodeStatus{{Node: "a", Configured: true}, {Node: "b", Configured: false}}
+	ready, msg := nodesProgress(2, nodes)
+	assert.False(ready)
+	assert.Equal("IPFIX export configured on 1 out of 2 nodes", msg)
+
+	nodes[1].Configured = true
+	ready, msg = nodesProgress(2, nodes)
+	assert.True(ready)
+	assert.Equal("IPFIX export configured on 2 nodes", msg)
+
+	ready, _ = nodesProgress(0, nil)
+	assert.False(ready)
+}
@@ -2,58 +2,144 @@ package reconcilers
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/netobserv/network-observability-operator/pkg/metrics"
 )
 
+// FieldManager is the name of the field manager under which the operator writes the objects through server-side
+// apply. The operator only owns the fields that it sets: those set by other controllers are left untouched.
+const FieldManager = "netobserv-operator"
+
 // ClientHelper includes a kube client with some additional helper functions
 type ClientHelper struct {
 	client.Client
 	SetControllerReference func(client.Object) error
+	// ForceOwnership makes the operator take over the fields it sets when they are managed by another field
+	// manager, e.g. after a manual edit. Otherwise, such conflicts are returned as errors.
+	ForceOwnership bool
+	// Recorder, when set, reports the objects created, updated and deleted through the helper as events on Owner
+	Recorder record.EventRecorder
+	Owner    runtime.Object
+}
+
+// change describes a create, update or delete operation, as logged, counted and reported in events
+type change struct {
+	operation string
+	reason    string
+	action    string
+	failure   string
 }
 
-// CreateOwned is an helper function that creates an object, sets owner reference and writes info & errors logs
+var (
+	created = change{operation: metrics.OperationCreate, reason: "Created", action: "Creating a new ", failure: "Failed to create new "}
+	updated = change{operation: metrics.OperationUpdate, reason: "Updated", action: "Updating ", failure: "Failed to update "}
+	deleted = change{operation: metrics.OperationDelete, reason: "Deleted", action: "Deleting ", failure: "Failed to delete "}
+)
+
+// CreateOwned is an helper function that creates an object, sets owner reference and writes info & errors logs.
+// The digest of the object is recorded for NeedsUpdate.
 func (c *ClientHelper) CreateOwned(ctx context.Context, obj client.Object) error {
+	return c.applyOwned(ctx, obj, created)
+}
+
+// UpdateOwned is an helper function that updates an object, sets owner reference and writes info & errors logs.
+// The digest of the object is recorded for NeedsUpdate.
+func (c *ClientHelper) UpdateOwned(ctx context.Context, obj client.Object) error {
+	return c.applyOwned(ctx, obj, updated)
+}
+
+func (c *ClientHelper) applyOwned(ctx context.Context, obj client.Object, ch change) error {
 	log := log.FromContext(ctx)
+	setDesiredDigest(obj)
 	err := c.SetControllerReference(obj)
 	if err != nil {
 		log.Error(err, "Failed to set controller reference")
 		return err
 	}
 	kind := reflect.TypeOf(obj).String()
-	log.Info("Creating a new "+kind, "Namespace", obj.GetNamespace(), "Name", obj.GetName())
-	err = c.Create(ctx, obj)
+	log.Info(ch.action+kind, "Namespace", obj.GetNamespace(), "Name", obj.GetName())
+	desired := obj.DeepCopyObject().(client.Object)
+	err = c.apply(ctx, obj)
+	if err == nil && hasAddedEntries(obj, desired) {
+		// The elements added by other field managers are kept by the server-side apply: they are removed by
+		// replacing the object
+		err = c.Update(ctx, withoutAddedEntries(obj, desired), client.FieldOwner(FieldManager))
+	}
 	if err != nil {
-		log.Error(err, "Failed to create new "+kind, "Namespace", obj.GetNamespace(), "Name", obj.GetName())
+		log.Error(err, ch.failure+kind, "Namespace", obj.GetNamespace(), "Name", obj.GetName())
 		return err
 	}
+	c.record(obj, ch)
 	return nil
 }
 
-// UpdateOwned is an helper function that updates an object, sets owner reference and writes info & errors logs
-func (c *ClientHelper) UpdateOwned(ctx context.Context, old, obj client.Object) error {
-	log := log.FromContext(ctx)
-	if old != nil {
-		obj.SetResourceVersion(old.GetResourceVersion())
-	}
-	err := c.SetControllerReference(obj)
+// apply creates or updates an object through server-side apply, with the operator field manager. The fields of
+// the object that are not set are left to the server defaults and to other controllers.
+func (c *ClientHelper) apply(ctx context.Context, obj client.Object) error {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
 	if err != nil {
-		log.Error(err, "Failed to set controller reference")
 		return err
 	}
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	// The object may be a copy of the current one: the metadata managed by the server can't be applied
+	obj.SetManagedFields(nil)
+	obj.SetResourceVersion("")
+	opts := []client.PatchOption{client.FieldOwner(FieldManager)}
+	if c.ForceOwnership {
+		opts = append(opts, client.ForceOwnership)
+	}
+	err = c.Patch(ctx, obj, client.Apply, opts...)
+	if errors.IsConflict(err) {
+		return fmt.Errorf("%s %s is managed by other field managers, force the operator ownership to take it over: %w",
+			gvk.Kind, client.ObjectKeyFromObject(obj), err)
+	}
+	return err
+}
+
+// DeleteIfExists is an helper function that deletes an object, ignoring it if it doesn't exist, and writes info & errors logs
+func (c *ClientHelper) DeleteIfExists(ctx context.Context, obj client.Object) error {
+	log := log.FromContext(ctx)
 	kind := reflect.TypeOf(obj).String()
-	log.Info("Updating "+kind, "Namespace", obj.GetNamespace(), "Name", obj.GetName())
-	err = c.Update(ctx, obj)
+	log.Info(deleted.action+kind, "Namespace", obj.GetNamespace(), "Name", obj.GetName())
+	err := c.Delete(ctx, obj)
 	if err != nil {
-		log.Error(err, "Failed to update "+kind, "Namespace", obj.GetNamespace(), "Name", obj.GetName())
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		log.Error(err, deleted.failure+kind, "Namespace", obj.GetNamespace(), "Name", obj.GetName())
 		return err
 	}
+	c.record(obj, deleted)
 	return nil
 }
 
+// record counts a successful change of an object in the operator metrics, and reports it as an event on the owner
+func (c *ClientHelper) record(obj client.Object, ch change) {
+	kind := reflect.TypeOf(obj).Elem().Name()
+	if gvk, err := apiutil.GVKForObject(obj, c.Scheme()); err == nil {
+		kind = gvk.Kind
+	}
+	metrics.ObserveObject(kind, ch.operation)
+	if c.Recorder == nil || c.Owner == nil {
+		return
+	}
+	ref := obj.GetName()
+	if obj.GetNamespace() != "" {
+		ref = obj.GetNamespace() + "/" + ref
+	}
+	c.Recorder.Eventf(c.Owner, corev1.EventTypeNormal, ch.reason, "%s %s", kind, ref)
+}
+
 // FindContainer searches in pod containers one that matches the provided name
 func FindContainer(podSpec *corev1.PodSpec, name string) *corev1.Container {
 	for i := range podSpec.Containers {
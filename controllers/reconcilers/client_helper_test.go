@@ -0,0 +1,126 @@
+package reconcilers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// patchRecorder records the patches sent to the server, and optionally fails them
+type patchRecorder struct {
+	client.Client
+	patchType types.PatchType
+	options   client.PatchOptions
+	applied   map[string]interface{}
+	err       error
+}
+
+func (c *patchRecorder) Patch(_ context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	c.patchType = patch.Type()
+	c.options = client.PatchOptions{}
+	c.options.ApplyOptions(opts)
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+	c.applied = map[string]interface{}{}
+	if err := json.Unmarshal(data, &c.applied); err != nil {
+		return err
+	}
+	return c.err
+}
+
+func getOwnedConfigMap() *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "netobserv",
+		},
+		Data: map[string]string{"config.yaml": "port: 2055"},
+	}
+}
+
+func TestApplyOwned(t *testing.T) {
+	recorder := patchRecorder{Client: fake.NewClientBuilder().Build()}
+	helper := ClientHelper{
+		Client:                 &recorder,
+		SetControllerReference: func(client.Object) error { return nil },
+	}
+
+	// The object may be a copy of the current one
+	cm := getOwnedConfigMap()
+	cm.ResourceVersion = "42"
+	cm.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: "kubectl-edit"}}
+	require.NoError(t, helper.UpdateOwned(context.Background(), cm))
+	assert.Equal(t, types.ApplyPatchType, recorder.patchType)
+	assert.Equal(t, FieldManager, recorder.options.FieldManager)
+	assert.Nil(t, recorder.options.Force)
+	assert.Equal(t, schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, cm.GroupVersionKind())
+	metadata := recorder.applied["metadata"].(map[string]interface{})
+	assert.NotContains(t, metadata, "resourceVersion")
+	assert.NotContains(t, metadata, "managedFields")
+	assert.Contains(t, metadata["annotations"], DesiredDigestAnnotation)
+
+	//conflicts with other field managers are reported
+	recorder.err = errors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "test", nil)
+	err := helper.UpdateOwned(context.Background(), getOwnedConfigMap())
+	assert.True(t, errors.IsConflict(err))
+	assert.Contains(t, err.Error(), "ConfigMap netobserv/test is managed by other field managers")
+
+	//or the operator takes the ownership over
+	helper.ForceOwnership = true
+	recorder.err = nil
+	require.NoError(t, helper.CreateOwned(context.Background(), getOwnedConfigMap()))
+	require.NotNil(t, recorder.options.Force)
+	assert.True(t, *recorder.options.Force)
+}
+
+// mergeApplyClient emulates the server-side apply with a merge patch, which also keeps the map entries that the
+// applied object doesn't have
+type mergeApplyClient struct {
+	client.Client
+}
+
+func (c *mergeApplyClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, _ ...client.PatchOption) error {
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+	return c.Client.Patch(ctx, obj, client.RawPatch(types.MergePatchType, data))
+}
+
+func TestUpdateOwnedRemovesAddedEntries(t *testing.T) {
+	ctx := context.Background()
+	modified := getOwnedConfigMap()
+	modified.Labels = map[string]string{"injected": "true"}
+	modified.Data["debug"] = "true"
+	modified.Annotations = map[string]string{"injected": "true"}
+	modified.Finalizers = []string{"example.com/cleanup"}
+	modified.Immutable = &[]bool{false}[0]
+	cl := fake.NewClientBuilder().WithObjects(modified).Build()
+	helper := ClientHelper{
+		Client:                 &mergeApplyClient{Client: cl},
+		SetControllerReference: func(client.Object) error { return nil },
+	}
+
+	require.NoError(t, helper.UpdateOwned(ctx, getOwnedConfigMap()))
+	restored := corev1.ConfigMap{}
+	require.NoError(t, cl.Get(ctx, client.ObjectKeyFromObject(modified), &restored))
+	assert.Equal(t, getOwnedConfigMap().Data, restored.Data)
+	assert.Empty(t, restored.Labels)
+	assert.Contains(t, restored.Annotations, DesiredDigestAnnotation)
+	// The annotations and the fields that the operator doesn't set are kept
+	assert.Equal(t, "true", restored.Annotations["injected"])
+	assert.Equal(t, []string{"example.com/cleanup"}, restored.Finalizers)
+	assert.NotNil(t, restored.Immutable)
+}
@@ -0,0 +1,203 @@
+package reconcilers
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"reflect"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/conversion"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DesiredDigestAnnotation holds the digest of the desired object, as built by the operator, that was last
+// written. It allows detecting any change of the desired state, including removed fields.
+const DesiredDigestAnnotation = "flows.netobserv.io/desired-digest"
+
+// derivative compares the fields set in a desired object with those of the current object. The unset fields of
+// the desired object, including zero numbers, are left to the server defaults and to other controllers, as are
+// the extra map entries, such as labels and annotations added by admission controllers.
+var derivative = conversion.EqualitiesOrDie(
+	func(a, b resource.Quantity) bool {
+		return a.Cmp(b) == 0
+	},
+	func(a, b metav1.Time) bool {
+		return a.IsZero() || a.UTC() == b.UTC()
+	},
+	func(a, b int32) bool {
+		return a == 0 || a == b
+	},
+	func(a, b int64) bool {
+		return a == 0 || a == b
+	},
+)
+
+// serverAllocated lists the fields that the server and other controllers fill when the desired object leaves them
+// empty
+var serverAllocated = map[reflect.Type]map[string]bool{
+	reflect.TypeOf(corev1.ServiceSpec{}): {"ClusterIPs": true, "IPFamilies": true},
+	reflect.TypeOf(metav1.ObjectMeta{}):  {"Finalizers": true},
+}
+
+var (
+	objectMetaType = reflect.TypeOf(metav1.ObjectMeta{})
+	quantityType   = reflect.TypeOf(resource.Quantity{})
+	timeType       = reflect.TypeOf(metav1.Time{})
+)
+
+// NeedsUpdate tells whether the current object must be updated with the desired one, built by the operator: either
+// the desired state has changed since the object was last written, or the object has been modified since then,
+// including the elements and entries added to its lists and maps.
+func NeedsUpdate(current, desired client.Object) bool {
+	return current.GetAnnotations()[DesiredDigestAnnotation] != DesiredDigest(desired) ||
+		!derivative.DeepDerivative(desired, current) || hasAddedEntries(current, desired)
+}
+
+// hasAddedEntries tells whether the current object has list elements or map entries that the desired one doesn't
+// have, such as an added environment variable, toleration, volume or label. The server-side apply keeps them, as
+// they are owned by other field managers. The status, the server-managed metadata, the annotations, which are
+// commonly added by other tools, and the fields allocated by the server are ignored. So are unstructured objects.
+func hasAddedEntries(current, desired client.Object) bool {
+	cv, dv := reflect.ValueOf(current), reflect.ValueOf(desired)
+	if _, ok := desired.(*unstructured.Unstructured); ok || cv.Type() != dv.Type() {
+		return false
+	}
+	return addedEntries(cv, dv)
+}
+
+func addedEntries(current, desired reflect.Value) bool {
+	switch desired.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if current.IsNil() || desired.IsNil() {
+			return false
+		}
+		return addedEntries(current.Elem(), desired.Elem())
+	case reflect.Struct:
+		return addedFieldEntries(current, desired)
+	case reflect.Slice:
+		if current.Len() > desired.Len() {
+			return true
+		}
+		for i := 0; i < desired.Len(); i++ {
+			if addedEntries(current.Index(i), desired.Index(i)) {
+				return true
+			}
+		}
+	case reflect.Map:
+		iter := current.MapRange()
+		for iter.Next() {
+			value := desired.MapIndex(iter.Key())
+			if !value.IsValid() || addedEntries(iter.Value(), value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DesiredDigest returns a digest of the desired object, ignoring its digest annotation
+func DesiredDigest(desired client.Object) string {
+	if _, ok := desired.GetAnnotations()[DesiredDigestAnnotation]; ok {
+		desired = desired.DeepCopyObject().(client.Object)
+		annotations := desired.GetAnnotations()
+		delete(annotations, DesiredDigestAnnotation)
+		desired.SetAnnotations(annotations)
+	}
+	b, err := json.Marshal(desired)
+	if err != nil {
+		return ""
+	}
+	hasher := fnv.New64a()
+	_, _ = hasher.Write(b)
+	return strconv.FormatUint(hasher.Sum64(), 36)
+}
+
+// setDesiredDigest records the digest of the desired object before it is written
+func setDesiredDigest(desired client.Object) {
+	digest := DesiredDigest(desired)
+	annotations := desired.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[DesiredDigestAnnotation] = digest
+	desired.SetAnnotations(annotations)
+}
+
+func addedFieldEntries(current, desired reflect.Value) bool {
+	if desired.Type() == objectMetaType {
+		return addedEntries(current.FieldByName("Labels"), desired.FieldByName("Labels"))
+	}
+	for i := 0; i < desired.NumField(); i++ {
+		f := desired.Type().Field(i)
+		if f.PkgPath != "" || f.Name == "Status" || f.Type == reflect.TypeOf(metav1.TypeMeta{}) ||
+			(serverAllocated[desired.Type()][f.Name] && desired.Field(i).Len() == 0) {
+			continue
+		}
+		if addedEntries(current.Field(i), desired.Field(i)) {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutAddedEntries returns a copy of the desired object, to replace the current one without the elements and
+// entries added to its lists and maps. The fields that the desired object leaves unset, as well as the
+// annotations, are kept from the current object.
+func withoutAddedEntries(current, desired client.Object) client.Object {
+	replacement := desired.DeepCopyObject().(client.Object)
+	fillUnset(reflect.ValueOf(replacement).Elem(), reflect.ValueOf(current.DeepCopyObject()).Elem())
+	annotations := current.GetAnnotations()
+	for k, v := range desired.GetAnnotations() {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[k] = v
+	}
+	replacement.SetAnnotations(annotations)
+	// The managed fields are left unchanged by the server, except for those of the operator
+	replacement.SetManagedFields(nil)
+	return replacement
+}
+
+// fillUnset sets the unset fields of the desired value from the current one. The lists and maps are left as is.
+func fillUnset(desired, current reflect.Value) {
+	switch desired.Kind() {
+	case reflect.Ptr:
+		if desired.IsNil() {
+			desired.Set(current)
+		} else if !current.IsNil() {
+			fillUnset(desired.Elem(), current.Elem())
+		}
+	case reflect.Struct:
+		if desired.Type() == quantityType || desired.Type() == timeType {
+			if desired.IsZero() {
+				desired.Set(current)
+			}
+			return
+		}
+		for i := 0; i < desired.NumField(); i++ {
+			f := desired.Type().Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			if serverAllocated[desired.Type()][f.Name] && desired.Field(i).Len() == 0 {
+				desired.Field(i).Set(current.Field(i))
+			} else {
+				fillUnset(desired.Field(i), current.Field(i))
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < desired.Len() && i < current.Len(); i++ {
+			fillUnset(desired.Index(i), current.Index(i))
+		}
+	case reflect.Map, reflect.Interface:
+	default:
+		if desired.IsZero() {
+			desired.Set(current)
+		}
+	}
+}
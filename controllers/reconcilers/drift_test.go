@@ -0,0 +1,128 @@
+package reconcilers_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/netobserv/network-observability-operator/controllers/controllerstest"
+	"github.com/netobserv/network-observability-operator/controllers/reconcilers"
+)
+
+func getDesiredDeployment() *appsv1.Deployment {
+	replicas := int32(2)
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "netobserv",
+			Labels:    map[string]string{"app": "test"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{"config": "digest"},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:    "test",
+						Image:   "quay.io/netobserv/test:main",
+						Command: []string{"/test", "-v"},
+						Resources: corev1.ResourceRequirements{
+							Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+						},
+						ReadinessProbe: &corev1.Probe{
+							Handler: corev1.Handler{
+								HTTPGet: &corev1.HTTPGetAction{Path: "/ready", Port: intstr.FromInt(8080)},
+							},
+						},
+					}},
+					NodeSelector: map[string]string{"kubernetes.io/os": "linux"},
+				},
+			},
+		},
+	}
+}
+
+func TestNeedsUpdate(t *testing.T) {
+	assert := assert.New(t)
+
+	current := controllerstest.AsWritten(getDesiredDeployment()).(*appsv1.Deployment)
+	assert.NotEmpty(current.Annotations[reconcilers.DesiredDigestAnnotation])
+	assert.False(reconcilers.NeedsUpdate(current, getDesiredDeployment()))
+
+	//written before the drift detection
+	depl := getDesiredDeployment()
+	assert.True(reconcilers.NeedsUpdate(depl, getDesiredDeployment()))
+
+	//server defaults are ignored
+	depl = current.DeepCopy()
+	depl.Generation = 3
+	depl.Spec.RevisionHistoryLimit = &[]int32{10}[0]
+	depl.Spec.Template.Spec.Containers[0].ReadinessProbe.TimeoutSeconds = 1
+	depl.Spec.Template.Spec.Containers[0].ReadinessProbe.HTTPGet.Scheme = corev1.URISchemeHTTP
+	depl.Spec.Template.Spec.Containers[0].Resources.Limits[corev1.ResourceMemory] = resource.MustParse("0.5Gi")
+	assert.False(reconcilers.NeedsUpdate(depl, getDesiredDeployment()))
+
+	//annotations, status and metadata added by other controllers are ignored
+	depl = current.DeepCopy()
+	depl.Annotations["deployment.kubernetes.io/revision"] = "2"
+	depl.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"] = "2022-03-01T00:00:00Z"
+	depl.OwnerReferences = []metav1.OwnerReference{{Kind: "FlowCollector", Name: "cluster"}}
+	depl.Status.Conditions = []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable}}
+	assert.False(reconcilers.NeedsUpdate(depl, getDesiredDeployment()))
+
+	//added elements and entries are detected
+	for name, add := range map[string]func(*appsv1.Deployment){
+		"label": func(d *appsv1.Deployment) { d.Labels["injected"] = "true" },
+		"env": func(d *appsv1.Deployment) {
+			d.Spec.Template.Spec.Containers[0].Env = []corev1.EnvVar{{Name: "DEBUG", Value: "true"}}
+		},
+		"toleration": func(d *appsv1.Deployment) {
+			d.Spec.Template.Spec.Tolerations = []corev1.Toleration{{Operator: corev1.TolerationOpExists}}
+		},
+		"volume": func(d *appsv1.Deployment) {
+			d.Spec.Template.Spec.Volumes = []corev1.Volume{{Name: "debug"}}
+		},
+		"argument": func(d *appsv1.Deployment) {
+			d.Spec.Template.Spec.Containers[0].Command = append(d.Spec.Template.Spec.Containers[0].Command, "-vv")
+		},
+		"node selector": func(d *appsv1.Deployment) { d.Spec.Template.Spec.NodeSelector["gpu"] = "true" },
+	} {
+		depl = current.DeepCopy()
+		add(depl)
+		assert.Truef(reconcilers.NeedsUpdate(depl, getDesiredDeployment()), "added %s not detected", name)
+	}
+
+	//the fields allocated by the server are ignored
+	svc := corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "netobserv"},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 8080}}},
+	}
+	currentSvc := controllerstest.AsWritten(&svc).(*corev1.Service)
+	currentSvc.Spec.ClusterIPs = []string{"172.30.0.10"}
+	currentSvc.Spec.IPFamilies = []corev1.IPFamily{corev1.IPv4Protocol}
+	assert.False(reconcilers.NeedsUpdate(currentSvc, &svc))
+
+	//removed fields of the desired state are detected
+	desired := getDesiredDeployment()
+	desired.Spec.Template.Spec.NodeSelector = nil
+	assert.True(reconcilers.NeedsUpdate(current, desired))
+
+	//modified fields are detected
+	mutations := controllerstest.Mutations(current, getDesiredDeployment())
+	assert.Contains(mutations, ".Spec.Template.Spec.Containers[0].Command[1]")
+	assert.Contains(mutations, ".Spec.Template.Spec.Containers[0].Resources.Limits[memory]")
+	assert.Contains(mutations, ".ObjectMeta.Labels[app]")
+	assert.Contains(mutations, ".Spec.Replicas")
+	assert.Contains(mutations, ".Spec.Template.Spec.Containers[0].Command[+]")
+	assert.Contains(mutations, ".ObjectMeta.Labels[+]")
+	assert.NotContains(mutations, ".Spec.Template.ObjectMeta.Annotations[+]")
+	assert.NotContains(mutations, ".ObjectMeta.Name")
+	assert.Empty(controllerstest.UndetectedDrifts(getDesiredDeployment()))
+}
@@ -12,7 +12,7 @@ import (
 
 // NamespacedObjectManager provides some helpers to manage (fetch, delete) namespace-scoped objects
 type NamespacedObjectManager struct {
-	client            client.Client
+	client            ClientHelper
 	Namespace         string
 	PreviousNamespace string
 	managedObjects    []managedObject
@@ -25,7 +25,7 @@ type managedObject struct {
 	found       bool
 }
 
-func NewNamespacedObjectManager(cl client.Client, ns, prevNS string) *NamespacedObjectManager {
+func NewNamespacedObjectManager(cl ClientHelper, ns, prevNS string) *NamespacedObjectManager {
 	return &NamespacedObjectManager{
 		client:            cl,
 		Namespace:         ns,
@@ -68,29 +68,27 @@ func (m *NamespacedObjectManager) FetchAll(ctx context.Context) error {
 // CleanupNamespace removes all managed objects (registered using AddManagedObject) from the previous namespace.
 func (m *NamespacedObjectManager) CleanupNamespace(ctx context.Context) {
 	namespace := m.PreviousNamespace
-	log := log.FromContext(ctx)
 	for _, obj := range m.managedObjects {
 		ref := obj.placeholder.DeepCopyObject().(client.Object)
 		ref.SetName(obj.name)
 		ref.SetNamespace(namespace)
-		log.Info("Deleting old "+obj.kind, "Namespace", namespace, "Name", obj.name)
-		err := m.client.Delete(ctx, ref)
-		if err != nil {
-			log.Error(err, "Failed to delete old "+obj.kind, "Namespace", namespace, "Name", obj.name)
-		}
+		// Errors are logged: the cleanup of the previous namespace is best effort
+		_ = m.client.DeleteIfExists(ctx, ref)
 	}
 }
 
 // TryDelete is an helper function that tries to delete the provided object previously loaded using FetchAll.
 func (m *NamespacedObjectManager) TryDelete(ctx context.Context, obj client.Object) {
 	if m.Exists(obj) {
-		log := log.FromContext(ctx)
-		kind := reflect.TypeOf(obj).String()
-		log.Info("Deleting old "+kind, "Namespace", obj.GetNamespace(), "Name", obj.GetName())
-		err := m.client.Delete(ctx, obj)
-		if err != nil {
-			log.Error(err, "Failed to delete old "+kind, "Namespace", obj.GetNamespace(), "Name", obj.GetName())
-		}
+		// Errors are logged
+		_ = m.client.DeleteIfExists(ctx, obj)
+	}
+}
+
+// DeleteAll tries to delete all managed objects (registered using AddManagedObject) previously loaded using FetchAll.
+func (m *NamespacedObjectManager) DeleteAll(ctx context.Context) {
+	for _, obj := range m.managedObjects {
+		m.TryDelete(ctx, obj.placeholder)
 	}
 }
 
@@ -0,0 +1,53 @@
+package reconcilers
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+
+// VolumesContentDigest returns a digest of the contents of the Secrets and ConfigMaps that the provided volumes
+// mount from the given namespace. Set as a pod template annotation, it triggers a rollout when a certificate or
+// a credential is rotated. It is empty when there is no such volume.
+func (c *ClientHelper) VolumesContentDigest(ctx context.Context, ns string, volumes []corev1.Volume) (string, error) {
+	hasher := fnv.New64a()
+	found := false
+	for i := range volumes {
+		vol := &volumes[i]
+		var content interface{}
+		switch {
+		case vol.Secret != nil:
+			secret := corev1.Secret{}
+			if err := c.Get(ctx, types.NamespacedName{Name: vol.Secret.SecretName, Namespace: ns}, &secret); err != nil {
+				return "", err
+			}
+			content = secret.Data
+		case vol.ConfigMap != nil:
+			cm := corev1.ConfigMap{}
+			if err := c.Get(ctx, types.NamespacedName{Name: vol.ConfigMap.Name, Namespace: ns}, &cm); err != nil {
+				return "", err
+			}
+			content = []interface{}{cm.Data, cm.BinaryData}
+		default:
+			continue
+		}
+		// Maps are marshalled with sorted keys, so that the digest is stable
+		b, err := json.Marshal(content)
+		if err != nil {
+			return "", err
+		}
+		_, _ = hasher.Write([]byte(vol.Name))
+		_, _ = hasher.Write(b)
+		found = true
+	}
+	if !found {
+		return "", nil
+	}
+	return strconv.FormatUint(hasher.Sum64(), 36), nil
+}
@@ -0,0 +1,62 @@
+package reconcilers
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DeploymentProgress tells whether the provided deployment has completed its rollout. When it hasn't,
+// a message describing the rollout progress is returned.
+func DeploymentProgress(d *appsv1.Deployment) (bool, string) {
+	if d.Generation > d.Status.ObservedGeneration {
+		return false, fmt.Sprintf("Deployment %s: waiting for the latest spec to be observed", d.Name)
+	}
+	desired := int32(1)
+	if d.Spec.Replicas != nil {
+		desired = *d.Spec.Replicas
+	}
+	if d.Status.UpdatedReplicas < desired {
+		return false, fmt.Sprintf("Deployment %s: %d out of %d replicas updated", d.Name, d.Status.UpdatedReplicas, desired)
+	}
+	if d.Status.Replicas > d.Status.UpdatedReplicas {
+		return false, fmt.Sprintf("Deployment %s: %d old replicas pending termination", d.Name, d.Status.Replicas-d.Status.UpdatedReplicas)
+	}
+	if d.Status.AvailableReplicas < desired {
+		return false, fmt.Sprintf("Deployment %s: %d out of %d replicas available", d.Name, d.Status.AvailableReplicas, desired)
+	}
+	return true, fmt.Sprintf("Deployment %s: %d replicas available", d.Name, d.Status.AvailableReplicas)
+}
+
+// DaemonSetProgress tells whether the provided daemon set has completed its rollout. When it hasn't,
+// a message describing the rollout progress is returned.
+func DaemonSetProgress(ds *appsv1.DaemonSet) (bool, string) {
+	if ds.Generation > ds.Status.ObservedGeneration {
+		return false, fmt.Sprintf("DaemonSet %s: waiting for the latest spec to be observed", ds.Name)
+	}
+	desired := ds.Status.DesiredNumberScheduled
+	if ds.Status.UpdatedNumberScheduled < desired {
+		return false, fmt.Sprintf("DaemonSet %s: %d out of %d pods updated", ds.Name, ds.Status.UpdatedNumberScheduled, desired)
+	}
+	if ds.Status.NumberAvailable < desired {
+		return false, fmt.Sprintf("DaemonSet %s: %d out of %d pods available", ds.Name, ds.Status.NumberAvailable, desired)
+	}
+	return true, fmt.Sprintf("DaemonSet %s: %d pods available", ds.Name, ds.Status.NumberAvailable)
+}
+
+// PodSpecDigest returns a digest of the provided pod spec. Set as a pod template annotation, it allows detecting
+// any configuration change without comparing with the pod specs fetched from the API, which contain server-side
+// defaults, and it triggers a rollout when it changes.
+func PodSpecDigest(spec *corev1.PodSpec) string {
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return ""
+	}
+	hasher := fnv.New64a()
+	_, _ = hasher.Write(b)
+	return strconv.FormatUint(hasher.Sum64(), 36)
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// secretWatcher watches the Secrets of the namespace where the components are deployed, which hold the
+// certificates and credentials that the FlowCollector may refer to, rather than all the Secrets of the cluster.
+// A cache restricted to this namespace is started the first time it is watched; the caches of the previous
+// namespaces are kept until the operator restarts. The Secrets are otherwise read directly from the API server.
+type secretWatcher struct {
+	mutex      sync.Mutex
+	mgr        ctrl.Manager
+	controller controller.Controller
+	handler    handler.EventHandler
+	namespaces map[string]bool
+}
+
+func newSecretWatcher(mgr ctrl.Manager, c controller.Controller, h handler.EventHandler) *secretWatcher {
+	return &secretWatcher{
+		mgr:        mgr,
+		controller: c,
+		handler:    h,
+		namespaces: map[string]bool{},
+	}
+}
+
+// watch starts watching the Secrets of the provided namespace, unless already done
+func (w *secretWatcher) watch(ns string) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.namespaces[ns] {
+		return nil
+	}
+	nsCache, err := cache.New(w.mgr.GetConfig(), cache.Options{
+		Scheme:    w.mgr.GetScheme(),
+		Mapper:    w.mgr.GetRESTMapper(),
+		Namespace: ns,
+	})
+	if err != nil {
+		return err
+	}
+	// Once the manager is started, the cache is started when added
+	if err := w.mgr.Add(nsCache); err != nil {
+		return err
+	}
+	if err := w.controller.Watch(source.NewKindWithCache(&corev1.Secret{}, nsCache), w.handler); err != nil {
+		return err
+	}
+	w.namespaces[ns] = true
+	return nil
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+)
+
+const flowCollectorCRDName = "flowcollectors.flows.netobserv.io"
+
+// crdGVK is used to manipulate CRDs as unstructured objects, which avoids depending on the apiextensions API module
+var crdGVK = schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}
+
+// migrationRetryInterval is the period after which a failed storage migration is attempted again
+const migrationRetryInterval = 10 * time.Second
+
+// StorageVersionMigrator rewrites the FlowCollector resources that are still stored with a former API version,
+// then removes this version from the CRD stored versions, so that it can be safely dropped in a future release.
+// It runs once, when the manager starts.
+type StorageVersionMigrator struct {
+	client client.Client
+	// reader reads directly from the API server, as the CRD isn't in the manager cache
+	reader client.Reader
+}
+
+//+kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,resourceNames=flowcollectors.flows.netobserv.io,verbs=get
+//+kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions/status,resourceNames=flowcollectors.flows.netobserv.io,verbs=update
+
+func NewStorageVersionMigrator(client client.Client, reader client.Reader) *StorageVersionMigrator {
+	return &StorageVersionMigrator{client: client, reader: reader}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, so that only the leader migrates resources
+func (m *StorageVersionMigrator) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable. Failures, e.g. because the conversion webhook isn't served yet,
+// are retried until the migration succeeds or the manager stops.
+func (m *StorageVersionMigrator) Start(ctx context.Context) error {
+	rlog := log.FromContext(ctx).WithName("storage-migration")
+	err := wait.PollImmediateUntil(migrationRetryInterval, func() (bool, error) {
+		if err := m.migrate(ctx); err != nil {
+			rlog.Error(err, "FlowCollector storage version migration failed, retrying")
+			return false, nil
+		}
+		return true, nil
+	}, ctx.Done())
+	if err == wait.ErrWaitTimeout {
+		// The manager is stopping
+		return nil
+	}
+	return err
+}
+
+func (m *StorageVersionMigrator) migrate(ctx context.Context) error {
+	rlog := log.FromContext(ctx).WithName("storage-migration")
+	crd := unstructured.Unstructured{}
+	crd.SetGroupVersionKind(crdGVK)
+	if err := m.reader.Get(ctx, types.NamespacedName{Name: flowCollectorCRDName}, &crd); err != nil {
+		return err
+	}
+	storedVersions, _, err := unstructured.NestedStringSlice(crd.Object, "status", "storedVersions")
+	if err != nil {
+		return err
+	}
+	storageVersion := flowsv1beta1.GroupVersion.Version
+	if len(storedVersions) == 1 && storedVersions[0] == storageVersion {
+		rlog.V(1).Info("No FlowCollector storage version migration needed")
+		return nil
+	}
+	rlog.Info("Migrating FlowCollector storage version", "from", storedVersions, "to", storageVersion)
+
+	list := flowsv1beta1.FlowCollectorList{}
+	if err := m.reader.List(ctx, &list); err != nil {
+		return err
+	}
+	for i := range list.Items {
+		key := types.NamespacedName{Name: list.Items[i].Name}
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			fc := flowsv1beta1.FlowCollector{}
+			if err := m.reader.Get(ctx, key, &fc); err != nil {
+				return client.IgnoreNotFound(err)
+			}
+			// An update without any change is enough to get the resource written with the storage version
+			return m.client.Update(ctx, &fc)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := unstructured.SetNestedStringSlice(crd.Object, []string{storageVersion}, "status", "storedVersions"); err != nil {
+		return err
+	}
+	if err := m.client.Status().Update(ctx, &crd); err != nil {
+		return err
+	}
+	rlog.Info("FlowCollector storage version migration done", "migrated", len(list.Items))
+	return nil
+}
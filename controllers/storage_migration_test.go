@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+)
+
+func crdWithStoredVersions(versions ...interface{}) *unstructured.Unstructured {
+	crd := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": flowCollectorCRDName},
+		"status":   map[string]interface{}{"storedVersions": versions},
+	}}
+	crd.SetGroupVersionKind(crdGVK)
+	return crd
+}
+
+func TestStorageVersionMigration(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	scheme := runtime.NewScheme()
+	require.NoError(clientgoscheme.AddToScheme(scheme))
+	require.NoError(flowsv1beta1.AddToScheme(scheme))
+	fc := &flowsv1beta1.FlowCollector{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
+	cl := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(fc, crdWithStoredVersions("v1alpha1", "v1beta1")).
+		Build()
+	before := flowsv1beta1.FlowCollector{}
+	require.NoError(cl.Get(context.Background(), types.NamespacedName{Name: "cluster"}, &before))
+
+	require.NoError(NewStorageVersionMigrator(cl, cl).migrate(context.Background()))
+
+	// The FlowCollector has been rewritten
+	after := flowsv1beta1.FlowCollector{}
+	require.NoError(cl.Get(context.Background(), types.NamespacedName{Name: "cluster"}, &after))
+	assert.NotEqual(before.ResourceVersion, after.ResourceVersion)
+	assert.Equal(before.Spec, after.Spec)
+
+	// The former version has been removed from the CRD stored versions
+	crd := unstructured.Unstructured{}
+	crd.SetGroupVersionKind(crdGVK)
+	require.NoError(cl.Get(context.Background(), types.NamespacedName{Name: flowCollectorCRDName}, &crd))
+	stored, _, err := unstructured.NestedStringSlice(crd.Object, "status", "storedVersions")
+	require.NoError(err)
+	assert.Equal([]string{"v1beta1"}, stored)
+
+	// Once migrated, resources aren't rewritten anymore
+	require.NoError(NewStorageVersionMigrator(cl, cl).migrate(context.Background()))
+	again := flowsv1beta1.FlowCollector{}
+	require.NoError(cl.Get(context.Background(), types.NamespacedName{Name: "cluster"}, &again))
+	assert.Equal(after.ResourceVersion, again.ResourceVersion)
+}
@@ -18,14 +18,12 @@ package controllers
 
 import (
 	"context"
-	"net"
 	"path/filepath"
 	"testing"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	osv1alpha1 "github.com/openshift/api/console/v1alpha1"
-	"github.com/stretchr/testify/mock"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -38,7 +36,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
-	flowsv1alpha1 "github.com/netobserv/network-observability-operator/api/v1alpha1"
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -51,7 +49,6 @@ var (
 	k8sClient                 client.Client
 	testEnv                   *envtest.Environment
 	cancel                    context.CancelFunc
-	ipResolver                ipResolverMock
 	testCnoNamespace          string
 	testOvsFlowsConfigMapName string
 )
@@ -82,7 +79,7 @@ var _ = BeforeSuite(func() {
 	Expect(err).NotTo(HaveOccurred())
 	Expect(cfg).NotTo(BeNil())
 
-	err = flowsv1alpha1.AddToScheme(scheme.Scheme)
+	err = flowsv1beta1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
 
 	err = corev1.AddToScheme(scheme.Scheme)
@@ -138,21 +135,15 @@ func prepareNamespaces() error {
 	})
 }
 
-// NewTestFlowCollectorReconciler allows mocking the IP resolutor of a
+// NewTestFlowCollectorReconciler allows mocking the Loki readiness check of a
 // FlowCollectorReconciler
 func NewTestFlowCollectorReconciler(client client.Client, scheme *runtime.Scheme) *FlowCollectorReconciler {
 	return &FlowCollectorReconciler{
-		Client:   client,
-		Scheme:   scheme,
-		lookupIP: ipResolver.LookupIP,
+		Client:         client,
+		Scheme:         scheme,
+		forceOwnership: true,
+		checkLoki: func(context.Context, *flowsv1beta1.FlowCollectorLoki, string) error {
+			return nil
+		},
 	}
 }
-
-type ipResolverMock struct {
-	mock.Mock
-}
-
-func (ipr *ipResolverMock) LookupIP(host string) ([]net.IP, error) {
-	m := ipr.Called(host)
-	return m.Get(0).([]net.IP), m.Error(1)
-}
@@ -29,10 +29,12 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	flowsv1alpha1 "github.com/netobserv/network-observability-operator/api/v1alpha1"
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
 	"github.com/netobserv/network-observability-operator/controllers"
 	osv1alpha1 "github.com/openshift/api/console/v1alpha1"
 	//+kubebuilder:scaffold:imports
@@ -46,6 +48,7 @@ var (
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(flowsv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(flowsv1beta1.AddToScheme(scheme))
 	utilruntime.Must(corev1.AddToScheme(scheme))
 	utilruntime.Must(osv1alpha1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
@@ -55,11 +58,19 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var webhookCertDir string
+	var forceOwnership bool
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.StringVar(&webhookCertDir, "webhook-cert-dir", "",
+		"The directory containing the webhook server certificate (tls.crt) and key (tls.key). "+
+			"Defaults to /tmp/k8s-webhook-server/serving-certs.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.BoolVar(&forceOwnership, "force-ownership", true,
+		"Take over the fields of the operator-managed resources that are modified by other field managers. "+
+			"When disabled, such conflicts are reported as errors in the FlowCollector status.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -72,20 +83,37 @@ func main() {
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
 		Port:                   9443,
+		CertDir:                webhookCertDir,
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "7a7ecdcd.netobserv.io",
+		// The Secrets are read directly rather than cached, so that the Secrets of the whole cluster aren't kept in
+		// memory. Those that the FlowCollector refers to are watched in the namespace of the components only.
+		ClientDisableCacheFor: []client.Object{&corev1.Secret{}},
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
-	if err = controllers.NewFlowCollectorReconciler(mgr.GetClient(), mgr.GetScheme()).
+	if err = controllers.NewFlowCollectorReconciler(mgr.GetClient(), mgr.GetScheme(), forceOwnership).
 		SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "FlowCollector")
 		os.Exit(1)
 	}
+	// Webhooks need a serving certificate, which is usually missing when running locally
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&flowsv1beta1.FlowCollector{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "FlowCollector")
+			os.Exit(1)
+		}
+	}
+	// The FlowCollectors are migrated to the storage version even when the webhooks aren't served by this process:
+	// the conversion relies on the webhook registered in the CRD, and failures are retried until it is available
+	if err = mgr.Add(controllers.NewStorageVersionMigrator(mgr.GetClient(), mgr.GetAPIReader())); err != nil {
+		setupLog.Error(err, "unable to set up storage version migration")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -0,0 +1,159 @@
+// Package certificates generates the CA and the serving certificates issued by the operator, when no other issuer
+// is available, and tells when they must be renewed
+package certificates
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// KeyPair is a PEM encoded certificate and its private key
+type KeyPair struct {
+	CertPEM []byte
+	KeyPEM  []byte
+}
+
+// NewCA returns a self-signed CA, valid from now for the provided duration
+func NewCA(commonName string, now time.Time, validity time.Duration) (*KeyPair, error) {
+	template, err := newTemplate(commonName, now, validity)
+	if err != nil {
+		return nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return encode(template, template, &key.PublicKey, key, key)
+}
+
+// NewServingCertificate returns a serving certificate for the provided DNS names, signed by the provided CA and
+// valid from now for the provided duration. It is not valid beyond the CA expiry.
+func NewServingCertificate(ca *KeyPair, dnsNames []string, now time.Time, validity time.Duration) (*KeyPair, error) {
+	if len(dnsNames) == 0 {
+		return nil, errors.New("a serving certificate requires at least one DNS name")
+	}
+	caCert, err := ParseCertificate(ca.CertPEM)
+	if err != nil {
+		return nil, err
+	}
+	caKey, err := parseKey(ca.KeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	template, err := newTemplate(dnsNames[0], now, validity)
+	if err != nil {
+		return nil, err
+	}
+	if template.NotAfter.After(caCert.NotAfter) {
+		template.NotAfter = caCert.NotAfter
+	}
+	template.DNSNames = dnsNames
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return encode(template, caCert, &key.PublicKey, caKey, key)
+}
+
+// ParseCertificate returns the first certificate of a PEM bundle
+func ParseCertificate(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no PEM encoded certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// RenewalTime returns the time from which a certificate is renewed: when two thirds of its validity period have
+// elapsed, which leaves time to roll it out before its expiry
+func RenewalTime(cert *x509.Certificate) time.Time {
+	return cert.NotBefore.Add(cert.NotAfter.Sub(cert.NotBefore) * 2 / 3)
+}
+
+// IsValid tells whether a key pair can still be used at the provided time, without being renewed. When a CA is
+// provided, the certificate must also be signed by it.
+func IsValid(pair *KeyPair, ca *x509.Certificate, now time.Time) bool {
+	cert, err := ParseCertificate(pair.CertPEM)
+	if err != nil {
+		return false
+	}
+	if _, err := parseKey(pair.KeyPEM); err != nil {
+		return false
+	}
+	if now.Before(cert.NotBefore) || !now.Before(RenewalTime(cert)) {
+		return false
+	}
+	return ca == nil || cert.CheckSignatureFrom(ca) == nil
+}
+
+// MatchesDNSNames tells whether a certificate is issued for exactly the provided DNS names
+func MatchesDNSNames(certPEM []byte, dnsNames []string) bool {
+	cert, err := ParseCertificate(certPEM)
+	if err != nil || len(cert.DNSNames) != len(dnsNames) {
+		return false
+	}
+	for i := range dnsNames {
+		if cert.DNSNames[i] != dnsNames[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func newTemplate(commonName string, now time.Time, validity time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		// Tolerates clock skews between the operator and the clients
+		NotBefore: now.Add(-time.Hour),
+		NotAfter:  now.Add(validity),
+	}, nil
+}
+
+func encode(template, parent *x509.Certificate, pub *ecdsa.PublicKey, signer, key *ecdsa.PrivateKey) (*KeyPair, error) {
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, signer)
+	if err != nil {
+		return nil, fmt.Errorf("can't create certificate %s: %w", template.Subject.CommonName, err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyPair{
+		CertPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		KeyPEM:  pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+	}, nil
+}
+
+func parseKey(keyPEM []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, errors.New("no PEM encoded private key found")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return ecKey, nil
+}
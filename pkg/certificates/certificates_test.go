@@ -0,0 +1,79 @@
+package certificates
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServingCertificate(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	now := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	ca, err := NewCA("netobserv-ca", now, 90*24*time.Hour)
+	require.NoError(err)
+	caCert, err := ParseCertificate(ca.CertPEM)
+	require.NoError(err)
+	assert.True(caCert.IsCA)
+
+	serving, err := NewServingCertificate(ca, []string{"plugin.netobserv.svc", "netobserv.example.com"}, now, 30*24*time.Hour)
+	require.NoError(err)
+	_, err = tls.X509KeyPair(serving.CertPEM, serving.KeyPEM)
+	require.NoError(err)
+	cert, err := ParseCertificate(serving.CertPEM)
+	require.NoError(err)
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	_, err = cert.Verify(x509.VerifyOptions{DNSName: "netobserv.example.com", Roots: roots, CurrentTime: now})
+	assert.NoError(err)
+
+	// The serving certificate doesn't outlive its CA
+	serving, err = NewServingCertificate(ca, []string{"plugin.netobserv.svc"}, now, 365*24*time.Hour)
+	require.NoError(err)
+	cert, err = ParseCertificate(serving.CertPEM)
+	require.NoError(err)
+	assert.Equal(caCert.NotAfter, cert.NotAfter)
+
+	assert.True(MatchesDNSNames(serving.CertPEM, []string{"plugin.netobserv.svc"}))
+	assert.False(MatchesDNSNames(serving.CertPEM, []string{"plugin.netobserv.svc", "netobserv.example.com"}))
+
+	_, err = NewServingCertificate(ca, nil, now, time.Hour)
+	assert.Error(err)
+}
+
+func TestIsValid(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	now := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	ca, err := NewCA("netobserv-ca", now, 90*24*time.Hour)
+	require.NoError(err)
+	caCert, err := ParseCertificate(ca.CertPEM)
+	require.NoError(err)
+	serving, err := NewServingCertificate(ca, []string{"plugin.netobserv.svc"}, now, 30*24*time.Hour)
+	require.NoError(err)
+	cert, err := ParseCertificate(serving.CertPEM)
+	require.NoError(err)
+
+	// Renewed after two thirds of the validity period
+	assert.Equal(cert.NotBefore.Add(cert.NotAfter.Sub(cert.NotBefore)*2/3), RenewalTime(cert))
+	assert.True(IsValid(serving, caCert, now))
+	assert.False(IsValid(serving, caCert, RenewalTime(cert)))
+
+	// Signed by another CA
+	otherCA, err := NewCA("other-ca", now, 90*24*time.Hour)
+	require.NoError(err)
+	otherCACert, err := ParseCertificate(otherCA.CertPEM)
+	require.NoError(err)
+	assert.False(IsValid(serving, otherCACert, now))
+	assert.True(IsValid(ca, nil, now))
+
+	// Invalid contents
+	assert.False(IsValid(&KeyPair{CertPEM: serving.CertPEM}, caCert, now))
+	assert.False(IsValid(&KeyPair{CertPEM: []byte("foo"), KeyPEM: serving.KeyPEM}, caCert, now))
+}
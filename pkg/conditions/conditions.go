@@ -0,0 +1,80 @@
+// Package conditions defines the condition types and reasons that are reported in the FlowCollector status
+package conditions
+
+import (
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition types
+const (
+	TypeReady              = "Ready"
+	TypeCollectorReady     = "CollectorReady"
+	TypeConsolePluginReady = "ConsolePluginReady"
+	TypeOVSConfigured      = "OVSConfigured"
+	TypeEBPFAgentReady     = "EBPFAgentReady"
+	TypeLokiReachable      = "LokiReachable"
+)
+
+// Condition reasons
+const (
+	ReasonReady           = "Ready"
+	ReasonNotReady        = "NotReady"
+	ReasonDeploying       = "Deploying"
+	ReasonReconcileFailed = "ReconcileFailed"
+	ReasonConfigured      = "Configured"
+	ReasonReachable       = "Reachable"
+	ReasonUnreachable     = "Unreachable"
+	ReasonUnmanaged       = "Unmanaged"
+	ReasonFieldConflict   = "FieldConflict"
+)
+
+// readinessTypes lists the condition types that are aggregated into the Ready condition.
+// LokiReachable is deliberately excluded: the operator doesn't necessarily have the same network
+// view as the collector pods, so it is only reported for information.
+var readinessTypes = []string{TypeCollectorReady, TypeConsolePluginReady, TypeOVSConfigured, TypeEBPFAgentReady}
+
+// New builds a condition of the given type, reporting True or False according to the status argument
+func New(condType string, status bool, reason, message string) metav1.Condition {
+	c := metav1.Condition{
+		Type:    condType,
+		Status:  metav1.ConditionFalse,
+		Reason:  reason,
+		Message: message,
+	}
+	if status {
+		c.Status = metav1.ConditionTrue
+	}
+	return c
+}
+
+// ReconcileFailed builds a False condition of the given type for a component that could not be reconciled.
+// Conflicts with the fields managed by other field managers, which the operator doesn't take over unless its
+// ownership is forced, have their own reason.
+func ReconcileFailed(condType string, err error) metav1.Condition {
+	if errors.IsConflict(err) {
+		return New(condType, false, ReasonFieldConflict, err.Error())
+	}
+	return New(condType, false, ReasonReconcileFailed, err.Error())
+}
+
+// Aggregate builds the Ready condition from the component conditions found in the provided list.
+// Components that don't report any condition (e.g. a disabled console plugin) are ignored.
+func Aggregate(conds []metav1.Condition) metav1.Condition {
+	var notReady []string
+	for _, t := range readinessTypes {
+		c := meta.FindStatusCondition(conds, t)
+		if c != nil && c.Status != metav1.ConditionTrue {
+			notReady = append(notReady, t)
+		}
+	}
+	if len(notReady) == 0 {
+		return New(TypeReady, true, ReasonReady, "All components are ready")
+	}
+	msg := "Some components are not ready:"
+	for _, t := range notReady {
+		msg += " " + t
+	}
+	return New(TypeReady, false, ReasonNotReady, msg)
+}
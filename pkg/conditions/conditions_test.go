@@ -0,0 +1,64 @@
+package conditions
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestAggregateAllReady(t *testing.T) {
+	assert := assert.New(t)
+
+	ready := Aggregate([]metav1.Condition{
+		New(TypeCollectorReady, true, ReasonReady, ""),
+		New(TypeConsolePluginReady, true, ReasonReady, ""),
+		New(TypeOVSConfigured, true, ReasonConfigured, ""),
+		// Loki reachability is informative only
+		New(TypeLokiReachable, false, ReasonUnreachable, ""),
+	})
+	assert.Equal(TypeReady, ready.Type)
+	assert.Equal(metav1.ConditionTrue, ready.Status)
+	assert.Equal(ReasonReady, ready.Reason)
+}
+
+func TestAggregateIgnoresMissingComponents(t *testing.T) {
+	assert := assert.New(t)
+
+	// e.g. console plugin disabled
+	ready := Aggregate([]metav1.Condition{
+		New(TypeCollectorReady, true, ReasonReady, ""),
+		New(TypeOVSConfigured, true, ReasonConfigured, ""),
+	})
+	assert.Equal(metav1.ConditionTrue, ready.Status)
+}
+
+func TestAggregateNotReady(t *testing.T) {
+	assert := assert.New(t)
+
+	ready := Aggregate([]metav1.Condition{
+		New(TypeCollectorReady, false, ReasonDeploying, ""),
+		New(TypeConsolePluginReady, true, ReasonReady, ""),
+		ReconcileFailed(TypeOVSConfigured, errors.New("boom")),
+	})
+	assert.Equal(metav1.ConditionFalse, ready.Status)
+	assert.Equal(ReasonNotReady, ready.Reason)
+	assert.Equal("Some components are not ready: CollectorReady OVSConfigured", ready.Message)
+}
+
+func TestReconcileFailedReportsConflicts(t *testing.T) {
+	assert := assert.New(t)
+
+	failed := ReconcileFailed(TypeCollectorReady, errors.New("boom"))
+	assert.Equal(ReasonReconcileFailed, failed.Reason)
+
+	conflict := kerrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "test", errors.New("conflict"))
+	failed = ReconcileFailed(TypeCollectorReady, fmt.Errorf("ConfigMap netobserv/test is managed by other field managers: %w", conflict))
+	assert.Equal(metav1.ConditionFalse, failed.Status)
+	assert.Equal(ReasonFieldConflict, failed.Reason)
+	assert.Contains(failed.Message, "ConfigMap netobserv/test is managed by other field managers")
+}
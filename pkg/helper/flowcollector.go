@@ -0,0 +1,29 @@
+package helper
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+)
+
+// LokiEnabled tells whether the flows are stored in Loki. The setting is enabled when unset, as in
+// FlowCollectors created before it was introduced.
+func LokiEnabled(loki *flowsv1beta1.FlowCollectorLoki) bool {
+	return loki.Enable == nil || *loki.Enable
+}
+
+// ApplyScheduling sets the scheduling constraints of a pod spec. Its tolerations are kept when none are configured.
+// The pods are spread across topology domains unless they run in a DaemonSet, which has one pod per node.
+func ApplyScheduling(podSpec *corev1.PodSpec, scheduling *flowsv1beta1.FlowCollectorScheduling, daemonSet bool) {
+	podSpec.NodeSelector = scheduling.NodeSelector
+	podSpec.Affinity = scheduling.Affinity
+	if len(scheduling.Tolerations) > 0 {
+		podSpec.Tolerations = scheduling.Tolerations
+	}
+	if daemonSet {
+		podSpec.TopologySpreadConstraints = nil
+	} else {
+		podSpec.TopologySpreadConstraints = scheduling.TopologySpreadConstraints
+	}
+	podSpec.PriorityClassName = scheduling.PriorityClassName
+}
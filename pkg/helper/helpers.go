@@ -2,6 +2,11 @@
 // to perform some basic computational operations
 package helper
 
+import (
+	"strconv"
+	"time"
+)
+
 func ContainsString(slice []string, s string) bool {
 	for _, item := range slice {
 		if item == s {
@@ -10,3 +15,21 @@ func ContainsString(slice []string, s string) bool {
 	}
 	return false
 }
+
+// UnitDuration formats the provided duration with the largest of the m, s, ms or ns units that represents it
+// exactly, e.g. "10s" or "1500ms". Unlike time.Duration.String, it never combines several units, as
+// some consumers of the flows configuration don't support it.
+func UnitDuration(d time.Duration) string {
+	if d == 0 {
+		return "0s"
+	}
+	for _, unit := range []struct {
+		suffix string
+		value  time.Duration
+	}{{"m", time.Minute}, {"s", time.Second}, {"ms", time.Millisecond}} {
+		if d%unit.value == 0 {
+			return strconv.FormatInt(int64(d/unit.value), 10) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(int64(d), 10) + "ns"
+}
@@ -0,0 +1,18 @@
+package helper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnitDuration(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("10s", UnitDuration(10*time.Second))
+	assert.Equal("2m", UnitDuration(2*time.Minute))
+	assert.Equal("90s", UnitDuration(90*time.Second))
+	assert.Equal("1500ms", UnitDuration(1500*time.Millisecond))
+	assert.Equal("1001ns", UnitDuration(1001*time.Nanosecond))
+	assert.Equal("0s", UnitDuration(0))
+}
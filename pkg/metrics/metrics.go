@@ -0,0 +1,102 @@
+// Package metrics defines the operator metrics. They are registered in the controller-runtime registry, hence
+// served by the manager metrics endpoint next to the controller-runtime ones.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const namespace = "netobserv_operator"
+
+// Reconciled components
+const (
+	ComponentCollector     = "collector"
+	ComponentConsolePlugin = "console_plugin"
+	ComponentAgent         = "agent"
+	ComponentLoki          = "loki"
+	ComponentDashboard     = "dashboard"
+)
+
+// Reconcile results
+const (
+	ResultSuccess = "success"
+	ResultError   = "error"
+)
+
+// Operations on the operator-managed objects
+const (
+	OperationCreate = "create"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+)
+
+var (
+	reconcileTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconcile_total",
+		Help:      "Number of reconciliations of the FlowCollector components, per component and result",
+	}, []string{"component", "result"})
+	objectsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "objects_total",
+		Help:      "Number of operations on the operator-managed objects, per kind and operation",
+	}, []string{"kind", "operation"})
+	ovsConfigChangesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ovs_config_changes_total",
+		Help:      "Number of changes of the OVS IPFIX configuration",
+	})
+	namespaceMigrationsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "namespace_migrations_total",
+		Help:      "Number of migrations of the operator-managed objects to a new namespace",
+	})
+	lastSuccessfulReconcile = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_successful_reconcile_timestamp_seconds",
+		Help:      "Time of the last successful reconciliation of the FlowCollector, in seconds since the epoch",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		reconcileTotal,
+		objectsTotal,
+		ovsConfigChangesTotal,
+		namespaceMigrationsTotal,
+		lastSuccessfulReconcile,
+	)
+}
+
+// ObserveReconcile counts a reconciliation of a component, which failed when err isn't nil
+func ObserveReconcile(component string, err error) {
+	result := ResultSuccess
+	if err != nil {
+		result = ResultError
+	}
+	reconcileTotal.WithLabelValues(component, result).Inc()
+}
+
+// ObserveObject counts an operation on an operator-managed object of the given kind
+func ObserveObject(kind, operation string) {
+	objectsTotal.WithLabelValues(kind, operation).Inc()
+}
+
+// ObserveOVSConfigChange counts a change of the OVS IPFIX configuration
+func ObserveOVSConfigChange() {
+	ovsConfigChangesTotal.Inc()
+}
+
+// ObserveNamespaceMigration counts a migration of the operator-managed objects to a new namespace
+func ObserveNamespaceMigration() {
+	namespaceMigrationsTotal.Inc()
+}
+
+// ObserveSuccessfulReconcile records the time of a successful reconciliation of the FlowCollector, from which the
+// time since the last one can be computed, e.g. with: time() - netobserv_operator_last_successful_reconcile_timestamp_seconds
+func ObserveSuccessfulReconcile(now time.Time) {
+	lastSuccessfulReconcile.Set(float64(now.UnixNano()) / float64(time.Second))
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+func value(t *testing.T, m prometheus.Metric) float64 {
+	out := dto.Metric{}
+	require.NoError(t, m.Write(&out))
+	if out.Gauge != nil {
+		return out.Gauge.GetValue()
+	}
+	return out.Counter.GetValue()
+}
+
+func TestObserveReconcile(t *testing.T) {
+	success := value(t, reconcileTotal.WithLabelValues(ComponentCollector, ResultSuccess))
+	failure := value(t, reconcileTotal.WithLabelValues(ComponentCollector, ResultError))
+
+	ObserveReconcile(ComponentCollector, nil)
+	ObserveReconcile(ComponentCollector, errors.New("oops"))
+	ObserveReconcile(ComponentCollector, nil)
+
+	assert.Equal(t, success+2, value(t, reconcileTotal.WithLabelValues(ComponentCollector, ResultSuccess)))
+	assert.Equal(t, failure+1, value(t, reconcileTotal.WithLabelValues(ComponentCollector, ResultError)))
+}
+
+func TestObserveSuccessfulReconcile(t *testing.T) {
+	ObserveSuccessfulReconcile(time.Unix(1600000000, 500000000))
+	assert.Equal(t, 1600000000.5, value(t, lastSuccessfulReconcile))
+}
+
+func TestMetricsAreRegistered(t *testing.T) {
+	ObserveObject("DaemonSet", OperationCreate)
+	ObserveOVSConfigChange()
+	ObserveNamespaceMigration()
+	families, err := metrics.Registry.Gather()
+	require.NoError(t, err)
+	var names []string
+	for _, f := range families {
+		names = append(names, f.GetName())
+	}
+	assert.Contains(t, names, "netobserv_operator_reconcile_total")
+	assert.Contains(t, names, "netobserv_operator_objects_total")
+	assert.Contains(t, names, "netobserv_operator_ovs_config_changes_total")
+	assert.Contains(t, names, "netobserv_operator_namespace_migrations_total")
+	assert.Contains(t, names, "netobserv_operator_last_successful_reconcile_timestamp_seconds")
+}
@@ -0,0 +1,131 @@
+// Package volumes builds the pod volumes that give access to the certificates referenced in the FlowCollector
+package volumes
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+)
+
+const certificatesRoot = "/var/"
+
+// ServiceAccountTokenPath is where the token of the pod service account is mounted
+const ServiceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// Builder accumulates the volumes of a pod, and their mounts in its main container
+type Builder struct {
+	volumes []corev1.Volume
+	mounts  []corev1.VolumeMount
+}
+
+// AddCertificate adds a volume holding the referenced certificate, and returns the paths of the certificate and
+// key files, as seen from the container. The key path is empty when the reference has no key.
+func (b *Builder) AddCertificate(ref *flowsv1beta1.CertificateReference, volumeName string) (certPath, keyPath string) {
+	if ref.Name == "" {
+		return "", ""
+	}
+	dir := certificatesRoot + volumeName + "/"
+	b.volumes = append(b.volumes, buildVolume(ref, volumeName))
+	b.mounts = append(b.mounts, corev1.VolumeMount{
+		Name:      volumeName,
+		MountPath: dir,
+		ReadOnly:  true,
+	})
+	certPath = dir + ref.CertFile
+	if ref.CertKey != "" {
+		keyPath = dir + ref.CertKey
+	}
+	return certPath, keyPath
+}
+
+// AddCACertificate adds a volume holding the referenced CA certificate, and returns its path, as seen from the
+// container
+func (b *Builder) AddCACertificate(ref *flowsv1beta1.CertificateReference, volumeName string) string {
+	certPath, _ := b.AddCertificate(ref, volumeName)
+	return certPath
+}
+
+// AddSecret adds a volume holding the named Secret, and returns the path of the directory where its keys are
+// mounted, as seen from the container
+func (b *Builder) AddSecret(secretName, volumeName string) string {
+	dir := certificatesRoot + volumeName + "/"
+	b.volumes = append(b.volumes, corev1.Volume{
+		Name: volumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: secretName},
+		},
+	})
+	b.mounts = append(b.mounts, corev1.VolumeMount{
+		Name:      volumeName,
+		MountPath: dir,
+		ReadOnly:  true,
+	})
+	return dir
+}
+
+// LokiClientFiles holds the paths of the files that a Loki client needs, as seen from the container.
+// Paths are empty when the corresponding setting is unused.
+type LokiClientFiles struct {
+	TLS                bool
+	InsecureSkipVerify bool
+	CACertPath         string
+	UserCertPath       string
+	UserKeyPath        string
+	TokenPath          string
+	UsernamePath       string
+	PasswordPath       string
+}
+
+// AddLokiClientFiles adds the volumes holding the certificates and credentials that the provided Loki
+// configuration refers to, and returns their paths
+func (b *Builder) AddLokiClientFiles(loki *flowsv1beta1.FlowCollectorLoki) LokiClientFiles {
+	files := LokiClientFiles{}
+	if loki.TLS.Enable {
+		files.TLS = true
+		files.InsecureSkipVerify = loki.TLS.InsecureSkipVerify
+		if !loki.TLS.InsecureSkipVerify {
+			files.CACertPath = b.AddCACertificate(&loki.TLS.CACert, "loki-ca")
+		}
+		files.UserCertPath, files.UserKeyPath = b.AddCertificate(&loki.TLS.UserCert, "loki-user")
+	}
+	switch loki.Auth.Type {
+	case "BearerToken":
+		files.TokenPath = b.AddSecret(loki.Auth.SecretName, "loki-auth") + loki.Auth.TokenKey
+	case "ServiceAccountToken":
+		files.TokenPath = ServiceAccountTokenPath
+	case "BasicAuth":
+		dir := b.AddSecret(loki.Auth.SecretName, "loki-auth")
+		files.UsernamePath = dir + loki.Auth.UsernameKey
+		files.PasswordPath = dir + loki.Auth.PasswordKey
+	}
+	return files
+}
+
+// GetVolumes returns the volumes added so far
+func (b *Builder) GetVolumes() []corev1.Volume {
+	return b.volumes
+}
+
+// GetMounts returns the mounts of the volumes added so far
+func (b *Builder) GetMounts() []corev1.VolumeMount {
+	return b.mounts
+}
+
+func buildVolume(ref *flowsv1beta1.CertificateReference, volumeName string) corev1.Volume {
+	if ref.Type == "configmap" {
+		return corev1.Volume{
+			Name: volumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: ref.Name},
+				},
+			},
+		}
+	}
+	return corev1.Volume{
+		Name: volumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: ref.Name},
+		},
+	}
+}
@@ -0,0 +1,75 @@
+package volumes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	flowsv1beta1 "github.com/netobserv/network-observability-operator/api/v1beta1"
+)
+
+func TestBuilder(t *testing.T) {
+	assert := assert.New(t)
+
+	b := Builder{}
+	caPath := b.AddCACertificate(&flowsv1beta1.CertificateReference{
+		Type:     "configmap",
+		Name:     "kafka-ca",
+		CertFile: "ca.crt",
+	}, "kafka-ca")
+	certPath, keyPath := b.AddCertificate(&flowsv1beta1.CertificateReference{
+		Type:     "secret",
+		Name:     "kafka-user",
+		CertFile: "user.crt",
+		CertKey:  "user.key",
+	}, "kafka-user")
+	// Unset references are ignored
+	emptyPath := b.AddCACertificate(&flowsv1beta1.CertificateReference{Type: "secret"}, "loki-ca")
+
+	assert.Equal("/var/kafka-ca/ca.crt", caPath)
+	assert.Equal("/var/kafka-user/user.crt", certPath)
+	assert.Equal("/var/kafka-user/user.key", keyPath)
+	assert.Empty(emptyPath)
+
+	volumes := b.GetVolumes()
+	assert.Len(volumes, 2)
+	assert.Equal("kafka-ca", volumes[0].ConfigMap.Name)
+	assert.Equal("kafka-user", volumes[1].Secret.SecretName)
+	mounts := b.GetMounts()
+	assert.Len(mounts, 2)
+	assert.Equal("/var/kafka-ca/", mounts[0].MountPath)
+	assert.True(mounts[1].ReadOnly)
+}
+
+func TestLokiClientFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	b := Builder{}
+	files := b.AddLokiClientFiles(&flowsv1beta1.FlowCollectorLoki{})
+	assert.Equal(LokiClientFiles{}, files)
+	assert.Empty(b.GetVolumes())
+
+	files = b.AddLokiClientFiles(&flowsv1beta1.FlowCollectorLoki{
+		TLS: flowsv1beta1.ClientTLS{
+			Enable: true,
+			CACert: flowsv1beta1.CertificateReference{Type: "configmap", Name: "loki-ca", CertFile: "service-ca.crt"},
+		},
+		Auth: flowsv1beta1.LokiAuth{Type: "BasicAuth", SecretName: "loki-credentials", UsernameKey: "user", PasswordKey: "pass"},
+	})
+	assert.Equal(LokiClientFiles{
+		TLS:          true,
+		CACertPath:   "/var/loki-ca/service-ca.crt",
+		UsernamePath: "/var/loki-auth/user",
+		PasswordPath: "/var/loki-auth/pass",
+	}, files)
+	assert.Len(b.GetVolumes(), 2)
+	assert.Equal("loki-credentials", b.GetVolumes()[1].Secret.SecretName)
+
+	b = Builder{}
+	files = b.AddLokiClientFiles(&flowsv1beta1.FlowCollectorLoki{
+		TLS:  flowsv1beta1.ClientTLS{Enable: true, InsecureSkipVerify: true},
+		Auth: flowsv1beta1.LokiAuth{Type: "ServiceAccountToken"},
+	})
+	assert.Equal(LokiClientFiles{TLS: true, InsecureSkipVerify: true, TokenPath: ServiceAccountTokenPath}, files)
+	assert.Empty(b.GetVolumes())
+}